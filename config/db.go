@@ -1,9 +1,12 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 	"gorm.io/driver/mysql"
@@ -20,6 +23,60 @@ type Config struct {
 	MQTTBroker   string
 	MQTTClientID string
 	JWTSecret    string
+	DeviceAPIKey string
+
+	// Reverse proxies allowed to set X-Forwarded-For (none by default)
+	TrustedProxies []string
+
+	// Per-device HMAC keys for messages that unlock the door (device_id → key)
+	DeviceSigningKeys map[string]string
+
+	// PIN brute-force protection
+	PinMaxAttempts       int
+	PinLockoutSeconds    int
+	PinLockoutMaxSeconds int
+	PinGlobalMaxAttempts int // shared by every PIN source, so rotating IPs doesn't help
+
+	// Offline MQTT command queue
+	MQTTQueueSize          int
+	MQTTQueueMaxAgeSeconds int
+
+	// Default timezone for schedules (IANA name)
+	Timezone string
+
+	// Device command confirmation
+	CommandTimeoutSeconds int
+	CommandMaxRetries     int
+
+	// Outbound notification channels (email/chat bot are disabled when unset)
+	SMTPHost              string
+	SMTPPort              int
+	SMTPUsername          string
+	SMTPPassword          string
+	SMTPFrom              string
+	ChatBotAPIURL         string
+	ChatBotToken          string
+	NotifyMaxRetries      int
+	NotifyRateLimitPerMin int
+	NotifyTimeoutSeconds  int
+
+	// Face recognition backend: "python" (HTTP service) or "mock" (in-memory, dev only)
+	FaceBackend                string
+	PythonServiceURL           string
+	FaceTimeoutSeconds         int
+	FaceMaxRetries             int
+	FaceBreakerThreshold       int
+	FaceBreakerCooldownSeconds int
+
+	// ESP32-CAM capture storage
+	UploadDir            string
+	CaptureMaxBytes      int
+	CaptureRetentionDays int // 0 = keep forever
+
+	// Fingerprint sensor on the door unit
+	FingerprintCapacity             int
+	FingerprintEnrollTimeoutSeconds int
+	FingerprintMinConfidence        int
 }
 
 func LoadConfig() *Config {
@@ -37,10 +94,88 @@ func LoadConfig() *Config {
 		ServerPort:   getEnv("PORT", "8080"),
 		MQTTBroker:   getEnv("MQTT_BROKER", "tcp://broker.hivemq.com:1883"),
 		MQTTClientID: getEnv("MQTT_CLIENT_ID", "smarthome-backend"),
-		JWTSecret:    getEnv("JWT_SECRET", "your-secret-key"),
+		JWTSecret:    getEnv("JWT_SECRET", ""),
+		DeviceAPIKey: getEnv("DEVICE_API_KEY", ""),
+
+		TrustedProxies: getEnvList("TRUSTED_PROXIES"),
+
+		DeviceSigningKeys: getEnvKeyValues("DEVICE_SIGNING_KEYS"),
+
+		PinMaxAttempts:       getEnvInt("PIN_MAX_ATTEMPTS", 5),
+		PinLockoutSeconds:    getEnvInt("PIN_LOCKOUT_SECONDS", 30),
+		PinLockoutMaxSeconds: getEnvInt("PIN_LOCKOUT_MAX_SECONDS", 3600),
+		PinGlobalMaxAttempts: getEnvInt("PIN_GLOBAL_MAX_ATTEMPTS", 20),
+
+		MQTTQueueSize:          getEnvInt("MQTT_QUEUE_SIZE", 100),
+		MQTTQueueMaxAgeSeconds: getEnvInt("MQTT_QUEUE_MAX_AGE_SECONDS", 120),
+
+		Timezone: getEnv("TIMEZONE", "Asia/Jakarta"),
+
+		CommandTimeoutSeconds: getEnvInt("COMMAND_TIMEOUT_SECONDS", 10),
+		CommandMaxRetries:     getEnvInt("COMMAND_MAX_RETRIES", 1),
+
+		SMTPHost:              getEnv("SMTP_HOST", ""),
+		SMTPPort:              getEnvInt("SMTP_PORT", 587),
+		SMTPUsername:          getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:          getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:              getEnv("SMTP_FROM", "smarthome@localhost"),
+		ChatBotAPIURL:         getEnv("CHATBOT_API_URL", "https://api.telegram.org"),
+		ChatBotToken:          getEnv("CHATBOT_TOKEN", ""),
+		NotifyMaxRetries:      getEnvInt("NOTIFY_MAX_RETRIES", 3),
+		NotifyRateLimitPerMin: getEnvInt("NOTIFY_RATE_LIMIT_PER_MINUTE", 10),
+		NotifyTimeoutSeconds:  getEnvInt("NOTIFY_TIMEOUT_SECONDS", 10),
+
+		FaceBackend:                getEnv("FACE_BACKEND", "python"),
+		PythonServiceURL:           getEnv("PYTHON_SERVICE_URL", "http://localhost:5000"),
+		FaceTimeoutSeconds:         getEnvInt("FACE_TIMEOUT_SECONDS", 30),
+		FaceMaxRetries:             getEnvInt("FACE_MAX_RETRIES", 2),
+		FaceBreakerThreshold:       getEnvInt("FACE_BREAKER_THRESHOLD", 5),
+		FaceBreakerCooldownSeconds: getEnvInt("FACE_BREAKER_COOLDOWN_SECONDS", 30),
+
+		UploadDir:            getEnv("UPLOAD_DIR", "./uploads/camera_captures"),
+		CaptureMaxBytes:      getEnvInt("CAPTURE_MAX_BYTES", 5<<20),
+		CaptureRetentionDays: getEnvInt("CAPTURE_RETENTION_DAYS", 30),
+
+		FingerprintCapacity:             getEnvInt("FINGERPRINT_CAPACITY", 127),
+		FingerprintEnrollTimeoutSeconds: getEnvInt("FINGERPRINT_ENROLL_TIMEOUT_SECONDS", 60),
+		FingerprintMinConfidence:        getEnvInt("FINGERPRINT_MIN_CONFIDENCE", 50),
 	}
 }
 
+// Placeholder secrets that ship in examples; anything protected by them can be forged
+var knownSecrets = map[string]bool{
+	"your-secret-key":      true,
+	"jwt-secret-key":       true,
+	"secret":               true,
+	"change-me":            true,
+	"changeme":             true,
+	"change-me-device-key": true,
+	"device-key":           true,
+}
+
+// ValidateJWTSecret rejects an unset or well-known JWT_SECRET
+func (c *Config) ValidateJWTSecret() error {
+	if c.JWTSecret == "" {
+		return errors.New("JWT_SECRET is not set (generate one with: openssl rand -hex 32)")
+	}
+	if knownSecrets[c.JWTSecret] {
+		return errors.New("JWT_SECRET is a published default, set a random value (openssl rand -hex 32)")
+	}
+	return nil
+}
+
+// ValidateDeviceAPIKey rejects an unset or well-known DEVICE_API_KEY; it gates
+// every ESP32 ingest route, including the ones that unlock the door
+func (c *Config) ValidateDeviceAPIKey() error {
+	if c.DeviceAPIKey == "" {
+		return errors.New("DEVICE_API_KEY is not set (generate one with: openssl rand -hex 32)")
+	}
+	if knownSecrets[c.DeviceAPIKey] {
+		return errors.New("DEVICE_API_KEY is a published default, set a random value (openssl rand -hex 32)")
+	}
+	return nil
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -48,6 +183,43 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+		log.Printf("⚠️  Invalid integer for %s, using default %d", key, defaultValue)
+	}
+	return defaultValue
+}
+
+// getEnvList parses "a,b" into a slice; nil when unset
+func getEnvList(key string) []string {
+	var values []string
+	for _, entry := range strings.Split(os.Getenv(key), ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			values = append(values, entry)
+		}
+	}
+	return values
+}
+
+// getEnvKeyValues parses "a=1,b=2" into a map; malformed entries are skipped
+func getEnvKeyValues(key string) map[string]string {
+	values := make(map[string]string)
+	for _, entry := range strings.Split(os.Getenv(key), ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || name == "" || value == "" {
+			if entry != "" {
+				log.Printf("⚠️  Ignoring malformed entry in %s", key)
+			}
+			continue
+		}
+		values[name] = value
+	}
+	return values
+}
+
 func InitDB() *gorm.DB {
 	cfg := LoadConfig()
 
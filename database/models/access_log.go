@@ -4,19 +4,22 @@ import "time"
 
 // AccessLog represents door access history
 type AccessLog struct {
-	AccessID  uint      `gorm:"primaryKey;column:access_id" json:"access_id"`
-	UserID    *uint     `gorm:"index" json:"user_id,omitempty"`
-	Method    string    `gorm:"type:enum('face','pin','remote')" json:"method"`
-	Status    string    `gorm:"type:enum('success','failed')" json:"status"`
-	ImagePath string    `gorm:"type:text" json:"image_path,omitempty"`
-	Timestamp time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"timestamp"`
-	User      *User     `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	AccessID   uint      `gorm:"primaryKey;column:access_id" json:"access_id"`
+	UserID     *uint     `gorm:"index" json:"user_id,omitempty"`
+	Method     string    `gorm:"type:enum('face','pin','remote','fingerprint')" json:"method"`
+	Status     string    `gorm:"type:enum('success','failed')" json:"status"`
+	ImagePath  string    `gorm:"type:text" json:"image_path,omitempty"`
+	CaptureID  *uint     `gorm:"index" json:"capture_id,omitempty"`              // ESP32-CAM frame behind a face attempt
+	GuestLabel *string   `gorm:"type:varchar(100)" json:"guest_label,omitempty"` // guest code label for visitor entries
+	Timestamp  time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"timestamp"`
+	User       *User     `gorm:"foreignKey:UserID" json:"user,omitempty"`
 }
 
 // AccessLogRequest for logging access attempts
 type AccessLogRequest struct {
 	UserID    *uint  `json:"user_id"`
-	Method    string `json:"method" binding:"required,oneof=face pin remote"`
+	Method    string `json:"method" binding:"required,oneof=face pin remote fingerprint"`
 	Status    string `json:"status" binding:"required,oneof=success failed"`
 	ImagePath string `json:"image_path"`
+	CaptureID *uint  `json:"capture_id"`
 }
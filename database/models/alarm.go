@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Alarm states
+const (
+	AlarmDisarmed  = "disarmed"
+	AlarmArmedHome = "armed_home"
+	AlarmArmedAway = "armed_away"
+	AlarmTriggered = "triggered"
+)
+
+// AlarmEvent is one alarm state change. The table is append-only;
+// the current state is the latest row.
+type AlarmEvent struct {
+	ID            uint      `gorm:"primaryKey;column:id" json:"id"`
+	State         string    `gorm:"type:enum('disarmed','armed_home','armed_away','triggered');not null" json:"state"`
+	PreviousState string    `gorm:"type:varchar(20)" json:"previous_state,omitempty"`
+	Reason        string    `gorm:"type:varchar(255)" json:"reason,omitempty"`
+	Source        string    `gorm:"type:varchar(30)" json:"source"` // api, door, face
+	UserID        *uint     `json:"user_id,omitempty"`
+	ImageBase64   *string   `gorm:"column:image_base64;type:longtext" json:"image_base64,omitempty"` // snapshot that tripped the alarm
+	CreatedAt     time.Time `gorm:"default:CURRENT_TIMESTAMP;index" json:"created_at"`
+}
+
+func (AlarmEvent) TableName() string {
+	return "alarm_events"
+}
+
+// AlarmStatus is the current alarm state for the API
+type AlarmStatus struct {
+	State         string     `json:"state"`
+	ArmedState    string     `json:"armed_state,omitempty"` // mode that was armed when triggered
+	Since         *time.Time `json:"since,omitempty"`
+	ChangedBy     *uint      `json:"changed_by,omitempty"`
+	TriggerReason string     `json:"trigger_reason,omitempty"`
+}
+
+// AlarmStateRequest arms or disarms the alarm
+type AlarmStateRequest struct {
+	State string `json:"state" binding:"required,oneof=disarmed armed_home armed_away"`
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// AutomationRule fires Actions when all Conditions hold (AND).
+// Example: temperature > 30 for 300s → curtain open.
+type AutomationRule struct {
+	ID              uint           `gorm:"primaryKey;column:id" json:"id"`
+	Name            string         `gorm:"type:varchar(100);not null" json:"name"`
+	Enabled         bool           `gorm:"not null;default:true" json:"enabled"`
+	Conditions      RuleConditions `gorm:"type:json;not null" json:"conditions"`
+	Actions         RuleActions    `gorm:"type:json;not null" json:"actions"`
+	CooldownSeconds int            `gorm:"not null;default:300" json:"cooldown_seconds"`
+	LastFiredAt     *time.Time     `json:"last_fired_at,omitempty"`
+	CreatedBy       *uint          `json:"created_by,omitempty"`
+	CreatedAt       time.Time      `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt       time.Time      `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+func (AutomationRule) TableName() string {
+	return "automation_rules"
+}
+
+// RuleCondition is either a sensor comparison or a time-of-day window
+type RuleCondition struct {
+	Type string `json:"type" binding:"required,oneof=sensor time"`
+
+	// type=sensor
+	Sensor          string  `json:"sensor,omitempty" binding:"omitempty,oneof=temperature humidity gas light"`
+	Operator        string  `json:"operator,omitempty" binding:"omitempty,oneof=> >= < <= == !="`
+	Value           float64 `json:"value,omitempty"`
+	DurationSeconds int     `json:"duration_seconds,omitempty" binding:"min=0"` // must hold this long
+
+	// type=time ("HH:MM", local time; After > Before spans midnight)
+	After  string   `json:"after,omitempty" binding:"omitempty,datetime=15:04"`
+	Before string   `json:"before,omitempty" binding:"omitempty,datetime=15:04"`
+	Days   []string `json:"days,omitempty" binding:"omitempty,dive,oneof=mon tue wed thu fri sat sun"`
+}
+
+// RuleAction is a device command sent through the device controller
+type RuleAction struct {
+	Device string `json:"device" binding:"required,oneof=door lamp curtain"`
+	Action string `json:"action" binding:"required"`
+	Mode   string `json:"mode,omitempty" binding:"omitempty,oneof=auto manual"`
+}
+
+type RuleConditions []RuleCondition
+type RuleActions []RuleAction
+
+func (c RuleConditions) Value() (driver.Value, error) {
+	return jsonValue(c)
+}
+
+func (c *RuleConditions) Scan(value interface{}) error {
+	return jsonScan(value, c)
+}
+
+func (a RuleActions) Value() (driver.Value, error) {
+	return jsonValue(a)
+}
+
+func (a *RuleActions) Scan(value interface{}) error {
+	return jsonScan(value, a)
+}
+
+// AutomationRuleLog records every firing of a rule with per-action results
+type AutomationRuleLog struct {
+	ID           uint              `gorm:"primaryKey;column:id" json:"id"`
+	RuleID       uint              `gorm:"index;not null" json:"rule_id"`
+	RuleName     string            `gorm:"type:varchar(100)" json:"rule_name"`
+	TriggerType  string            `gorm:"type:varchar(30)" json:"trigger_type"` // sensor that caused the evaluation
+	TriggerValue float64           `json:"trigger_value"`
+	Status       string            `gorm:"type:enum('success','partial','failed')" json:"status"`
+	Results      RuleActionResults `gorm:"type:json" json:"results"`
+	FiredAt      time.Time         `gorm:"default:CURRENT_TIMESTAMP;index" json:"fired_at"`
+}
+
+func (AutomationRuleLog) TableName() string {
+	return "automation_rule_logs"
+}
+
+type RuleActionResult struct {
+	Device        string `json:"device"`
+	Action        string `json:"action"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+	Status        string `json:"status"`
+	Error         string `json:"error,omitempty"`
+}
+
+type RuleActionResults []RuleActionResult
+
+func (r RuleActionResults) Value() (driver.Value, error) {
+	return jsonValue(r)
+}
+
+func (r *RuleActionResults) Scan(value interface{}) error {
+	return jsonScan(value, r)
+}
+
+// AutomationRuleRequest for creating/updating a rule
+type AutomationRuleRequest struct {
+	Name            string          `json:"name" binding:"required,max=100"`
+	Enabled         *bool           `json:"enabled"`
+	Conditions      []RuleCondition `json:"conditions" binding:"required,min=1,dive"`
+	Actions         []RuleAction    `json:"actions" binding:"required,min=1,dive"`
+	CooldownSeconds *int            `json:"cooldown_seconds" binding:"omitempty,min=0"`
+}
+
+// EnabledRequest toggles rules/schedules on or off
+type EnabledRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// jsonValue / jsonScan store slices in MySQL JSON columns
+func jsonValue(v interface{}) (driver.Value, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func jsonScan(value interface{}, dest interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, dest)
+	case string:
+		return json.Unmarshal([]byte(v), dest)
+	default:
+		return errors.New("unsupported JSON column type")
+	}
+}
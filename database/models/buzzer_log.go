@@ -2,12 +2,31 @@ package models
 
 import "time"
 
-// BuzzerLog represents buzzer activity log
+// Buzzer command sources
+const (
+	BuzzerSourceManual = "manual" // POST /api/control/buzzer
+	BuzzerSourceGas    = "gas"    // gas emergency playbook
+	BuzzerSourceAlarm  = "alarm"  // security alarm trigger / disarm
+	BuzzerSourceDevice = "device" // state change reported by the ESP32 without a backend command
+)
+
+// BuzzerLog represents buzzer activity log. Every command the backend sends is a row;
+// ConfirmedAt is set once the device reports the same state on buzzer/status,
+// Error when the command never reached the broker.
 type BuzzerLog struct {
-	BuzzerID  uint      `gorm:"primaryKey;column:buzzer_id" json:"buzzer_id"`
-	Status    string    `gorm:"type:enum('on','off')" json:"status"`
-	Reason    string    `gorm:"type:varchar(200)" json:"reason,omitempty"`
-	Timestamp time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"timestamp"`
+	BuzzerID    uint       `gorm:"primaryKey;column:buzzer_id" json:"buzzer_id"`
+	Status      string     `gorm:"type:enum('on','off')" json:"status"`
+	Source      string     `gorm:"type:varchar(20);index" json:"source"`
+	Reason      string     `gorm:"type:varchar(200)" json:"reason,omitempty"`
+	UserID      *uint      `gorm:"index" json:"user_id,omitempty"`
+	ConfirmedAt *time.Time `json:"confirmed_at,omitempty"`
+	Error       string     `gorm:"type:varchar(255)" json:"error,omitempty"`
+	Timestamp   time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"timestamp"`
+	User        *User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+func (BuzzerLog) TableName() string {
+	return "buzzer_log"
 }
 
 // BuzzerRequest for controlling buzzer
@@ -15,3 +34,29 @@ type BuzzerRequest struct {
 	Status string `json:"status" binding:"required,oneof=on off"`
 	Reason string `json:"reason"`
 }
+
+// BuzzerCommand - what to send and why; UserID is nil for automatic commands
+type BuzzerCommand struct {
+	Action string
+	Source string
+	Reason string
+	UserID *uint
+}
+
+// BuzzerState - last state reported by the device
+type BuzzerState struct {
+	Status     string     `json:"status"`
+	Since      *time.Time `json:"since,omitempty"`
+	ReportedAt *time.Time `json:"reported_at,omitempty"`
+	LastLog    *BuzzerLog `json:"last_log,omitempty"`
+}
+
+// BuzzerLogFilter - query for GET /api/device/buzzer/history
+type BuzzerLogFilter struct {
+	Status string
+	Source string
+	UserID *uint
+	From   *time.Time
+	To     *time.Time
+	Limit  int
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Camera - ESP32-CAM registered from its "camera/ip" MQTT announcement.
+// The app never talks to IPAddress directly; snapshots and the MJPEG stream
+// are proxied through the backend.
+type Camera struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	DeviceID   string    `gorm:"type:varchar(64);uniqueIndex;not null" json:"device_id"`
+	Name       string    `gorm:"type:varchar(100)" json:"name"`
+	IPAddress  string    `gorm:"type:varchar(64);not null" json:"-"` // LAN address, kept server-side
+	StreamPort int       `gorm:"not null;default:81" json:"-"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// CameraAnnouncement - payload on iotcihuy/home/camera/ip. Older firmware
+// sends only the raw IP string, which maps to DefaultCameraDeviceID.
+type CameraAnnouncement struct {
+	DeviceID   string `json:"device_id"`
+	Name       string `json:"name"`
+	IP         string `json:"ip"`
+	StreamPort int    `json:"stream_port"`
+}
+
+const DefaultCameraDeviceID = "esp32cam"
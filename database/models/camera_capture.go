@@ -2,11 +2,17 @@ package models
 
 import "time"
 
-// CameraCapture represents captured images from ESP32-CAM
+// CameraCapture represents captured images from ESP32-CAM.
+// ImagePath is the file name under UPLOAD_DIR (sha256 of the content + extension),
+// so identical frames share one file.
 type CameraCapture struct {
 	CaptureID    uint      `gorm:"primaryKey;column:capture_id" json:"capture_id"`
 	ImagePath    string    `gorm:"type:text;not null" json:"image_path"`
 	DetectedFace string    `gorm:"type:varchar(100)" json:"detected_face,omitempty"`
+	UserID       *uint     `gorm:"index" json:"user_id,omitempty"` // recognized user, nil for unknown faces
+	ContentHash  string    `gorm:"type:char(64);index" json:"content_hash"`
+	ContentType  string    `gorm:"type:varchar(50)" json:"content_type"`
+	SizeBytes    int       `json:"size_bytes"`
 	Timestamp    time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"timestamp"`
 }
 
@@ -15,3 +21,9 @@ type CameraCaptureRequest struct {
 	ImagePath    string `json:"image_path" binding:"required"`
 	DetectedFace string `json:"detected_face"`
 }
+
+// Detected face labels for captures without a recognized user
+const (
+	DetectedFaceUnknown     = "unknown"
+	DetectedFaceUnavailable = "unavailable" // face service could not be reached
+)
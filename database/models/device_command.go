@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// DeviceCommand tracks a control command from publish until the ESP32
+// reports the matching */status message (correlated by CorrelationID).
+type DeviceCommand struct {
+	ID            uint       `gorm:"primaryKey;column:id" json:"id"`
+	CorrelationID string     `gorm:"type:varchar(36);uniqueIndex;not null" json:"correlation_id"`
+	Device        string     `gorm:"type:enum('door','lamp','curtain');not null" json:"device"`
+	Action        string     `gorm:"type:varchar(20);not null" json:"action"`
+	Mode          string     `gorm:"type:varchar(20)" json:"mode,omitempty"`   // lamp/curtain
+	Method        string     `gorm:"type:varchar(20)" json:"method,omitempty"` // door
+	Status        string     `gorm:"type:enum('pending','confirmed','timed_out','failed');default:'pending';index" json:"status"`
+	Attempts      int        `gorm:"not null;default:1" json:"attempts"`
+	Source        string     `gorm:"type:varchar(30);default:'api'" json:"source"` // api, automation, schedule, ...
+	SourceID      *uint      `json:"source_id,omitempty"`
+	UserID        *uint      `gorm:"index" json:"user_id,omitempty"`
+	Error         string     `gorm:"type:varchar(255)" json:"error,omitempty"`
+	Queued        bool       `gorm:"-" json:"queued,omitempty"` // broker offline at send time
+	CreatedAt     time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+	ConfirmedAt   *time.Time `json:"confirmed_at,omitempty"`
+}
+
+func (DeviceCommand) TableName() string {
+	return "device_commands"
+}
+
+// DeviceCommandRequest is what callers (API, automations, ...) hand to the device controller
+type DeviceCommandRequest struct {
+	Device   string
+	Action   string
+	Mode     string
+	Method   string
+	UserID   *uint
+	Source   string
+	SourceID *uint
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// DeviceStateSourceDevice - change reported by the ESP32 that no tracked command
+// asked for (wall switch, LDR auto mode, HTTP ingest). Confirmed commands keep
+// their own source: api, automation, schedule, scene, ...
+const DeviceStateSourceDevice = "device"
+
+// DeviceStateEvent is one lamp/curtain state transition. Rows are append-only;
+// the current state lives in lamp_status / curtain_status.
+type DeviceStateEvent struct {
+	EventID        uint      `gorm:"primaryKey;column:event_id" json:"event_id"`
+	Device         string    `gorm:"type:enum('lamp','curtain');not null;index:idx_device_timestamp,priority:1" json:"device"`
+	Status         string    `gorm:"type:varchar(20);not null" json:"status"`
+	PreviousStatus string    `gorm:"type:varchar(20)" json:"previous_status,omitempty"`
+	Mode           string    `gorm:"type:varchar(20)" json:"mode"`
+	PreviousMode   string    `gorm:"type:varchar(20)" json:"previous_mode,omitempty"`
+	Source         string    `gorm:"type:varchar(30);default:'device';index" json:"source"`
+	SourceID       *uint     `json:"source_id,omitempty"` // automation rule / schedule / scene id
+	UserID         *uint     `gorm:"index" json:"user_id,omitempty"`
+	CorrelationID  string    `gorm:"type:varchar(36)" json:"correlation_id,omitempty"`
+	Timestamp      time.Time `gorm:"default:CURRENT_TIMESTAMP;index:idx_device_timestamp,priority:2" json:"timestamp"`
+	User           *User     `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+func (DeviceStateEvent) TableName() string {
+	return "device_state_events"
+}
+
+// DeviceStateChange - a reported lamp/curtain state and who caused it
+type DeviceStateChange struct {
+	Status        string
+	Mode          string
+	Source        string
+	SourceID      *uint
+	UserID        *uint
+	CorrelationID string
+}
+
+// DeviceStateFilter - query for GET /api/device/{lamp,curtain}/timeline
+type DeviceStateFilter struct {
+	Device string
+	Source string
+	UserID *uint
+	From   *time.Time
+	To     *time.Time
+	Limit  int
+}
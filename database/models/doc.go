@@ -6,13 +6,19 @@
 //   - user.go: User authentication and management models
 //   - fingerprint.go: Fingerprint authentication models
 //   - pin_code.go: PIN code authentication models
+//   - pin_attempt.go: PIN brute-force attempt tracking models
+//   - user_pin.go: Personal (per-user) PIN models
+//   - guest_code.go: Time-limited guest access code models
 //   - access_log.go: Access history and logging models
 //
 // Camera & Vision:
+//   - camera.go: ESP32-CAM registry (announced IPs, last seen)
 //   - camera_capture.go: ESP32-CAM capture models
+//   - face_recognition.go: Face recognition logs and unknown-face alerts
 //
 // Sensors:
 //   - sensor_gas.go: Gas/smoke sensor models
+//   - gas_incident.go: Gas emergency playbook and incident history
 //   - sensor_temperature.go: Temperature sensor models
 //   - sensor_humidity.go: Humidity sensor models
 //   - sensor_light.go: Light/LDR sensor models
@@ -21,11 +27,20 @@
 //   - door_status.go: Door lock control models
 //   - lamp_status.go: Lamp control models
 //   - curtain_status.go: Curtain/blind control models
+//   - device_state_event.go: Append-only lamp/curtain state history
 //   - buzzer_log.go: Buzzer activity models
 //
 // System:
-//   - notification.go: System notification models
+//   - notification.go: System notifications and per-user read state
+//   - notification_channel.go: Outbound channels (webhook/email/chat bot) and delivery log
+//   - webhook.go: Signed outgoing webhooks for domain events and their delivery queue
+//   - alarm.go: Security alarm state machine and event history
+//   - system_setting.go: Runtime key/value settings
 //   - device_control.go: MQTT device control models
+//   - device_command.go: Tracked control commands (correlation ID + confirmation)
+//   - automation_rule.go: Sensor-triggered automation rules and firing log
+//   - schedule.go: Cron-style device schedules
+//   - scene.go: Multi-device presets and activation log
 //   - response.go: Standard API response models
 //
 // All models use GORM for ORM and Gin validator for request validation.
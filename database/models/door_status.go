@@ -6,7 +6,7 @@ import "time"
 type DoorStatus struct {
 	DoorID    uint      `gorm:"primaryKey;column:door_id" json:"door_id"`
 	Status    string    `gorm:"type:enum('locked','unlocked')" json:"status"`
-	Method    string    `gorm:"type:enum('face','pin','remote')" json:"method"`
+	Method    string    `gorm:"type:enum('face','pin','remote','fingerprint','auto','auto_relock')" json:"method"`
 	Timestamp time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"timestamp"`
 }
 
@@ -17,6 +17,13 @@ func (DoorStatus) TableName() string {
 // DoorRequest for controlling door lock
 type DoorRequest struct {
 	Status string `json:"status" binding:"required,oneof=locked unlocked"`
-	Method string `json:"method" binding:"required,oneof=face pin remote"`
+	Method string `json:"method" binding:"required,oneof=face pin remote fingerprint"`
 	UserID *uint  `json:"user_id"` // Optional: untuk tracking user yang remote control
 }
+
+// AutoRelockSettings - Lock the door again after it stayed unlocked for DelaySeconds.
+// Stored as JSON in system_settings (key "door_auto_relock").
+type AutoRelockSettings struct {
+	Enabled      bool `json:"enabled"`
+	DelaySeconds int  `json:"delay_seconds" binding:"required,min=5,max=3600"`
+}
@@ -5,13 +5,13 @@ import "time"
 // FaceRecognitionLog - Log hasil face recognition dari Python service
 type FaceRecognitionLog struct {
 	ID         int       `json:"id" gorm:"primaryKey;autoIncrement"`
-	UserID     *int      `json:"user_id" gorm:"column:user_id"`
-	Name       *string   `json:"name" gorm:"column:name"`
+	UserID     *int      `json:"user_id" gorm:"column:user_id;index"`
+	Name       *string   `json:"name" gorm:"column:name;type:varchar(100)"`
 	Confidence float64   `json:"confidence" gorm:"column:confidence"`
 	Recognized bool      `json:"recognized" gorm:"column:recognized"`
-	Message    string    `json:"message" gorm:"column:message"`
-	Source     string    `json:"source" gorm:"column:source"`
-	Timestamp  time.Time `json:"timestamp" gorm:"column:timestamp"`
+	Message    string    `json:"message" gorm:"column:message;type:varchar(255)"`
+	Source     string    `json:"source" gorm:"column:source;type:varchar(50)"`
+	Timestamp  time.Time `json:"timestamp" gorm:"column:timestamp;index"`
 	CreatedAt  time.Time `json:"created_at" gorm:"column:created_at"`
 }
 
@@ -21,12 +21,18 @@ func (FaceRecognitionLog) TableName() string {
 
 // FaceAlert - Alert untuk unknown face
 type FaceAlert struct {
-	ID          int       `json:"id" gorm:"primaryKey;autoIncrement"`
-	AlertType   string    `json:"alert_type" gorm:"column:alert_type"`
-	ImageBase64 *string   `json:"image_base64" gorm:"column:image_base64;type:longtext"`
-	Resolved    bool      `json:"resolved" gorm:"column:resolved;default:false"`
-	Timestamp   time.Time `json:"timestamp" gorm:"column:timestamp"`
-	CreatedAt   time.Time `json:"created_at" gorm:"column:created_at"`
+	ID          int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	AlertType   string     `json:"alert_type" gorm:"column:alert_type;type:varchar(50)"`
+	ImageBase64 *string    `json:"image_base64,omitempty" gorm:"column:image_base64;type:longtext"`
+	Source      string     `json:"source,omitempty" gorm:"column:source;type:varchar(50)"`
+	Resolved    bool       `json:"resolved" gorm:"column:resolved;default:false;index"`
+	ResolvedBy  *uint      `json:"resolved_by,omitempty" gorm:"column:resolved_by"`
+	ResolvedAt  *time.Time `json:"resolved_at,omitempty" gorm:"column:resolved_at"`
+	Timestamp   time.Time  `json:"timestamp" gorm:"column:timestamp;index"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"column:created_at"`
+
+	// Lists omit the image; HasImage tells the client to fetch the detail
+	HasImage bool `json:"has_image" gorm:"->;-:migration;column:has_image"`
 }
 
 func (FaceAlert) TableName() string {
@@ -34,22 +40,48 @@ func (FaceAlert) TableName() string {
 }
 
 // FaceRecognitionRequest - Request dari Python service
+// Timestamp is unix seconds (0 = time of ingestion)
 type FaceRecognitionRequest struct {
 	UserID     *int    `json:"user_id"`
-	Name       *string `json:"name"`
-	Confidence float64 `json:"confidence"`
+	Name       *string `json:"name" binding:"omitempty,max=100"`
+	Confidence float64 `json:"confidence" binding:"min=0,max=1"`
 	Recognized bool    `json:"recognized"`
-	Message    string  `json:"message"`
-	Timestamp  int64   `json:"timestamp"`
-	Source     string  `json:"source"`
+	Message    string  `json:"message" binding:"max=255"`
+	Timestamp  int64   `json:"timestamp" binding:"min=0"`
+	Source     string  `json:"source" binding:"max=50"`
 }
 
 // FaceAlertRequest - Request alert dari Python service
 type FaceAlertRequest struct {
-	AlertType   string  `json:"alert_type"`
+	AlertType   string  `json:"alert_type" binding:"required,max=50"`
 	ImageBase64 *string `json:"image_base64"`
-	Timestamp   int64   `json:"timestamp"`
-	Source      string  `json:"source"`
+	Timestamp   int64   `json:"timestamp" binding:"min=0"`
+	Source      string  `json:"source" binding:"max=50"`
+}
+
+// FaceLogFilter for listing recognition logs (zero values = no filter)
+type FaceLogFilter struct {
+	UserID     *int
+	Recognized *bool
+	Source     string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+}
+
+// FaceAlertFilter for listing alerts; images are only loaded on request
+type FaceAlertFilter struct {
+	Resolved      *bool
+	AlertType     string
+	From          *time.Time
+	To            *time.Time
+	IncludeImages bool
+	Limit         int
+}
+
+// FaceAlertResolveRequest resolves several alerts at once
+type FaceAlertResolveRequest struct {
+	IDs []int `json:"ids" binding:"required,min=1,max=500"`
 }
 
 // FaceRecognitionEvent - WebSocket broadcast message
@@ -2,17 +2,79 @@ package models
 
 import "time"
 
-// FingerprintData represents stored fingerprint template
+// FingerprintData represents stored fingerprint template.
+// TemplateIndex is the slot on the door unit's sensor; the template itself never leaves the sensor.
 type FingerprintData struct {
 	FingerprintID uint      `gorm:"primaryKey;column:fingerprint_id" json:"fingerprint_id"`
 	UserID        uint      `gorm:"not null" json:"user_id"`
-	TemplateIndex int       `gorm:"not null" json:"template_index"`
+	TemplateIndex int       `gorm:"not null;uniqueIndex" json:"template_index"`
 	CreatedAt     time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
 	User          User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
 }
 
-// FingerprintRequest for enrolling new fingerprint
+func (FingerprintData) TableName() string {
+	return "fingerprint_data"
+}
+
+// FingerprintRequest for enrolling new fingerprint (the backend picks a free template slot)
 type FingerprintRequest struct {
-	UserID        uint `json:"user_id" binding:"required"`
-	TemplateIndex int  `json:"template_index" binding:"required"`
+	UserID uint `json:"user_id" binding:"required"`
+}
+
+// Enrollment states
+const (
+	FingerprintEnrollPending  = "pending"
+	FingerprintEnrollSuccess  = "success"
+	FingerprintEnrollFailed   = "failed"
+	FingerprintEnrollTimedOut = "timed_out"
+)
+
+// FingerprintEnrollment - one enrollment session on the sensor (the user presses
+// the finger on the door unit twice). Kept in memory; only the result is stored.
+type FingerprintEnrollment struct {
+	ID            string           `json:"id"`
+	UserID        uint             `json:"user_id"`
+	TemplateIndex int              `json:"template_index"`
+	Status        string           `json:"status"`
+	Message       string           `json:"message,omitempty"`
+	RequestedBy   *uint            `json:"requested_by,omitempty"`
+	StartedAt     time.Time        `json:"started_at"`
+	ExpiresAt     time.Time        `json:"expires_at"`
+	FinishedAt    *time.Time       `json:"finished_at,omitempty"`
+	Fingerprint   *FingerprintData `json:"fingerprint,omitempty"`
+}
+
+// FingerprintEnrollResult - payload on iotcihuy/home/fingerprint/enroll/result
+type FingerprintEnrollResult struct {
+	EnrollmentID  string `json:"enrollment_id"`
+	TemplateIndex int    `json:"template_index"`
+	Success       bool   `json:"success"`
+	Message       string `json:"message"`
+}
+
+// FingerprintMatch - payload on iotcihuy/home/fingerprint/match.
+// Matched=false, a low confidence or a template index no user owns is a failed attempt.
+// The door unit signs every match with its own key:
+//
+//	signature = hex(HMAC-SHA256(key, "device_id|timestamp|nonce|matched|template_index|confidence"))
+//
+// with timestamp in unix seconds and a fresh random nonce per message.
+type FingerprintMatch struct {
+	Matched       bool   `json:"matched"`
+	TemplateIndex int    `json:"template_index"`
+	Confidence    int    `json:"confidence"`
+	DeviceID      string `json:"device_id"`
+	Timestamp     int64  `json:"timestamp"`
+	Nonce         string `json:"nonce"`
+	Signature     string `json:"signature"`
+}
+
+// FingerprintVerifyResult - answer sent back to the door unit
+type FingerprintVerifyResult struct {
+	Valid          bool   `json:"valid"`
+	UserID         *uint  `json:"user_id,omitempty"`
+	Name           string `json:"name,omitempty"`
+	Locked         bool   `json:"locked"`
+	LockoutSeconds int    `json:"lockout_seconds,omitempty"`
+	Message        string `json:"message"`
 }
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// GasPlaybook is the admin-tunable emergency response to dangerous gas levels.
+// Stored as JSON in system_settings (key "gas_playbook").
+type GasPlaybook struct {
+	Enabled bool `json:"enabled"`
+
+	// Trigger: DebounceSamples consecutive readings above DangerPPM
+	DangerPPM       int `json:"danger_ppm" binding:"required,min=1"`
+	DebounceSamples int `json:"debounce_samples" binding:"required,min=1,max=60"`
+
+	// All-clear: readings stay at or below ClearPPM for ClearSeconds
+	ClearPPM     int `json:"clear_ppm" binding:"min=0"`
+	ClearSeconds int `json:"clear_seconds" binding:"required,min=1"`
+
+	// Actions
+	Buzzer      bool `json:"buzzer"`
+	OpenCurtain bool `json:"open_curtain"` // ventilation
+	UnlockDoor  bool `json:"unlock_door"`  // evacuation
+	Notify      bool `json:"notify"`
+}
+
+// DefaultGasPlaybook matches the danger threshold used by handleGas (> 500 PPM)
+func DefaultGasPlaybook() GasPlaybook {
+	return GasPlaybook{
+		Enabled:         true,
+		DangerPPM:       500,
+		DebounceSamples: 3,
+		ClearPPM:        200,
+		ClearSeconds:    120,
+		Buzzer:          true,
+		OpenCurtain:     true,
+		UnlockDoor:      false,
+		Notify:          true,
+	}
+}
+
+// GasIncident is one gas emergency from trigger to all-clear
+type GasIncident struct {
+	ID        uint              `gorm:"primaryKey;column:id" json:"id"`
+	Status    string            `gorm:"type:enum('active','resolved');not null;default:'active'" json:"status"`
+	StartPPM  int               `json:"start_ppm"`
+	PeakPPM   int               `json:"peak_ppm"`
+	StartedAt time.Time         `gorm:"index" json:"started_at"`
+	PeakAt    time.Time         `json:"peak_at"`
+	EndedAt   *time.Time        `json:"ended_at,omitempty"`
+	Actions   RuleActionResults `gorm:"type:json" json:"actions"`
+}
+
+func (GasIncident) TableName() string {
+	return "gas_incidents"
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// GuestCode is a temporary keypad code for visitors (cleaner, courier, ...).
+// Like other PINs only the bcrypt hash is stored; the plaintext is returned once on creation.
+type GuestCode struct {
+	ID          uint       `gorm:"primaryKey;column:id" json:"id"`
+	Label       string     `gorm:"type:varchar(100);not null" json:"label"`
+	CodeHash    string     `gorm:"type:varchar(255);not null" json:"-"`
+	ValidFrom   time.Time  `gorm:"not null" json:"valid_from"`
+	ValidUntil  time.Time  `gorm:"not null;index" json:"valid_until"`
+	MaxUses     int        `gorm:"not null;default:0" json:"max_uses"` // 0 = unlimited
+	UseCount    int        `gorm:"not null;default:0" json:"use_count"`
+	AllowedDays string     `gorm:"type:varchar(27)" json:"allowed_days,omitempty"` // "mon,wed,fri", empty = every day
+	TimeStart   *string    `gorm:"type:varchar(5)" json:"time_start,omitempty"`    // "HH:MM" local time
+	TimeEnd     *string    `gorm:"type:varchar(5)" json:"time_end,omitempty"`
+	Revoked     bool       `gorm:"not null;default:false" json:"revoked"`
+	CreatedBy   uint       `gorm:"not null" json:"created_by"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt   time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+func (GuestCode) TableName() string {
+	return "guest_codes"
+}
+
+// GuestCodeRequest for creating a guest code; Code is generated when empty
+type GuestCodeRequest struct {
+	Label       string     `json:"label" binding:"required,max=100"`
+	Code        string     `json:"code" binding:"omitempty,numeric,min=4,max=6"`
+	ValidFrom   *time.Time `json:"valid_from"` // default: now
+	ValidUntil  time.Time  `json:"valid_until" binding:"required"`
+	MaxUses     int        `json:"max_uses" binding:"min=0"`
+	AllowedDays []string   `json:"allowed_days" binding:"omitempty,dive,oneof=mon tue wed thu fri sat sun"`
+	TimeStart   string     `json:"time_start" binding:"omitempty,datetime=15:04"`
+	TimeEnd     string     `json:"time_end" binding:"omitempty,datetime=15:04"`
+}
+
+// GuestCodeCreated is returned once after creation and carries the plaintext code
+type GuestCodeCreated struct {
+	GuestCode
+	Code string `json:"code"`
+}
@@ -2,18 +2,66 @@ package models
 
 import "time"
 
+// Notification types
+const (
+	NotifGas      = "gas"
+	NotifDoor     = "door"
+	NotifSystem   = "system"
+	NotifIntruder = "intruder"
+	NotifAccess   = "access"   // failed PIN / keypad lockout
+	NotifDevice   = "device"   // device or broker offline/online
+	NotifApproval = "approval" // registrations waiting for / resolved by an admin
+)
+
 // Notification represents system notifications
 type Notification struct {
 	NotifID   uint      `gorm:"primaryKey;column:notif_id" json:"notif_id"`
 	Title     string    `gorm:"type:varchar(200)" json:"title"`
 	Message   string    `gorm:"type:text" json:"message"`
-	Type      string    `gorm:"type:enum('gas','door','system','intruder')" json:"type"`
+	Type      string    `gorm:"type:enum('gas','door','system','intruder','access','device','approval')" json:"type"`
 	Timestamp time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"timestamp"`
+
+	// Read state of the requesting user (filled by list queries only)
+	Read bool `gorm:"->;column:is_read" json:"read"`
+}
+
+// NotificationRead marks a notification as read by one user
+type NotificationRead struct {
+	NotifID uint      `gorm:"primaryKey;column:notif_id" json:"notif_id"`
+	UserID  uint      `gorm:"primaryKey;column:user_id" json:"user_id"`
+	ReadAt  time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"read_at"`
+}
+
+func (NotificationRead) TableName() string {
+	return "notification_reads"
 }
 
 // NotificationRequest for creating notifications
 type NotificationRequest struct {
 	Title   string `json:"title" binding:"required"`
 	Message string `json:"message" binding:"required"`
-	Type    string `json:"type" binding:"required,oneof=gas door system intruder"`
+	Type    string `json:"type" binding:"required,oneof=gas door system intruder access device approval"`
+}
+
+// NotificationFilter for listing notifications of one user
+type NotificationFilter struct {
+	Types      []string
+	UnreadOnly bool
+	Page       int
+	PageSize   int
+}
+
+// NotificationPage is one page of notifications with the user's read state
+type NotificationPage struct {
+	Data        []Notification `json:"data"`
+	Total       int64          `json:"total"`
+	Page        int            `json:"page"`
+	PageSize    int            `json:"page_size"`
+	TotalPages  int            `json:"total_pages"`
+	UnreadCount int64          `json:"unread_count"`
+}
+
+// NotificationReadRequest marks specific notifications as read
+type NotificationReadRequest struct {
+	IDs []uint `json:"ids" binding:"required,min=1,max=500"`
 }
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+)
+
+// Outbound channel kinds
+const (
+	ChannelWebhook = "webhook" // generic HTTP POST (JSON body)
+	ChannelEmail   = "email"   // SMTP
+	ChannelChatBot = "chatbot" // Telegram-style bot API (sendMessage)
+)
+
+// Delivery outcomes
+const (
+	DeliverySent        = "sent"
+	DeliveryFailed      = "failed"
+	DeliveryRateLimited = "rate_limited"
+)
+
+// NotificationChannel is one user's outbound destination.
+// Target is the webhook URL, email address or chat ID depending on Kind;
+// Types lists the notification types the user subscribed to on this channel.
+type NotificationChannel struct {
+	ID        uint       `gorm:"primaryKey;column:id" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	Kind      string     `gorm:"type:enum('webhook','email','chatbot');not null" json:"kind"`
+	Name      string     `gorm:"type:varchar(100);not null" json:"name"`
+	Target    string     `gorm:"type:varchar(255);not null" json:"target"`
+	Types     StringList `gorm:"type:json;not null" json:"types"`
+	Enabled   bool       `gorm:"not null;default:true" json:"enabled"`
+	CreatedAt time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+func (NotificationChannel) TableName() string {
+	return "notification_channels"
+}
+
+// Subscribed reports whether notifType should go out on this channel
+func (c *NotificationChannel) Subscribed(notifType string) bool {
+	for _, t := range c.Types {
+		if t == notifType {
+			return true
+		}
+	}
+	return false
+}
+
+// NotificationDelivery is the delivery log (one row per notification per channel).
+// NotifID is nil for test messages.
+type NotificationDelivery struct {
+	ID          uint       `gorm:"primaryKey;column:id" json:"id"`
+	NotifID     *uint      `gorm:"column:notif_id;index" json:"notif_id,omitempty"`
+	ChannelID   uint       `gorm:"not null;index" json:"channel_id"`
+	UserID      uint       `gorm:"not null;index" json:"user_id"`
+	Kind        string     `gorm:"type:varchar(20)" json:"kind"`
+	Status      string     `gorm:"type:enum('sent','failed','rate_limited');not null" json:"status"`
+	Attempts    int        `gorm:"not null;default:0" json:"attempts"`
+	Error       string     `gorm:"type:varchar(500)" json:"error,omitempty"`
+	CreatedAt   time.Time  `gorm:"default:CURRENT_TIMESTAMP;index" json:"created_at"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+}
+
+func (NotificationDelivery) TableName() string {
+	return "notification_deliveries"
+}
+
+// NotificationChannelRequest for creating/updating a channel
+type NotificationChannelRequest struct {
+	Kind    string   `json:"kind" binding:"required,oneof=webhook email chatbot"`
+	Name    string   `json:"name" binding:"required,max=100"`
+	Target  string   `json:"target" binding:"required,max=255"`
+	Types   []string `json:"types" binding:"required,min=1,dive,oneof=gas door system intruder access device approval"`
+	Enabled *bool    `json:"enabled"`
+}
+
+// StringList stores a list of strings in a MySQL JSON column
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	return jsonValue(l)
+}
+
+func (l *StringList) Scan(value interface{}) error {
+	return jsonScan(value, l)
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// PinAttempt tracks consecutive failed PIN entries per source (client IP / keypad)
+type PinAttempt struct {
+	ID            uint       `gorm:"primaryKey;column:id" json:"id"`
+	Source        string     `gorm:"type:varchar(100);uniqueIndex;not null" json:"source"`
+	FailedCount   int        `gorm:"not null;default:0" json:"failed_count"`
+	LockoutLevel  int        `gorm:"not null;default:0" json:"lockout_level"`
+	LockedUntil   *time.Time `json:"locked_until,omitempty"`
+	LastAttemptAt time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"last_attempt_at"`
+}
+
+func (PinAttempt) TableName() string {
+	return "pin_attempts"
+}
+
+// PinVerifyResult is returned by PIN verification to HTTP and keypad callers
+type PinVerifyResult struct {
+	Valid             bool       `json:"valid"`
+	UserID            *uint      `json:"user_id,omitempty"`     // set when a personal PIN matched
+	GuestLabel        string     `json:"guest_label,omitempty"` // set when a guest code matched
+	Locked            bool       `json:"locked"`
+	RemainingAttempts int        `json:"remaining_attempts"`
+	LockedUntil       *time.Time `json:"locked_until,omitempty"`
+	LockoutSeconds    int        `json:"lockout_seconds,omitempty"`
+	Message           string     `json:"message"`
+}
@@ -2,15 +2,18 @@ package models
 
 import "time"
 
+// PinCode stores the universal door PIN as a bcrypt hash.
+// The hash is never serialized; API callers only see metadata.
 type PinCode struct {
-    ID           uint      `gorm:"primaryKey;column:id" json:"id"`
-    UniversalPin string    `gorm:"type:varchar(6);uniqueIndex;not null;column:universal_pin" json:"universal_pin"`
-    SetBy        uint      `gorm:"not null;column:set_by" json:"set_by"`
-    CreatedAt    time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
-    UpdatedAt    time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+	ID        uint      `gorm:"primaryKey;column:id" json:"id"`
+	PinHash   string    `gorm:"type:varchar(255);column:pin_hash" json:"-"`
+	LegacyPin *string   `gorm:"type:varchar(6);column:universal_pin" json:"-"` // plaintext from older installs, cleared by migration
+	SetBy     uint      `gorm:"not null;column:set_by" json:"set_by"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
 }
 
+// PinRequest for setting the universal PIN; set_by is the authenticated admin
 type PinRequest struct {
-    UniversalPin string `json:"universal_pin" binding:"required,min=4,max=6"`
-    SetBy        uint   `json:"set_by" binding:"required"` // ⭐ Tambahkan ini
-}
\ No newline at end of file
+	UniversalPin string `json:"universal_pin" binding:"required,min=4,max=6"`
+}
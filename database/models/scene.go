@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Scene is a named preset of device states applied together
+// ("Movie night" = lamp off + curtain close).
+type Scene struct {
+	ID              uint        `gorm:"primaryKey;column:id" json:"id"`
+	Name            string      `gorm:"type:varchar(100);not null;uniqueIndex" json:"name"`
+	Description     string      `gorm:"type:varchar(255)" json:"description,omitempty"`
+	Actions         RuleActions `gorm:"type:json;not null" json:"actions"`
+	LastActivatedAt *time.Time  `json:"last_activated_at,omitempty"`
+	LastActivatedBy *uint       `json:"last_activated_by,omitempty"`
+	CreatedBy       *uint       `json:"created_by,omitempty"`
+	CreatedAt       time.Time   `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt       time.Time   `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+func (Scene) TableName() string {
+	return "scenes"
+}
+
+// SceneActivation records who activated a scene and the result per device
+type SceneActivation struct {
+	ID          uint              `gorm:"primaryKey;column:id" json:"id"`
+	SceneID     uint              `gorm:"index;not null" json:"scene_id"`
+	SceneName   string            `gorm:"type:varchar(100)" json:"scene_name"`
+	UserID      *uint             `json:"user_id,omitempty"`
+	Status      string            `gorm:"type:enum('success','partial','failed')" json:"status"`
+	Results     RuleActionResults `gorm:"type:json" json:"results"`
+	ActivatedAt time.Time         `gorm:"default:CURRENT_TIMESTAMP;index" json:"activated_at"`
+}
+
+func (SceneActivation) TableName() string {
+	return "scene_activations"
+}
+
+// SceneRequest for creating/updating a scene (one action per device)
+type SceneRequest struct {
+	Name        string       `json:"name" binding:"required,max=100"`
+	Description string       `json:"description" binding:"max=255"`
+	Actions     []RuleAction `json:"actions" binding:"required,min=1,max=3,dive"`
+}
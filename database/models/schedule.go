@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Schedule runs one device action on a 5-field cron expression
+// ("30 6 * * 1-5" = 06:30 on weekdays) in the schedule's timezone.
+type Schedule struct {
+	ID            uint       `gorm:"primaryKey;column:id" json:"id"`
+	Name          string     `gorm:"type:varchar(100);not null" json:"name"`
+	Cron          string     `gorm:"type:varchar(100);not null" json:"cron"`
+	Timezone      string     `gorm:"type:varchar(64);not null" json:"timezone"`
+	Device        string     `gorm:"type:enum('door','lamp','curtain');not null" json:"device"`
+	Action        string     `gorm:"type:varchar(20);not null" json:"action"`
+	Mode          string     `gorm:"type:varchar(20)" json:"mode,omitempty"`
+	Paused        bool       `gorm:"not null;default:false" json:"paused"`
+	SkipNext      bool       `gorm:"not null;default:false" json:"skip_next"`
+	NextRunAt     *time.Time `json:"next_run_at,omitempty"`
+	LastRunAt     *time.Time `json:"last_run_at,omitempty"`
+	LastStatus    string     `gorm:"type:varchar(20)" json:"last_status,omitempty"` // command status or "skipped"
+	LastCommandID string     `gorm:"type:varchar(36)" json:"last_command_id,omitempty"`
+	CreatedBy     *uint      `json:"created_by,omitempty"`
+	CreatedAt     time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+func (Schedule) TableName() string {
+	return "schedules"
+}
+
+// ScheduleRequest for creating/updating a schedule
+type ScheduleRequest struct {
+	Name     string `json:"name" binding:"required,max=100"`
+	Cron     string `json:"cron" binding:"required,max=100"`
+	Timezone string `json:"timezone" binding:"max=64"` // IANA name, default server TIMEZONE
+	Device   string `json:"device" binding:"required,oneof=door lamp curtain"`
+	Action   string `json:"action" binding:"required"`
+	Mode     string `json:"mode" binding:"omitempty,oneof=auto manual"`
+	Paused   bool   `json:"paused"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// SystemSetting is a key/value store for admin-tunable runtime options
+type SystemSetting struct {
+	Key       string    `gorm:"primaryKey;column:setting_key;type:varchar(100)" json:"key"`
+	Value     string    `gorm:"column:setting_value;type:text" json:"value"`
+	UpdatedBy *uint     `gorm:"column:updated_by" json:"updated_by,omitempty"`
+	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+func (SystemSetting) TableName() string {
+	return "system_settings"
+}
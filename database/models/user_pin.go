@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// UserPin is a personal keypad PIN (bcrypt) so unlocks can be attributed to a user
+type UserPin struct {
+	ID        uint      `gorm:"primaryKey;column:id" json:"id"`
+	UserID    uint      `gorm:"uniqueIndex;not null" json:"user_id"`
+	PinHash   string    `gorm:"type:varchar(255);not null" json:"-"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+func (UserPin) TableName() string {
+	return "user_pins"
+}
+
+// UserPinRequest for setting a personal PIN
+type UserPinRequest struct {
+	Pin string `json:"pin" binding:"required,min=4,max=6"`
+}
+
+// UniversalPinStatusRequest for enabling/disabling the shared fallback PIN
+type UniversalPinStatusRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"time"
+)
+
+// Outgoing webhook event types
+const (
+	EventSensorThreshold = "sensor.threshold" // reading crossed a configured low/high bound
+	EventLampState       = "lamp.state"
+	EventDoorState       = "door.state"
+	EventCurtainState    = "curtain.state"
+	EventAccessLog       = "access.log"
+	EventUserApproval    = "user.approval"
+)
+
+// Webhook delivery states ("pending" includes scheduled retries)
+const (
+	WebhookPending   = "pending"
+	WebhookDelivered = "delivered"
+	WebhookDead      = "dead"
+)
+
+// WebhookEndpoint is an admin-registered receiver of domain events.
+// Secret signs every payload (HMAC-SHA256) and is only shown on create/rotate.
+type WebhookEndpoint struct {
+	ID          uint       `gorm:"primaryKey;column:id" json:"id"`
+	URL         string     `gorm:"type:varchar(500);not null" json:"url"`
+	Description string     `gorm:"type:varchar(255)" json:"description,omitempty"`
+	Secret      string     `gorm:"type:varchar(64);not null" json:"-"`
+	Events      StringList `gorm:"type:json;not null" json:"events"`
+	Enabled     bool       `gorm:"not null;default:true" json:"enabled"`
+	CreatedBy   *uint      `json:"created_by,omitempty"`
+	CreatedAt   time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+func (WebhookEndpoint) TableName() string {
+	return "webhook_endpoints"
+}
+
+// WebhookDelivery is one event queued for one endpoint. Failed attempts are
+// rescheduled with exponential backoff until they end up "dead".
+type WebhookDelivery struct {
+	ID            uint       `gorm:"primaryKey;column:id" json:"id"`
+	EndpointID    uint       `gorm:"not null;index" json:"endpoint_id"`
+	EventID       string     `gorm:"type:varchar(36);not null;index" json:"event_id"`
+	EventType     string     `gorm:"type:varchar(50);not null" json:"event_type"`
+	Payload       RawJSON    `gorm:"type:json;not null" json:"payload"`
+	Status        string     `gorm:"type:enum('pending','delivered','dead');not null;default:'pending'" json:"status"`
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt *time.Time `gorm:"index" json:"next_attempt_at,omitempty"`
+	ResponseCode  int        `json:"response_code,omitempty"`
+	LastError     string     `gorm:"type:varchar(500)" json:"last_error,omitempty"`
+	RedeliveryOf  *uint      `json:"redelivery_of,omitempty"`
+	CreatedAt     time.Time  `gorm:"default:CURRENT_TIMESTAMP;index" json:"created_at"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// WebhookEndpointRequest for registering/updating an endpoint
+type WebhookEndpointRequest struct {
+	URL         string   `json:"url" binding:"required,url,max=500"`
+	Description string   `json:"description" binding:"max=255"`
+	Events      []string `json:"events" binding:"required,min=1,dive,oneof=sensor.threshold lamp.state door.state curtain.state access.log user.approval"`
+	Enabled     *bool    `json:"enabled"`
+}
+
+// WebhookDeliveryFilter for the delivery / dead-letter views
+type WebhookDeliveryFilter struct {
+	EndpointID *uint
+	Status     string
+	EventType  string
+	Limit      int
+}
+
+// SensorThreshold bounds for sensor.threshold events (nil = no bound)
+type SensorThreshold struct {
+	Low  *float64 `json:"low,omitempty"`
+	High *float64 `json:"high,omitempty"`
+}
+
+// SensorThresholds per sensor: gas, temperature, humidity, light
+type SensorThresholds map[string]SensorThreshold
+
+// DefaultSensorThresholds - gas danger matches handleGas (> 500 PPM)
+func DefaultSensorThresholds() SensorThresholds {
+	gasHigh, tempHigh, humidHigh := 500.0, 35.0, 80.0
+	return SensorThresholds{
+		"gas":         {High: &gasHigh},
+		"temperature": {High: &tempHigh},
+		"humidity":    {High: &humidHigh},
+	}
+}
+
+// RawJSON keeps a stored JSON document as-is (served unescaped in API responses)
+type RawJSON []byte
+
+func (j RawJSON) Value() (driver.Value, error) {
+	if len(j) == 0 {
+		return "null", nil
+	}
+	return string(j), nil
+}
+
+func (j *RawJSON) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*j = nil
+	case []byte:
+		*j = append((*j)[:0], v...)
+	case string:
+		*j = RawJSON(v)
+	default:
+		return errors.New("unsupported JSON column type")
+	}
+	return nil
+}
+
+func (j RawJSON) MarshalJSON() ([]byte, error) {
+	if len(j) == 0 {
+		return []byte("null"), nil
+	}
+	return j, nil
+}
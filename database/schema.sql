@@ -30,12 +30,13 @@ CREATE TABLE IF NOT EXISTS users (
 CREATE TABLE IF NOT EXISTS fingerprint_data (
     fingerprint_id INT AUTO_INCREMENT PRIMARY KEY,
     user_id INT NOT NULL,
-    template_index INT NOT NULL,
+    template_index INT NOT NULL,     -- slot on the door unit's fingerprint sensor
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 
     CONSTRAINT fk_fp_user FOREIGN KEY (user_id)
         REFERENCES users(user_id)
         ON DELETE CASCADE,
+    UNIQUE KEY uniq_template_index (template_index),
     INDEX idx_user_id (user_id)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
 
@@ -43,7 +44,8 @@ DROP TABLE IF EXISTS pin_codes;
 
 CREATE TABLE IF NOT EXISTS pin_codes (
     id INT AUTO_INCREMENT PRIMARY KEY,
-    universal_pin VARCHAR(6) NOT NULL UNIQUE,
+    pin_hash VARCHAR(255) NOT NULL,
+    universal_pin VARCHAR(6) NULL, -- legacy plaintext, hashed & cleared on startup
     set_by INT NOT NULL,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
@@ -54,21 +56,91 @@ CREATE TABLE IF NOT EXISTS pin_codes (
     INDEX idx_created_at (created_at)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
 
+-- ============================================================
+-- TABLE: PIN_ATTEMPTS (brute-force lockout per source)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS pin_attempts (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    source VARCHAR(100) NOT NULL UNIQUE,
+    failed_count INT NOT NULL DEFAULT 0,
+    lockout_level INT NOT NULL DEFAULT 0,
+    locked_until DATETIME NULL,
+    last_attempt_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_locked_until (locked_until)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- ============================================================
+-- TABLE: USER_PINS (personal keypad PINs, bcrypt)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS user_pins (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL UNIQUE,
+    pin_hash VARCHAR(255) NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_user_pin_user FOREIGN KEY (user_id)
+        REFERENCES users(user_id)
+        ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- ============================================================
+-- TABLE: SYSTEM_SETTINGS (key/value runtime settings)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS system_settings (
+    setting_key VARCHAR(100) PRIMARY KEY,
+    setting_value TEXT NOT NULL,
+    updated_by INT NULL,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_setting_updated_by FOREIGN KEY (updated_by)
+        REFERENCES users(user_id)
+        ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- ============================================================
+-- TABLE: GUEST_CODES (time-limited visitor keypad codes)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS guest_codes (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    label VARCHAR(100) NOT NULL,
+    code_hash VARCHAR(255) NOT NULL,
+    valid_from DATETIME NOT NULL,
+    valid_until DATETIME NOT NULL,
+    max_uses INT NOT NULL DEFAULT 0, -- 0 = unlimited
+    use_count INT NOT NULL DEFAULT 0,
+    allowed_days VARCHAR(27) NULL,   -- e.g. 'mon,wed,fri'
+    time_start VARCHAR(5) NULL,      -- 'HH:MM'
+    time_end VARCHAR(5) NULL,
+    revoked BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by INT NOT NULL,
+    last_used_at DATETIME NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_guest_created_by FOREIGN KEY (created_by)
+        REFERENCES users(user_id)
+        ON DELETE RESTRICT,
+    INDEX idx_valid_until (valid_until)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
 -- ============================================================
 -- TABLE: ACCESS_LOGS
 -- ============================================================
 CREATE TABLE IF NOT EXISTS access_logs (
     access_id INT AUTO_INCREMENT PRIMARY KEY,
     user_id INT,
-    method ENUM('face','pin','remote','unknown') DEFAULT 'unknown',
+    method ENUM('face','pin','remote','fingerprint','unknown') DEFAULT 'unknown',
     status ENUM('success','failed') DEFAULT 'failed',
     image_path TEXT,
+    capture_id INT NULL,
+    guest_label VARCHAR(100) NULL,
     timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
 
     CONSTRAINT fk_access_user FOREIGN KEY (user_id)
         REFERENCES users(user_id)
         ON DELETE SET NULL,
     INDEX idx_user_id (user_id),
+    INDEX idx_capture_id (capture_id),
     INDEX idx_method (method),
     INDEX idx_status (status),
     INDEX idx_timestamp (timestamp)
@@ -79,10 +151,31 @@ CREATE TABLE IF NOT EXISTS access_logs (
 -- ============================================================
 CREATE TABLE IF NOT EXISTS camera_captures (
     capture_id INT AUTO_INCREMENT PRIMARY KEY,
-    image_path TEXT NOT NULL,
+    image_path TEXT NOT NULL,            -- file name under UPLOAD_DIR (<sha256>.<ext>)
     detected_face VARCHAR(100),
+    user_id INT NULL,                    -- recognized user, NULL for unknown faces
+    content_hash CHAR(64),
+    content_type VARCHAR(50),
+    size_bytes INT NOT NULL DEFAULT 0,
     timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
-    INDEX idx_timestamp (timestamp)
+    INDEX idx_timestamp (timestamp),
+    INDEX idx_user_id (user_id),
+    INDEX idx_content_hash (content_hash)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- ============================================================
+-- TABLE: CAMERAS (ESP32-CAM registry, filled from MQTT camera/ip)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS cameras (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    device_id VARCHAR(64) NOT NULL,
+    name VARCHAR(100),
+    ip_address VARCHAR(64) NOT NULL,
+    stream_port INT NOT NULL DEFAULT 81,
+    last_seen_at DATETIME NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uniq_device_id (device_id)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
 
 -- ============================================================
@@ -103,8 +196,14 @@ CREATE TABLE IF NOT EXISTS sensor_gas (
 CREATE TABLE IF NOT EXISTS buzzer_log (
     buzzer_id INT AUTO_INCREMENT PRIMARY KEY,
     status ENUM('on','off') DEFAULT 'off',
+    source VARCHAR(20),              -- manual | gas | alarm | device
     reason VARCHAR(200),
+    user_id INT NULL,                -- who pressed it (manual / alarm disarm)
+    confirmed_at DATETIME NULL,      -- device reported the state on buzzer/status
+    error VARCHAR(255) NULL,         -- publish failed / expired in the offline queue
     timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_source (source),
+    INDEX idx_user_id (user_id),
     INDEX idx_timestamp (timestamp)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
 
@@ -144,7 +243,7 @@ CREATE TABLE IF NOT EXISTS sensor_light (
 CREATE TABLE IF NOT EXISTS door_status (
     door_id INT AUTO_INCREMENT PRIMARY KEY,
     status ENUM('locked','unlocked') DEFAULT 'locked',
-    method ENUM('face','pin','remote','auto') DEFAULT 'remote',
+    method ENUM('face','pin','remote','fingerprint','auto','auto_relock') DEFAULT 'remote',
     timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
     INDEX idx_status (status),
     INDEX idx_timestamp (timestamp)
@@ -168,11 +267,238 @@ CREATE TABLE IF NOT EXISTS lamp_status (
 CREATE TABLE IF NOT EXISTS curtain_status (
     curtain_id INT AUTO_INCREMENT PRIMARY KEY,
     position INT NOT NULL DEFAULT 0,
+    status ENUM('open','closed') DEFAULT 'closed',
     mode ENUM('auto','manual') DEFAULT 'manual',
     timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
     INDEX idx_timestamp (timestamp)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
 
+-- ============================================================
+-- TABLE: DEVICE_STATE_EVENTS (append-only lamp/curtain transitions;
+-- lamp_status / curtain_status hold the current state)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS device_state_events (
+    event_id INT AUTO_INCREMENT PRIMARY KEY,
+    device ENUM('lamp','curtain') NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    previous_status VARCHAR(20) NULL,
+    mode VARCHAR(20) NULL,
+    previous_mode VARCHAR(20) NULL,
+    source VARCHAR(30) DEFAULT 'device',     -- device, api, automation, schedule, scene
+    source_id INT NULL,
+    user_id INT NULL,
+    correlation_id VARCHAR(36) NULL,
+    timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_state_event_user FOREIGN KEY (user_id)
+        REFERENCES users(user_id)
+        ON DELETE SET NULL,
+    INDEX idx_device_timestamp (device, timestamp),
+    INDEX idx_source (source),
+    INDEX idx_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- ============================================================
+-- TABLE: DEVICE_COMMANDS (control commands awaiting */status confirmation)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS device_commands (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    correlation_id VARCHAR(36) NOT NULL UNIQUE,
+    device ENUM('door','lamp','curtain') NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    mode VARCHAR(20) NULL,
+    method VARCHAR(20) NULL,
+    status ENUM('pending','confirmed','timed_out','failed') DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 1,
+    source VARCHAR(30) DEFAULT 'api',
+    source_id INT NULL,
+    user_id INT NULL,
+    error VARCHAR(255) NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    confirmed_at DATETIME NULL,
+
+    CONSTRAINT fk_command_user FOREIGN KEY (user_id)
+        REFERENCES users(user_id)
+        ON DELETE SET NULL,
+    INDEX idx_status (status),
+    INDEX idx_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- ============================================================
+-- TABLE: AUTOMATION_RULES (sensor-triggered rules, JSON conditions/actions)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS automation_rules (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    conditions JSON NOT NULL,
+    actions JSON NOT NULL,
+    cooldown_seconds INT NOT NULL DEFAULT 300,
+    last_fired_at DATETIME NULL,
+    created_by INT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_rule_created_by FOREIGN KEY (created_by)
+        REFERENCES users(user_id)
+        ON DELETE SET NULL,
+    INDEX idx_enabled (enabled)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS automation_rule_logs (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    rule_id INT NOT NULL,
+    rule_name VARCHAR(100),
+    trigger_type VARCHAR(30),
+    trigger_value DOUBLE,
+    status ENUM('success','partial','failed') DEFAULT 'success',
+    results JSON,
+    fired_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_rule_log_rule FOREIGN KEY (rule_id)
+        REFERENCES automation_rules(id)
+        ON DELETE CASCADE,
+    INDEX idx_rule_id (rule_id),
+    INDEX idx_fired_at (fired_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- ============================================================
+-- TABLE: SCHEDULES (cron-style device actions)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS schedules (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    cron VARCHAR(100) NOT NULL,          -- 'minute hour dom month dow'
+    timezone VARCHAR(64) NOT NULL,
+    device ENUM('door','lamp','curtain') NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    mode VARCHAR(20) NULL,
+    paused BOOLEAN NOT NULL DEFAULT FALSE,
+    skip_next BOOLEAN NOT NULL DEFAULT FALSE,
+    next_run_at DATETIME NULL,
+    last_run_at DATETIME NULL,
+    last_status VARCHAR(20) NULL,
+    last_command_id VARCHAR(36) NULL,
+    created_by INT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_schedule_created_by FOREIGN KEY (created_by)
+        REFERENCES users(user_id)
+        ON DELETE SET NULL,
+    INDEX idx_paused (paused)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- ============================================================
+-- TABLE: SCENES (named multi-device presets)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS scenes (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description VARCHAR(255) NULL,
+    actions JSON NOT NULL,               -- [{"device":"lamp","action":"off"}, ...]
+    last_activated_at DATETIME NULL,
+    last_activated_by INT NULL,
+    created_by INT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_scene_activated_by FOREIGN KEY (last_activated_by)
+        REFERENCES users(user_id)
+        ON DELETE SET NULL,
+    CONSTRAINT fk_scene_created_by FOREIGN KEY (created_by)
+        REFERENCES users(user_id)
+        ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS scene_activations (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    scene_id INT NOT NULL,
+    scene_name VARCHAR(100),
+    user_id INT NULL,
+    status ENUM('success','partial','failed') DEFAULT 'success',
+    results JSON,
+    activated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_scene_activation_scene FOREIGN KEY (scene_id)
+        REFERENCES scenes(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_scene_activation_user FOREIGN KEY (user_id)
+        REFERENCES users(user_id)
+        ON DELETE SET NULL,
+    INDEX idx_scene_id (scene_id),
+    INDEX idx_activated_at (activated_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- ============================================================
+-- TABLE: ALARM_EVENTS (append-only; latest row = current alarm state)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS alarm_events (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    state ENUM('disarmed','armed_home','armed_away','triggered') NOT NULL,
+    previous_state VARCHAR(20) NULL,
+    reason VARCHAR(255) NULL,
+    source VARCHAR(30) NULL,             -- api, door, face
+    user_id INT NULL,
+    image_base64 LONGTEXT NULL,          -- snapshot that tripped the alarm
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_alarm_user FOREIGN KEY (user_id)
+        REFERENCES users(user_id)
+        ON DELETE SET NULL,
+    INDEX idx_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- ============================================================
+-- TABLE: GAS_INCIDENTS (gas emergency from trigger to all-clear)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS gas_incidents (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    status ENUM('active','resolved') NOT NULL DEFAULT 'active',
+    start_ppm INT NOT NULL,
+    peak_ppm INT NOT NULL,
+    started_at DATETIME NOT NULL,
+    peak_at DATETIME NOT NULL,
+    ended_at DATETIME NULL,
+    actions JSON NULL,                   -- playbook actions and their results
+
+    INDEX idx_status (status),
+    INDEX idx_started_at (started_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- ============================================================
+-- TABLE: FACE_RECOGNITION_LOGS (results pushed by the Python face service)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS face_recognition_logs (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NULL,
+    name VARCHAR(100),
+    confidence DOUBLE,
+    recognized BOOLEAN,
+    message VARCHAR(255),
+    source VARCHAR(50),
+    timestamp DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_user_id (user_id),
+    INDEX idx_timestamp (timestamp)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Unknown-face alerts awaiting admin review
+CREATE TABLE IF NOT EXISTS face_alerts (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    alert_type VARCHAR(50),
+    image_base64 LONGTEXT,
+    source VARCHAR(50),
+    resolved BOOLEAN DEFAULT FALSE,
+    resolved_by INT NULL,
+    resolved_at DATETIME NULL,
+    timestamp DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_resolved (resolved),
+    INDEX idx_timestamp (timestamp)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
 -- ============================================================
 -- TABLE: NOTIFICATIONS
 -- ============================================================
@@ -180,12 +506,112 @@ CREATE TABLE IF NOT EXISTS notifications (
     notif_id INT AUTO_INCREMENT PRIMARY KEY,
     title VARCHAR(200) NOT NULL,
     message TEXT,
-    type ENUM('gas','door','system','intruder') DEFAULT 'system',
+    type ENUM('gas','door','system','intruder','access','device','approval') DEFAULT 'system',
     timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
     INDEX idx_type (type),
     INDEX idx_timestamp (timestamp)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
 
+-- Per-user read markers (no row = unread)
+CREATE TABLE IF NOT EXISTS notification_reads (
+    notif_id INT NOT NULL,
+    user_id INT NOT NULL,
+    read_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (notif_id, user_id),
+
+    CONSTRAINT fk_notif_read_notif FOREIGN KEY (notif_id)
+        REFERENCES notifications(notif_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_notif_read_user FOREIGN KEY (user_id)
+        REFERENCES users(user_id)
+        ON DELETE CASCADE,
+    INDEX idx_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Outbound channels per user; types = subscribed notification types (JSON array)
+CREATE TABLE IF NOT EXISTS notification_channels (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    kind ENUM('webhook','email','chatbot') NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    target VARCHAR(255) NOT NULL,
+    types JSON NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_notif_channel_user FOREIGN KEY (user_id)
+        REFERENCES users(user_id)
+        ON DELETE CASCADE,
+    INDEX idx_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Delivery log (notif_id NULL = test message)
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    notif_id INT NULL,
+    channel_id INT NOT NULL,
+    user_id INT NOT NULL,
+    kind VARCHAR(20),
+    status ENUM('sent','failed','rate_limited') NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    error VARCHAR(500),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    delivered_at DATETIME NULL,
+
+    CONSTRAINT fk_notif_delivery_notif FOREIGN KEY (notif_id)
+        REFERENCES notifications(notif_id)
+        ON DELETE SET NULL,
+    CONSTRAINT fk_notif_delivery_channel FOREIGN KEY (channel_id)
+        REFERENCES notification_channels(id)
+        ON DELETE CASCADE,
+    INDEX idx_user_created (user_id, created_at),
+    INDEX idx_channel_id (channel_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- ============================================================
+-- TABLE: WEBHOOK ENDPOINTS (signed outgoing domain events)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    url VARCHAR(500) NOT NULL,
+    description VARCHAR(255),
+    secret VARCHAR(64) NOT NULL,
+    events JSON NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by INT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_webhook_created_by FOREIGN KEY (created_by)
+        REFERENCES users(user_id)
+        ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Delivery queue: pending rows are retried with exponential backoff, then 'dead'
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    endpoint_id INT NOT NULL,
+    event_id VARCHAR(36) NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSON NOT NULL,
+    status ENUM('pending','delivered','dead') NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME NULL,
+    response_code INT,
+    last_error VARCHAR(500),
+    redelivery_of INT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    delivered_at DATETIME NULL,
+
+    CONSTRAINT fk_webhook_delivery_endpoint FOREIGN KEY (endpoint_id)
+        REFERENCES webhook_endpoints(id)
+        ON DELETE CASCADE,
+    INDEX idx_status_next (status, next_attempt_at),
+    INDEX idx_event_id (event_id),
+    INDEX idx_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
 
 INSERT INTO users (name, email, password, role, status) VALUES
 ('Admin', 'admin@smarthome.local', '$2a$10$8K1p/a0dL3.2E7HVy2Z3KeY5I.KH9.nZ8sH1J5xZ6K1xL7Y9Z3KeY', 'admin', 'active')
@@ -195,9 +621,14 @@ INSERT INTO users (name, email, password, role, status) VALUES
 ('John Doe', 'john@example.com', '$2a$10$8K1p/a0dL3.2E7HVy2Z3KeY5I.KH9.nZ8sH1J5xZ6K1xL7Y9Z3KeY', 'member', 'active')
 ON DUPLICATE KEY UPDATE name=name;
 
-INSERT INTO pin_codes (universal_pin, set_by) VALUES
-('123456', 1)
-ON DUPLICATE KEY UPDATE universal_pin=universal_pin;
+-- Default universal PIN 123456 (bcrypt)
+INSERT INTO pin_codes (pin_hash, set_by) VALUES
+('$2a$10$GKfyz9SLrYtnVahpYs/tqOXcUlUHqtFO6RCqxosBkqpDFLoUberY6', 1);
+
+-- Universal PIN stays enabled as fallback until an admin turns it off
+INSERT INTO system_settings (setting_key, setting_value) VALUES
+('universal_pin_enabled', 'true')
+ON DUPLICATE KEY UPDATE setting_key=setting_key;
 
 -- Insert initial notifications
 INSERT INTO notifications (title, message, type) VALUES
@@ -1,22 +1,28 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"smarthome-backend/database/models"
+	"smarthome-backend/internal/middleware"
 	"smarthome-backend/internal/service"
 
 	"github.com/gin-gonic/gin"
 )
 
 type AdminHandler struct {
-	pinSvc  service.PinService
-	userSvc service.UserService
+	pinSvc   service.PinService
+	userSvc  service.UserService
+	notifSvc service.NotificationService
+	events   service.EventPublisher
 }
 
-func NewAdminHandler(pSvc service.PinService, uSvc service.UserService) *AdminHandler {
+func NewAdminHandler(pSvc service.PinService, uSvc service.UserService, nSvc service.NotificationService, events service.EventPublisher) *AdminHandler {
 	return &AdminHandler{
-		pinSvc:  pSvc,
-		userSvc: uSvc,
+		pinSvc:   pSvc,
+		userSvc:  uSvc,
+		notifSvc: nSvc,
+		events:   events,
 	}
 }
 
@@ -26,7 +32,14 @@ func (h *AdminHandler) GetUniversalPin(c *gin.Context) {
 		c.JSON(500, gin.H{"success": false, "error": "Failed to retrieve universal PIN"})
 		return
 	}
-	c.JSON(200, gin.H{"success": true, "data": pin})
+	// Only metadata is exposed; the PIN itself is stored as a hash
+	c.JSON(200, gin.H{"success": true, "data": gin.H{
+		"configured": pin.PinHash != "",
+		"enabled":    h.pinSvc.IsUniversalPinEnabled(),
+		"set_by":     pin.SetBy,
+		"created_at": pin.CreatedAt,
+		"updated_at": pin.UpdatedAt,
+	}})
 }
 
 func (h *AdminHandler) SetUniversalPin(c *gin.Context) {
@@ -36,8 +49,13 @@ func (h *AdminHandler) SetUniversalPin(c *gin.Context) {
 		return
 	}
 
-	// ⭐ FIX: Pass 2 parameter dengan field yang benar
-	if err := h.pinSvc.SetUniversalPin(req.UniversalPin, req.SetBy); err != nil {
+	// Audit field comes from the token, not the body
+	admin := middleware.CurrentUser(c)
+	if err := h.pinSvc.SetUniversalPin(req.UniversalPin, admin.UserID); err != nil {
+		if errors.Is(err, service.ErrPinInUse) {
+			c.JSON(409, gin.H{"success": false, "error": err.Error()})
+			return
+		}
 		c.JSON(500, gin.H{"success": false, "error": "Failed to set universal PIN"})
 		return
 	}
@@ -45,6 +63,23 @@ func (h *AdminHandler) SetUniversalPin(c *gin.Context) {
 	c.JSON(200, gin.H{"success": true, "message": "Universal PIN updated successfully"})
 }
 
+// SetUniversalPinStatus enables/disables the shared fallback PIN (personal PINs keep working)
+func (h *AdminHandler) SetUniversalPinStatus(c *gin.Context) {
+	var req models.UniversalPinStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	user := middleware.CurrentUser(c)
+	if err := h.pinSvc.SetUniversalPinEnabled(*req.Enabled, user.UserID); err != nil {
+		c.JSON(500, gin.H{"success": false, "error": "Failed to update universal PIN status"})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "data": gin.H{"enabled": *req.Enabled}})
+}
+
 func (h *AdminHandler) GetPendingUsers(c *gin.Context) {
 	users, err := h.userSvc.GetPending()
 	if err != nil {
@@ -71,6 +106,8 @@ func (h *AdminHandler) Approve(c *gin.Context) {
 		c.JSON(500, gin.H{"success": false, "error": "Failed to approve user: " + err.Error()})
 		return
 	}
+	user, _ := h.userSvc.GetByID(id)
+	h.notifyApproval(user, id, "approved", c)
 
 	c.JSON(200, gin.H{
 		"success": true,
@@ -90,11 +127,15 @@ func (h *AdminHandler) Reject(c *gin.Context) {
 		return
 	}
 
+	// Name is gone after the delete, resolve it first
+	user, _ := h.userSvc.GetByID(id)
+
 	// Reject user (delete)
 	if err := h.userSvc.Reject(id); err != nil {
 		c.JSON(500, gin.H{"success": false, "error": "Failed to reject user: " + err.Error()})
 		return
 	}
+	h.notifyApproval(user, id, "rejected", c)
 
 	c.JSON(200, gin.H{
 		"success": true,
@@ -103,6 +144,32 @@ func (h *AdminHandler) Reject(c *gin.Context) {
 	})
 }
 
+// notifyApproval records who approved/rejected a registration
+func (h *AdminHandler) notifyApproval(user *models.User, id uint, decision string, c *gin.Context) {
+	name := fmt.Sprintf("User #%d", id)
+	if user != nil && user.UserID > 0 {
+		name = user.Name
+	}
+	by := "an admin"
+	if admin := middleware.CurrentUser(c); admin != nil {
+		by = admin.Name
+	}
+
+	go h.notifSvc.Notify(models.NotifApproval, "Registration "+decision,
+		fmt.Sprintf("%s was %s by %s", name, decision, by))
+
+	event := map[string]interface{}{
+		"user_id":    id,
+		"decision":   decision,
+		"decided_by": middleware.CurrentUserID(c),
+	}
+	if user != nil && user.UserID > 0 {
+		event["name"] = user.Name
+		event["email"] = user.Email
+	}
+	h.events.Publish(models.EventUserApproval, event)
+}
+
 // ==================== ADMIN CRUD ====================
 
 func (h *AdminHandler) CreateAdmin(c *gin.Context) {
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"smarthome-backend/database/models"
+	"smarthome-backend/internal/middleware"
+	"smarthome-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AlarmHandler struct {
+	svc service.AlarmService
+}
+
+func NewAlarmHandler(s service.AlarmService) *AlarmHandler {
+	return &AlarmHandler{svc: s}
+}
+
+// GetStatus - Current alarm state
+// GET /api/alarm
+func (h *AlarmHandler) GetStatus(c *gin.Context) {
+	c.JSON(200, gin.H{"success": true, "data": h.svc.GetStatus()})
+}
+
+// SetState - Arm/disarm
+// PUT /api/alarm {"state": "armed_away"}
+func (h *AlarmHandler) SetState(c *gin.Context) {
+	var req models.AlarmStateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	status, err := h.svc.SetState(req.State, middleware.CurrentUserID(c))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidAlarmTransition) {
+			c.JSON(409, gin.H{"success": false, "error": err.Error(), "data": status})
+			return
+		}
+		c.JSON(500, gin.H{"success": false, "error": "Failed to update alarm state"})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "message": "Alarm " + status.State, "data": status})
+}
+
+// GetEvents - Alarm history (without snapshots)
+// GET /api/alarm/events?limit=100
+func (h *AlarmHandler) GetEvents(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+
+	events, err := h.svc.GetEvents(limit)
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "error": "Failed to retrieve alarm events"})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "data": events})
+}
+
+// GetEvent - One alarm event including its snapshot
+// GET /api/alarm/events/:id
+func (h *AlarmHandler) GetEvent(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "error": "Invalid event ID"})
+		return
+	}
+
+	event, err := h.svc.GetEvent(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"success": false, "error": "Alarm event not found"})
+			return
+		}
+		c.JSON(500, gin.H{"success": false, "error": "Failed to retrieve alarm event"})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "data": event})
+}
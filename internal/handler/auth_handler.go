@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"fmt"
 	"smarthome-backend/database/models"
 	"smarthome-backend/internal/service"
 
@@ -8,14 +9,16 @@ import (
 )
 
 type AuthHandler struct {
-	userSvc service.UserService
-	authSvc service.AuthService
+	userSvc  service.UserService
+	authSvc  service.AuthService
+	notifSvc service.NotificationService
 }
 
-func NewAuthHandler(uSvc service.UserService, aSvc service.AuthService) *AuthHandler {
+func NewAuthHandler(uSvc service.UserService, aSvc service.AuthService, nSvc service.NotificationService) *AuthHandler {
 	return &AuthHandler{
-		userSvc: uSvc,
-		authSvc: aSvc,
+		userSvc:  uSvc,
+		authSvc:  aSvc,
+		notifSvc: nSvc,
 	}
 }
 
@@ -82,6 +85,9 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	println("[AUTH] User updated successfully!")
 	println("[AUTH] Registration completed successfully!")
 
+	go h.notifSvc.Notify(models.NotifApproval, "New Registration",
+		fmt.Sprintf("%s (%s) is waiting for admin approval", user.Name, user.Email))
+
 	c.JSON(201, gin.H{
 		"success": true,
 		"message": "Registration successful. Waiting for admin approval.",
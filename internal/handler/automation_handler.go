@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"smarthome-backend/database/models"
+	"smarthome-backend/internal/middleware"
+	"smarthome-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AutomationHandler struct {
+	svc service.AutomationService
+}
+
+func NewAutomationHandler(s service.AutomationService) *AutomationHandler {
+	return &AutomationHandler{svc: s}
+}
+
+func (h *AutomationHandler) GetAll(c *gin.Context) {
+	rules, err := h.svc.GetAll()
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "error": "Failed to retrieve automation rules"})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "data": rules})
+}
+
+func (h *AutomationHandler) GetByID(c *gin.Context) {
+	id, ok := parseRuleID(c)
+	if !ok {
+		return
+	}
+
+	rule, err := h.svc.GetByID(id)
+	if err != nil {
+		respondRuleError(c, err, "Failed to retrieve automation rule")
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "data": rule})
+}
+
+func (h *AutomationHandler) Create(c *gin.Context) {
+	var req models.AutomationRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	rule, err := h.svc.Create(req, middleware.CurrentUserID(c))
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(201, gin.H{"success": true, "message": "Automation rule created", "data": rule})
+}
+
+func (h *AutomationHandler) Update(c *gin.Context) {
+	id, ok := parseRuleID(c)
+	if !ok {
+		return
+	}
+
+	var req models.AutomationRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	rule, err := h.svc.Update(id, req)
+	if err != nil {
+		respondRuleError(c, err, err.Error())
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "message": "Automation rule updated", "data": rule})
+}
+
+func (h *AutomationHandler) SetEnabled(c *gin.Context) {
+	id, ok := parseRuleID(c)
+	if !ok {
+		return
+	}
+
+	var req models.EnabledRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	if err := h.svc.SetEnabled(id, *req.Enabled); err != nil {
+		respondRuleError(c, err, "Failed to update automation rule")
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "data": gin.H{"id": id, "enabled": *req.Enabled}})
+}
+
+func (h *AutomationHandler) Delete(c *gin.Context) {
+	id, ok := parseRuleID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Delete(id); err != nil {
+		c.JSON(500, gin.H{"success": false, "error": "Failed to delete automation rule"})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "message": "Automation rule deleted"})
+}
+
+// GetLogs - Firing history of all rules, or one rule via /:id/logs
+func (h *AutomationHandler) GetLogs(c *gin.Context) {
+	var ruleID *uint
+	if c.Param("id") != "" {
+		id, ok := parseRuleID(c)
+		if !ok {
+			return
+		}
+		ruleID = &id
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+
+	logs, err := h.svc.GetLogs(ruleID, limit)
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "error": "Failed to retrieve automation logs"})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "data": logs})
+}
+
+func parseRuleID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "error": "Invalid rule ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondRuleError(c *gin.Context, err error, message string) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(404, gin.H{"success": false, "error": "Automation rule not found"})
+		return
+	}
+	c.JSON(400, gin.H{"success": false, "error": message})
+}
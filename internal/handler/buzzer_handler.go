@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"strconv"
+
+	"smarthome-backend/database/models"
+	"smarthome-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BuzzerHandler struct {
+	svc service.BuzzerService
+}
+
+func NewBuzzerHandler(s service.BuzzerService) *BuzzerHandler {
+	return &BuzzerHandler{svc: s}
+}
+
+// GetLatest - Last reported buzzer state and the latest log entry
+// GET /api/device/buzzer/latest
+func (h *BuzzerHandler) GetLatest(c *gin.Context) {
+	c.JSON(200, gin.H{"success": true, "data": h.svc.GetState()})
+}
+
+// GetHistory - Buzzer activations, newest first
+// GET /api/device/buzzer/history?status=on&source=gas&user_id=3&from=2024-01-01&to=2024-01-31&limit=100
+func (h *BuzzerHandler) GetHistory(c *gin.Context) {
+	filter := models.BuzzerLogFilter{
+		Status: c.Query("status"),
+		Source: c.Query("source"),
+		Limit:  parseLimitQuery(c, 100, 1000),
+	}
+	if filter.Status != "" && filter.Status != "on" && filter.Status != "off" {
+		c.JSON(400, gin.H{"success": false, "error": "status must be on or off"})
+		return
+	}
+	switch filter.Source {
+	case "", models.BuzzerSourceManual, models.BuzzerSourceGas, models.BuzzerSourceAlarm, models.BuzzerSourceDevice:
+	default:
+		c.JSON(400, gin.H{"success": false, "error": "source must be manual, gas, alarm or device"})
+		return
+	}
+	if raw := c.Query("user_id"); raw != "" {
+		userID, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			c.JSON(400, gin.H{"success": false, "error": "Invalid user_id"})
+			return
+		}
+		id := uint(userID)
+		filter.UserID = &id
+	}
+
+	from, to, ok := parseTimeRange(c)
+	if !ok {
+		return
+	}
+	filter.From, filter.To = from, to
+
+	entries, err := h.svc.GetHistory(filter)
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "error": "Failed to retrieve buzzer history"})
+		return
+	}
+	c.JSON(200, gin.H{"success": true, "data": entries})
+}
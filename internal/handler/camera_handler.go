@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"smarthome-backend/internal/middleware"
+	"smarthome-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CameraHandler struct {
+	cameras  service.CameraService
+	captures service.CameraCaptureService
+}
+
+func NewCameraHandler(cameras service.CameraService, captures service.CameraCaptureService) *CameraHandler {
+	return &CameraHandler{cameras: cameras, captures: captures}
+}
+
+// GetAll - Registered ESP32-CAMs with their proxied snapshot/stream URLs
+// GET /api/camera
+func (h *CameraHandler) GetAll(c *gin.Context) {
+	cameras, err := h.cameras.GetAll()
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "error": "Failed to retrieve cameras"})
+		return
+	}
+	c.JSON(200, gin.H{"success": true, "data": cameras})
+}
+
+// GetByID - One camera
+// GET /api/camera/:id
+func (h *CameraHandler) GetByID(c *gin.Context) {
+	id, ok := parseCameraID(c)
+	if !ok {
+		return
+	}
+
+	camera, err := h.cameras.GetByID(id)
+	if err != nil {
+		respondCameraError(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"success": true, "data": camera})
+}
+
+// Snapshot - Single JPEG fetched from the camera through the backend
+// GET /api/camera/:id/snapshot
+func (h *CameraHandler) Snapshot(c *gin.Context) {
+	id, ok := parseCameraID(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.cameras.OpenSnapshot(c.Request.Context(), id)
+	if err != nil {
+		respondCameraError(c, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	c.Header("Cache-Control", "no-store")
+	c.DataFromReader(200, resp.ContentLength, resp.Header.Get("Content-Type"), resp.Body, nil)
+}
+
+// Stream - MJPEG stream relayed from the camera until the viewer disconnects
+// GET /api/camera/:id/stream
+func (h *CameraHandler) Stream(c *gin.Context) {
+	id, ok := parseCameraID(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.cameras.OpenStream(c.Request.Context(), id)
+	if err != nil {
+		respondCameraError(c, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	// Keep the camera's multipart boundary so the client can split frames
+	c.Header("Content-Type", resp.Header.Get("Content-Type"))
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(200)
+
+	buf := make([]byte, 32*1024)
+	c.Stream(func(w io.Writer) bool {
+		n, err := resp.Body.Read(buf)
+		if n > 0 {
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return false
+			}
+		}
+		return err == nil
+	})
+}
+
+func parseCameraID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "error": "Invalid camera ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// respondCameraError never echoes the upstream error, it contains the camera's LAN address
+func respondCameraError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(404, gin.H{"success": false, "error": "Camera not found"})
+	case errors.Is(err, service.ErrCameraUnreachable):
+		log.Printf("[CAMERA] Proxy failed: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"success": false, "error": "Camera is not reachable"})
+	default:
+		c.JSON(500, gin.H{"success": false, "error": "Failed to load camera"})
+	}
+}
+
+// GetCaptureImage - Stored ESP32-CAM frame (admin, or the user it shows)
+// GET /api/camera/captures/:id/image
+func (h *CameraHandler) GetCaptureImage(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "error": "Invalid capture ID"})
+		return
+	}
+
+	capture, err := h.captures.GetByID(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"success": false, "error": "Capture not found"})
+			return
+		}
+		c.JSON(500, gin.H{"success": false, "error": "Failed to load capture"})
+		return
+	}
+
+	allowed, err := h.captures.CanView(middleware.CurrentUser(c), capture)
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "error": "Failed to load capture"})
+		return
+	}
+	if !allowed {
+		c.JSON(403, gin.H{"success": false, "error": "Insufficient permissions"})
+		return
+	}
+
+	path := h.captures.FilePath(capture)
+	if _, err := os.Stat(path); err != nil {
+		c.JSON(404, gin.H{"success": false, "error": "Capture image no longer available"})
+		return
+	}
+
+	// Files are named by content hash, so they never change
+	c.Header("Cache-Control", "private, max-age=86400, immutable")
+	c.Header("Content-Type", capture.ContentType)
+	c.File(path)
+}
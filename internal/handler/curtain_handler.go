@@ -4,8 +4,6 @@ import (
 	"smarthome-backend/database/models"
 	"smarthome-backend/internal/service"
 
-	// "strconv" -> Hapus ini karena tidak dipakai lagi
-
 	"github.com/gin-gonic/gin"
 )
 
@@ -24,7 +22,11 @@ func (h *CurtainHandler) Create(c *gin.Context) {
 		return
 	}
 
-	err := h.svc.ProcessCurtain(req.Status, req.Mode)
+	_, err := h.svc.ProcessCurtain(models.DeviceStateChange{
+		Status: req.Status,
+		Mode:   req.Mode,
+		Source: models.DeviceStateSourceDevice,
+	})
 	if err != nil {
 		c.JSON(500, gin.H{"success": false, "error": "Failed to save curtain status"})
 		return
@@ -43,6 +45,18 @@ func (h *CurtainHandler) GetLatest(c *gin.Context) {
 	c.JSON(200, gin.H{"success": true, "data": data})
 }
 
-// --- FUNGSI GetAll DIHAPUS ---
-// Karena kita hanya menyimpan 1 baris data (Upsert),
-// maka tidak ada history yang bisa diambil.
+// GetTimeline - Curtain state transitions, newest first
+// GET /api/device/curtain/timeline?from=2024-01-01&to=2024-01-31&source=api&user_id=3&limit=50
+func (h *CurtainHandler) GetTimeline(c *gin.Context) {
+	filter, ok := parseDeviceStateFilter(c)
+	if !ok {
+		return
+	}
+	data, err := h.svc.GetTimeline(filter)
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "error": "Failed to retrieve data"})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "data": data})
+}
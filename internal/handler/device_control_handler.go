@@ -1,46 +1,49 @@
 package handler
 
 import (
-	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"strconv"
+	"time"
 
+	"smarthome-backend/database/models"
+	"smarthome-backend/internal/middleware"
+	"smarthome-backend/internal/mqtt"
 	"smarthome-backend/internal/service"
 
-	mqtt "github.com/eclipse/paho.mqtt.golang"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type DeviceControlHandler struct {
-	mqttClient mqtt.Client
-	lampSvc    service.LampService
-	doorSvc    service.DoorService
-	curtainSvc service.CurtainService
+	publisher  *mqtt.Publisher
+	controller *mqtt.DeviceController
+	buzzer     service.Buzzer
 }
 
-// Constructor sesuai dengan main.go (4 Parameter)
-func NewDeviceControlHandler(
-	client mqtt.Client,
-	lampSvc service.LampService,
-	doorSvc service.DoorService,
-	curtainSvc service.CurtainService,
-) *DeviceControlHandler {
+// Door/lamp/curtain go through the controller (tracked until confirmed),
+// raw commands are published directly and buzzer commands are logged by the buzzer
+func NewDeviceControlHandler(publisher *mqtt.Publisher, controller *mqtt.DeviceController, buzzer service.Buzzer) *DeviceControlHandler {
 	return &DeviceControlHandler{
-		mqttClient: client,
-		lampSvc:    lampSvc,
-		doorSvc:    doorSvc,
-		curtainSvc: curtainSvc,
+		publisher:  publisher,
+		controller: controller,
+		buzzer:     buzzer,
 	}
 }
 
-// Helper untuk Publish ke MQTT
-func (h *DeviceControlHandler) publishToMQTT(topic string, payload interface{}) error {
-	jsonPayload, err := json.Marshal(payload)
-	if err != nil {
-		return err
-	}
-	token := h.mqttClient.Publish(topic, 1, false, jsonPayload)
-	token.Wait()
-	return token.Error()
+// Helper untuk Publish ke MQTT (queued=true kalau broker offline, dikirim saat reconnect)
+func (h *DeviceControlHandler) publishToMQTT(topic string, payload interface{}) (bool, error) {
+	return h.publisher.Publish(topic, 1, payload)
+}
+
+// respondQueued - Command accepted but waiting for the broker connection
+func respondQueued(c *gin.Context, device string) {
+	c.JSON(202, gin.H{
+		"success": true,
+		"queued":  true,
+		"message": "MQTT offline, " + device + " command queued and will be sent on reconnect",
+	})
 }
 
 // --- UNIVERSAL CONTROL (Opsional, jika ingin satu endpoint untuk semua) ---
@@ -57,10 +60,15 @@ func (h *DeviceControlHandler) Control(c *gin.Context) {
 
 	// Contoh implementasi sederhana publish raw
 	topic := "iotcihuy/home/" + req.Device + "/control"
-	if err := h.publishToMQTT(topic, req.Action); err != nil {
+	queued, err := h.publishToMQTT(topic, req.Action)
+	if err != nil {
 		c.JSON(500, gin.H{"error": "Failed to publish MQTT"})
 		return
 	}
+	if queued {
+		respondQueued(c, req.Device)
+		return
+	}
 
 	c.JSON(200, gin.H{"message": "Command sent", "device": req.Device})
 }
@@ -69,7 +77,8 @@ func (h *DeviceControlHandler) Control(c *gin.Context) {
 func (h *DeviceControlHandler) ControlDoor(c *gin.Context) {
 	var req struct {
 		Action string `json:"action" binding:"required,oneof=lock unlock"`
-		Method string `json:"method"`
+		// Only "remote": pin/face/... would hide an app unlock in access history
+		Method string `json:"method" binding:"omitempty,oneof=remote"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
@@ -79,23 +88,13 @@ func (h *DeviceControlHandler) ControlDoor(c *gin.Context) {
 		req.Method = "remote"
 	}
 
-	// A. Kirim ke MQTT (Agar Alat Bergerak)
-	payload := map[string]string{"action": req.Action, "method": req.Method}
-	if err := h.publishToMQTT("iotcihuy/home/door/control", payload); err != nil {
-		log.Printf("MQTT Error: %v", err)
-		c.JSON(500, gin.H{"error": "Failed MQTT"})
-		return
-	}
-
-	// B. SIMPAN KE DB LANGSUNG (Optimistic Update)
-	status := "locked"
-	if req.Action == "unlock" {
-		status = "unlocked"
-	}
-
-	h.doorSvc.ProcessDoor(status, req.Method, nil)
-
-	c.JSON(200, gin.H{"success": true, "message": "Door Command Sent & Saved"})
+	// State and auto-relock are handled by the door/status handler once the ESP32 confirms
+	h.sendCommand(c, models.DeviceCommandRequest{
+		Device: "door",
+		Action: req.Action,
+		Method: req.Method,
+		UserID: middleware.CurrentUserID(c),
+	})
 }
 
 // 2. CONTROL LAMP (On/Off + Auto/Manual)
@@ -112,18 +111,12 @@ func (h *DeviceControlHandler) ControlLamp(c *gin.Context) {
 		req.Mode = "manual"
 	}
 
-	// A. Kirim ke MQTT
-	payload := map[string]string{"action": req.Action, "mode": req.Mode}
-	if err := h.publishToMQTT("iotcihuy/home/lamp/control", payload); err != nil {
-		log.Printf("MQTT Error: %v", err)
-		c.JSON(500, gin.H{"error": "Failed MQTT"})
-		return
-	}
-
-	// B. SIMPAN KE DB LANGSUNG
-	h.lampSvc.ProcessLamp(req.Action, req.Mode)
-
-	c.JSON(200, gin.H{"success": true, "message": "Lamp Command Sent & Saved"})
+	h.sendCommand(c, models.DeviceCommandRequest{
+		Device: "lamp",
+		Action: req.Action,
+		Mode:   req.Mode,
+		UserID: middleware.CurrentUserID(c),
+	})
 }
 
 // 3. CONTROL CURTAIN (Open/Close + Auto/Manual)
@@ -140,39 +133,146 @@ func (h *DeviceControlHandler) ControlCurtain(c *gin.Context) {
 		req.Mode = "manual"
 	}
 
-	// A. Kirim ke MQTT
-	payload := map[string]string{"action": req.Action, "mode": req.Mode}
-	if err := h.publishToMQTT("iotcihuy/home/curtain/control", payload); err != nil {
+	h.sendCommand(c, models.DeviceCommandRequest{
+		Device: "curtain",
+		Action: req.Action,
+		Mode:   req.Mode,
+		UserID: middleware.CurrentUserID(c),
+	})
+}
+
+// sendCommand publishes a tracked command. With ?wait=true (or ?wait=<seconds>)
+// the request blocks until the device confirms, fails or times out.
+// Returns the command as responded, or nil when nothing was sent.
+func (h *DeviceControlHandler) sendCommand(c *gin.Context, req models.DeviceCommandRequest) *models.DeviceCommand {
+	wait, err := h.parseWait(c)
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "error": err.Error()})
+		return nil
+	}
+
+	cmd, err := h.controller.Send(req)
+	if err != nil && cmd == nil {
 		log.Printf("MQTT Error: %v", err)
-		c.JSON(500, gin.H{"error": "Failed MQTT"})
+		c.JSON(500, gin.H{"success": false, "error": "Failed to send command"})
+		return nil
+	}
+
+	if cmd.Status == "pending" && wait > 0 {
+		if waited, err := h.controller.Wait(cmd.CorrelationID, wait); err == nil {
+			waited.Queued = cmd.Queued
+			cmd = waited
+		}
+	}
+
+	respondCommand(c, cmd)
+	return cmd
+}
+
+func (h *DeviceControlHandler) parseWait(c *gin.Context) (time.Duration, error) {
+	return parseWaitQuery(c, h.controller.DefaultWait())
+}
+
+// parseWaitQuery reads ?wait=true (defaultWait) or ?wait=<seconds>
+func parseWaitQuery(c *gin.Context, defaultWait time.Duration) (time.Duration, error) {
+	raw := c.Query("wait")
+	switch raw {
+	case "", "false", "0":
+		return 0, nil
+	case "true":
+		return defaultWait, nil
+	}
+	seconds, err := strconv.Atoi(raw)
+	if err != nil || seconds < 0 {
+		return 0, fmt.Errorf("invalid wait parameter")
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+func respondCommand(c *gin.Context, cmd *models.DeviceCommand) {
+	switch cmd.Status {
+	case "confirmed":
+		c.JSON(200, gin.H{"success": true, "message": cmd.Device + " command confirmed", "data": cmd})
+	case "failed":
+		c.JSON(502, gin.H{"success": false, "error": cmd.Device + " command failed: " + cmd.Error, "data": cmd})
+	case "timed_out":
+		c.JSON(504, gin.H{"success": false, "error": cmd.Device + " did not confirm the command", "data": cmd})
+	default:
+		message := cmd.Device + " command sent, waiting for device confirmation"
+		if cmd.Queued {
+			message = "MQTT offline, " + cmd.Device + " command queued and will be sent on reconnect"
+		}
+		c.JSON(202, gin.H{"success": true, "message": message, "data": cmd})
+	}
+}
+
+// GetCommand - Poll a command by correlation ID
+// GET /api/control/commands/:id?wait=<seconds>
+func (h *DeviceControlHandler) GetCommand(c *gin.Context) {
+	wait, err := h.parseWait(c)
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "error": err.Error()})
 		return
 	}
 
-	// B. SIMPAN KE DB LANGSUNG
-	status := "closed"
-	if req.Action == "open" {
-		status = "open"
+	cmd, err := h.controller.Wait(c.Param("id"), wait)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"success": false, "error": "Command not found"})
+			return
+		}
+		c.JSON(500, gin.H{"success": false, "error": "Failed to retrieve command"})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "data": cmd})
+}
+
+// GetCommands - Recent commands, optional ?device=door&limit=50
+func (h *DeviceControlHandler) GetCommands(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	cmds, err := h.controller.Recent(c.Query("device"), limit)
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "error": "Failed to retrieve commands"})
+		return
 	}
-	h.curtainSvc.ProcessCurtain(status, req.Mode)
 
-	c.JSON(200, gin.H{"success": true, "message": "Curtain Command Sent & Saved"})
+	c.JSON(200, gin.H{"success": true, "data": cmds})
 }
 
 // 4. CONTROL BUZZER (Manual)
 func (h *DeviceControlHandler) ControlBuzzer(c *gin.Context) {
 	var req struct {
 		Action string `json:"action" binding:"required,oneof=on off"`
+		Reason string `json:"reason" binding:"max=200"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
 
-	payload := map[string]string{"action": req.Action}
-	if err := h.publishToMQTT("iotcihuy/home/buzzer/control", payload); err != nil {
+	reason := req.Reason
+	if reason == "" {
+		reason = "Manual control"
+	}
+	queued, err := h.buzzer.PublishBuzzerControl(models.BuzzerCommand{
+		Action: req.Action,
+		Source: models.BuzzerSourceManual,
+		Reason: reason,
+		UserID: middleware.CurrentUserID(c),
+	})
+	if err != nil {
 		c.JSON(500, gin.H{"error": "Failed MQTT"})
 		return
 	}
+	if queued {
+		respondQueued(c, "buzzer")
+		return
+	}
 
 	c.JSON(200, gin.H{"success": true, "message": "Buzzer Command Sent"})
 }
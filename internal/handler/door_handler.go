@@ -1,27 +1,30 @@
 package handler
 
 import (
-	"encoding/json"
+	"errors"
 	"log"
 	"smarthome-backend/database/models"
+	"smarthome-backend/internal/middleware"
+	"smarthome-backend/internal/mqtt"
 	"smarthome-backend/internal/service"
 	"strconv"
 
-	mqtt "github.com/eclipse/paho.mqtt.golang"
 	"github.com/gin-gonic/gin"
 )
 
 type DoorHandler struct {
 	svc        service.DoorService
 	pinSvc     service.PinService
-	mqttClient mqtt.Client
+	relockSvc  service.DoorRelockService
+	controller *mqtt.DeviceController
 }
 
-func NewDoorHandler(s service.DoorService, p service.PinService, mqttClient mqtt.Client) *DoorHandler {
+func NewDoorHandler(s service.DoorService, p service.PinService, relock service.DoorRelockService, controller *mqtt.DeviceController) *DoorHandler {
 	return &DoorHandler{
 		svc:        s,
 		pinSvc:     p,
-		mqttClient: mqttClient,
+		relockSvc:  relock,
+		controller: controller,
 	}
 }
 
@@ -38,6 +41,12 @@ func (h *DoorHandler) Create(c *gin.Context) {
 		return
 	}
 
+	if req.Status == "unlocked" {
+		h.relockSvc.OnUnlocked(req.Method)
+	} else {
+		h.relockSvc.OnLocked()
+	}
+
 	c.JSON(200, gin.H{"success": true, "message": "Door status updated"})
 }
 
@@ -73,47 +82,96 @@ func (h *DoorHandler) VerifyPin(c *gin.Context) {
 		return
 	}
 
-	// Get universal PIN from database
-	pinData, err := h.pinSvc.GetUniversalPin()
+	// Attempts are counted per client IP
+	result, err := h.pinSvc.VerifyPin("http:"+c.ClientIP(), req.Pin)
 	if err != nil {
-		c.JSON(500, gin.H{"success": false, "error": "Failed to retrieve PIN"})
+		log.Printf("[VERIFY] PIN verification error: %v", err)
+		c.JSON(500, gin.H{"success": false, "error": "Failed to verify PIN"})
 		return
 	}
 
-	// Verify PIN
-	if req.Pin != pinData.UniversalPin {
-		log.Printf("[VERIFY] Invalid PIN attempt: %s", req.Pin)
-		c.JSON(401, gin.H{"success": false, "error": "Invalid PIN", "valid": false})
+	if result.Locked {
+		log.Printf("[VERIFY] PIN locked out for %s (%ds)", c.ClientIP(), result.LockoutSeconds)
+		c.Header("Retry-After", strconv.Itoa(result.LockoutSeconds))
+		c.JSON(429, gin.H{
+			"success":            false,
+			"error":              result.Message,
+			"valid":              false,
+			"locked":             true,
+			"lockout_seconds":    result.LockoutSeconds,
+			"remaining_attempts": 0,
+		})
 		return
 	}
 
-	log.Printf("[VERIFY] Valid PIN: Sending unlock command")
-
-	// Send unlock command via MQTT
-	topic := "iotcihuy/home/door/control"
-	payload := map[string]string{
-		"action": "unlock",
-		"method": "pin",
+	if !result.Valid {
+		log.Printf("[VERIFY] Invalid PIN attempt from %s (%d left)", c.ClientIP(), result.RemainingAttempts)
+		c.JSON(401, gin.H{
+			"success":            false,
+			"error":              result.Message,
+			"valid":              false,
+			"remaining_attempts": result.RemainingAttempts,
+		})
+		return
 	}
-	jsonPayload, _ := json.Marshal(payload)
 
-	token := h.mqttClient.Publish(topic, 1, false, jsonPayload)
-	token.Wait()
+	log.Printf("[VERIFY] Valid PIN: Sending unlock command")
 
-	if token.Error() != nil {
-		log.Printf("[ERROR] Failed to publish door unlock: %v", token.Error())
-		c.JSON(500, gin.H{"success": false, "error": "Failed to send unlock command"})
+	// Send unlock command via MQTT (tracked, so the status echo counts as authorized)
+	if _, err := h.controller.Send(models.DeviceCommandRequest{
+		Device: "door",
+		Action: "unlock",
+		Method: "pin",
+		Source: "pin",
+		UserID: result.UserID,
+	}); err != nil {
+		// Unlocks are never queued, so offline means the door stayed locked
+		log.Printf("[ERROR] Failed to publish door unlock: %v", err)
+		status := 500
+		if errors.Is(err, mqtt.ErrNotConnected) {
+			status = 503
+		}
+		c.JSON(status, gin.H{"success": false, "valid": true, "error": "PIN verified, but the door could not be unlocked"})
 		return
 	}
 
 	log.Printf("[CONTROL] 🔓 Door → unlock (via PIN)")
 
-	// Save access log to database (async)
-	go h.svc.ProcessDoor("unlocked", "pin", nil)
+	// Door status and auto-relock are recorded by the door/status handler once the
+	// ESP32 confirms (access log already written by PinService)
+	c.JSON(200, gin.H{
+		"success":     true,
+		"message":     "PIN verified successfully, unlock command sent",
+		"valid":       true,
+		"user_id":     result.UserID,
+		"guest_label": result.GuestLabel,
+	})
+}
 
+// GetAutoRelock - Auto-relock settings and the pending relock time
+// GET /api/admin/door/auto-relock
+func (h *DoorHandler) GetAutoRelock(c *gin.Context) {
 	c.JSON(200, gin.H{
-		"success": true,
-		"message": "PIN verified successfully, door unlocked",
-		"valid":   true,
+		"success":   true,
+		"data":      h.relockSvc.GetSettings(),
+		"relock_at": h.relockSvc.RelockAt(),
 	})
 }
+
+// UpdateAutoRelock - Enable/disable auto-relock and set its delay
+// PUT /api/admin/door/auto-relock {"enabled": true, "delay_seconds": 30}
+func (h *DoorHandler) UpdateAutoRelock(c *gin.Context) {
+	var req models.AutoRelockSettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	settings, err := h.relockSvc.UpdateSettings(req, middleware.CurrentUserID(c))
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "error": "Failed to update auto-relock settings"})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "message": "Auto-relock settings updated", "data": settings})
+}
@@ -1,39 +1,21 @@
 package handler
 
 import (
-	"bytes"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
-	"os"
-	"path/filepath"
 	"smarthome-backend/database/models"
+	"smarthome-backend/internal/middleware"
+	"smarthome-backend/internal/mqtt"
 	"smarthome-backend/internal/service"
+	"strconv"
 	"time"
 
-	mqtt "github.com/eclipse/paho.mqtt.golang"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-// Python service configuration
-const (
-	PYTHON_SERVICE_URL = "http://localhost:5000"
-	UPLOAD_DIR         = "./uploads/camera_captures"
-)
-
-// FaceRecognitionResponse from Python service
-type FaceRecognitionResponse struct {
-	Success    bool    `json:"success"`
-	Recognized bool    `json:"recognized"`
-	UserID     uint    `json:"user_id"`
-	Name       string  `json:"name"`
-	Confidence float64 `json:"confidence"`
-	Message    string  `json:"message"`
-	Error      string  `json:"error,omitempty"`
-}
-
 // FaceRecognizeRequest from ESP32-CAM
 type FaceRecognizeRequest struct {
 	Image string `json:"image" binding:"required"` // base64 encoded image
@@ -48,16 +30,31 @@ type FaceEnrollRequest struct {
 
 type FaceHandler struct {
 	accessLogService service.AccessLogService
-	mqttClient       mqtt.Client
+	faceService      service.FaceService
+	faceClient       service.FaceRecognizer
+	captureService   service.CameraCaptureService
+	controller       *mqtt.DeviceController
+	alarmService     service.AlarmService
+	notifService     service.NotificationService
 }
 
-func NewFaceHandler(accessLogSvc service.AccessLogService, mqttClient mqtt.Client) *FaceHandler {
-	// Create upload directory if not exists
-	os.MkdirAll(UPLOAD_DIR, os.ModePerm)
-
+func NewFaceHandler(
+	accessLogSvc service.AccessLogService,
+	faceSvc service.FaceService,
+	faceClient service.FaceRecognizer,
+	captureSvc service.CameraCaptureService,
+	controller *mqtt.DeviceController,
+	alarmSvc service.AlarmService,
+	notifSvc service.NotificationService,
+) *FaceHandler {
 	return &FaceHandler{
 		accessLogService: accessLogSvc,
-		mqttClient:       mqttClient,
+		faceService:      faceSvc,
+		faceClient:       faceClient,
+		captureService:   captureSvc,
+		controller:       controller,
+		alarmService:     alarmSvc,
+		notifService:     notifSvc,
 	}
 }
 
@@ -77,73 +74,95 @@ func (h *FaceHandler) RecognizeFace(c *gin.Context) {
 	log.Println("📸 Face recognition request received")
 
 	// 1. Forward to Python service for recognition
-	pythonResp, err := h.sendToPythonService("/recognize", map[string]interface{}{
-		"image": req.Image,
-	})
+	pythonResp, err := h.faceClient.RecognizeFace(c.Request.Context(), req.Image)
 
 	if err != nil {
 		log.Printf("Error calling Python service: %v", err)
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		h.saveCapture(req.Image, models.DetectedFaceUnavailable, nil)
+		c.JSON(faceServiceStatus(err), models.ErrorResponse{
 			Success: false,
 			Error:   "Face recognition service unavailable: " + err.Error(),
 		})
 		return
 	}
 
-	// 2. Save to access log
+	// 2. Save the frame and the access log pointing at it
 	var userID *uint
 	var accessStatus string
 	var accessMethod string = "face" // Face recognition dianggap remote access
 
 	if pythonResp.Recognized {
-		userID = &pythonResp.UserID
+		recognizedID := uint(pythonResp.UserID)
+		userID = &recognizedID
 		accessStatus = "success"
 		log.Printf("Face recognized: %s (user_id: %d, confidence: %.2f)",
 			pythonResp.Name, pythonResp.UserID, pythonResp.Confidence)
 	} else {
 		accessStatus = "failed"
 		log.Printf("Face not recognized")
+
+		// Trips the alarm when armed away
+		h.alarmService.OnUnknownFace(req.Image)
+		go h.notifService.NotifyThrottled("unknown_face", time.Minute, models.NotifIntruder,
+			"Unknown Face", "An unrecognized person was detected at the door")
 	}
 
+	detectedFace := models.DetectedFaceUnknown
+	if pythonResp.Recognized {
+		detectedFace = pythonResp.Name
+	}
+	capture := h.saveCapture(req.Image, detectedFace, userID)
+
 	// Save access log
 	accessLogReq := models.AccessLogRequest{
 		UserID: userID,
 		Method: accessMethod,
 		Status: accessStatus,
 	}
+	if capture != nil {
+		accessLogReq.ImagePath = capture.ImagePath
+		accessLogReq.CaptureID = &capture.CaptureID
+	}
 
 	if err := h.accessLogService.LogAccess(accessLogReq); err != nil {
 		log.Printf("⚠️  Failed to save access log: %v", err)
 	}
 
 	// 3. If recognized, unlock door via MQTT
+	doorUnlocked := false
 	if pythonResp.Recognized {
-		unlockPayload := map[string]string{
-			"command": "unlock",
-			"user_id": fmt.Sprintf("%d", pythonResp.UserID),
-			"method":  "face_recognition",
-		}
-
-		payload, _ := json.Marshal(unlockPayload)
-
-		token := h.mqttClient.Publish("iotcihuy/home/door/control", 0, false, payload)
-		token.Wait()
-
-		if token.Error() != nil {
-			log.Printf("⚠️  Failed to publish MQTT unlock command: %v", token.Error())
+		// Tracked, so the door/status echo counts as an authorized unlock
+		_, err := h.controller.Send(models.DeviceCommandRequest{
+			Device: "door",
+			Action: "unlock",
+			Method: "face",
+			Source: "face",
+			UserID: userID,
+		})
+		if err != nil {
+			// Unlocks are never queued: when MQTT is offline the door stays locked
+			log.Printf("⚠️  Failed to publish MQTT unlock command: %v", err)
 		} else {
+			// Door status and auto-relock follow from the door/status confirmation
 			log.Printf("🔓 Door unlock command published via MQTT")
+			doorUnlocked = true
 		}
 	}
 
 	// 4. Return response
+	var captureID *uint
+	if capture != nil {
+		captureID = &capture.CaptureID
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"success":    true,
 		"recognized": pythonResp.Recognized,
+		"unlocked":   doorUnlocked,
 		"user_id":    pythonResp.UserID,
 		"name":       pythonResp.Name,
 		"confidence": pythonResp.Confidence,
 		"message":    pythonResp.Message,
+		"capture_id": captureID,
 	})
 }
 
@@ -163,15 +182,20 @@ func (h *FaceHandler) EnrollFace(c *gin.Context) {
 	log.Printf("📝 Face enrollment request for user_id: %d, name: %s", req.UserID, req.Name)
 
 	// Forward to Python service for enrollment
-	pythonResp, err := h.sendToPythonService("/enroll", map[string]interface{}{
-		"user_id": req.UserID,
-		"name":    req.Name,
-		"image":   req.Image,
-	})
+	pythonResp, err := h.faceClient.EnrollFace(c.Request.Context(), int(req.UserID), req.Name, req.Image)
 
+	var rejected *service.FaceServiceError
+	if errors.As(err, &rejected) {
+		log.Printf("Face enrollment failed: %s", rejected.Message)
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   rejected.Message,
+		})
+		return
+	}
 	if err != nil {
 		log.Printf("Error calling Python service: %v", err)
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		c.JSON(faceServiceStatus(err), models.ErrorResponse{
 			Success: false,
 			Error:   "Face enrollment service unavailable: " + err.Error(),
 		})
@@ -204,11 +228,11 @@ func (h *FaceHandler) EnrollFace(c *gin.Context) {
 func (h *FaceHandler) ReloadFaces(c *gin.Context) {
 	log.Println("🔄 Reloading faces in Python service...")
 
-	pythonResp, err := h.sendToPythonService("/reload", nil)
+	err := h.faceClient.ReloadFaces(c.Request.Context())
 
 	if err != nil {
 		log.Printf("Error calling Python service: %v", err)
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		c.JSON(faceServiceStatus(err), models.ErrorResponse{
 			Success: false,
 			Error:   "Face service unavailable: " + err.Error(),
 		})
@@ -219,86 +243,273 @@ func (h *FaceHandler) ReloadFaces(c *gin.Context) {
 
 	c.JSON(http.StatusOK, models.Response{
 		Success: true,
-		Message: pythonResp.Message,
+		Message: "Faces reloaded",
 	})
 }
 
-// GetAccessLogs returns recent access logs
-// GET /api/face/logs
-func (h *FaceHandler) GetAccessLogs(c *gin.Context) {
-	// This will be handled by access_log_handler
-	// Redirect or call the service directly
-	logs, err := h.accessLogService.GetAll(100) // Get last 100 logs
+// ==================== PYTHON SERVICE CALLBACKS ====================
+
+// IngestRecognition stores a recognition result pushed by the Python service
+// POST /api/face/recognition-logs
+func (h *FaceHandler) IngestRecognition(c *gin.Context) {
+	var req models.FaceRecognitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	if err := h.faceService.ProcessRecognition(&req); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to save recognition log",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.Response{Success: true, Message: "Recognition log saved"})
+}
+
+// IngestAlert stores an unknown-face alert pushed by the Python service
+// POST /api/face/alerts
+func (h *FaceHandler) IngestAlert(c *gin.Context) {
+	var req models.FaceAlertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	if err := h.faceService.ProcessAlert(&req); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to save face alert",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.Response{Success: true, Message: "Face alert saved"})
+}
+
+// ==================== ADMIN REVIEW ====================
+
+// GetLogs returns face recognition logs (not door access logs)
+// GET /api/face/logs?recognized=false&user_id=3&source=esp32cam&from=2024-01-01&to=2024-01-31&limit=100
+func (h *FaceHandler) GetLogs(c *gin.Context) {
+	filter := models.FaceLogFilter{Source: c.Query("source")}
+
+	if raw := c.Query("user_id"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Success: false, Error: "Invalid user_id"})
+			return
+		}
+		filter.UserID = &id
+	}
+	recognized, ok := parseBoolQuery(c, "recognized")
+	if !ok {
+		return
+	}
+	filter.Recognized = recognized
+
+	from, to, ok := parseTimeRange(c)
+	if !ok {
+		return
+	}
+	filter.From, filter.To = from, to
+	filter.Limit = parseLimitQuery(c, 100, 1000)
+
+	logs, err := h.faceService.GetLogs(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to retrieve recognition logs",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": logs, "count": len(logs)})
+}
+
+// GetAlerts lists alerts, unresolved by default; images only with include_images=true
+// GET /api/face/alerts?resolved=false&alert_type=unknown_face&include_images=true&limit=50
+func (h *FaceHandler) GetAlerts(c *gin.Context) {
+	filter := models.FaceAlertFilter{
+		AlertType:     c.Query("alert_type"),
+		IncludeImages: c.Query("include_images") == "true",
+	}
 
+	unresolved := false
+	filter.Resolved = &unresolved
+	if c.Query("resolved") == "all" {
+		filter.Resolved = nil
+	} else if resolved, ok := parseBoolQuery(c, "resolved"); !ok {
+		return
+	} else if resolved != nil {
+		filter.Resolved = resolved
+	}
+
+	from, to, ok := parseTimeRange(c)
+	if !ok {
+		return
+	}
+	filter.From, filter.To = from, to
+	filter.Limit = parseLimitQuery(c, 50, 500)
+
+	alerts, err := h.faceService.GetAlerts(filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Success: false,
-			Error:   err.Error(),
+			Error:   "Failed to retrieve face alerts",
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, models.Response{
-		Success: true,
-		Message: "Access logs retrieved",
-		Data:    logs,
-	})
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": alerts, "count": len(alerts)})
 }
 
-// sendToPythonService sends HTTP request to Python service
-func (h *FaceHandler) sendToPythonService(endpoint string, data map[string]interface{}) (*FaceRecognitionResponse, error) {
-	url := PYTHON_SERVICE_URL + endpoint
+// GetAlert returns one alert including its image
+// GET /api/face/alerts/:id
+func (h *FaceHandler) GetAlert(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Success: false, Error: "Invalid alert ID"})
+		return
+	}
 
-	var req *http.Request
-	var err error
+	alert, err := h.faceService.GetAlert(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Success: false, Error: "Face alert not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to retrieve face alert",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": alert})
+}
 
-	if data != nil {
-		jsonData, _ := json.Marshal(data)
-		req, err = http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+// ResolveAlerts resolves one alert (/:id/resolve) or many ({"ids": [...]})
+// POST /api/face/alerts/:id/resolve
+// POST /api/face/alerts/resolve
+func (h *FaceHandler) ResolveAlerts(c *gin.Context) {
+	var ids []int
+	if c.Param("id") != "" {
+		id, err := strconv.Atoi(c.Param("id"))
 		if err != nil {
-			return nil, err
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Success: false, Error: "Invalid alert ID"})
+			return
 		}
-		req.Header.Set("Content-Type", "application/json")
+		ids = []int{id}
 	} else {
-		req, err = http.NewRequest("POST", url, nil)
-		if err != nil {
-			return nil, err
+		var req models.FaceAlertResolveRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Success: false, Error: err.Error()})
+			return
 		}
+		ids = req.IDs
 	}
 
-	// Set timeout 30 seconds (face recognition bisa lama)
-	client := &http.Client{
-		Timeout: 30 * time.Second,
+	resolved, err := h.faceService.ResolveAlerts(ids, middleware.CurrentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to resolve face alerts",
+		})
+		return
 	}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to Python service: %v", err)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("%d alert(s) resolved", resolved),
+		"data":    gin.H{"resolved": resolved},
+	})
+}
+
+// faceServiceStatus - 503 while the circuit breaker is open, 500 otherwise
+func faceServiceStatus(err error) int {
+	if errors.Is(err, service.ErrFaceServiceUnavailable) {
+		return http.StatusServiceUnavailable
 	}
-	defer resp.Body.Close()
+	return http.StatusInternalServerError
+}
 
-	body, err := io.ReadAll(resp.Body)
+// saveCapture stores the frame; a failed save never blocks door access
+func (h *FaceHandler) saveCapture(image, detectedFace string, userID *uint) *models.CameraCapture {
+	capture, err := h.captureService.Save(image, detectedFace, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %v", err)
+		log.Printf("⚠️  Failed to save camera capture: %v", err)
+		return nil
 	}
+	return capture
+}
 
-	var result FaceRecognitionResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %v", err)
+// parseBoolQuery returns nil when the parameter is absent
+func parseBoolQuery(c *gin.Context, key string) (*bool, bool) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, true
 	}
-
-	return &result, nil
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Success: false, Error: "Invalid " + key})
+		return nil, false
+	}
+	return &value, true
 }
 
-// SaveBase64Image saves base64 image to file (helper function)
-func (h *FaceHandler) SaveBase64Image(base64Image string) (string, error) {
-	// This is optional - for debugging/logging purposes
-	timestamp := time.Now().Format("20060102_150405")
-	filename := fmt.Sprintf("capture_%s.jpg", timestamp)
-	filepath := filepath.Join(UPLOAD_DIR, filename)
+func parseLimitQuery(c *gin.Context, defaultLimit, maxLimit int) int {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultLimit)))
+	if err != nil || limit <= 0 || limit > maxLimit {
+		return defaultLimit
+	}
+	return limit
+}
 
-	// Decode base64
-	// ... implementation if needed
+// parseTimeRange reads ?from=&to= as RFC3339 or YYYY-MM-DD (a date-only "to"
+// covers the whole day)
+func parseTimeRange(c *gin.Context) (*time.Time, *time.Time, bool) {
+	parse := func(key string, endOfDay bool) (*time.Time, bool) {
+		raw := c.Query(key)
+		if raw == "" {
+			return nil, true
+		}
+		if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			return &t, true
+		}
+		t, err := time.ParseInLocation("2006-01-02", raw, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Success: false,
+				Error:   "Invalid " + key + " (use RFC3339 or YYYY-MM-DD)",
+			})
+			return nil, false
+		}
+		if endOfDay {
+			t = t.Add(24*time.Hour - time.Nanosecond)
+		}
+		return &t, true
+	}
 
-	return filepath, nil
+	from, ok := parse("from", false)
+	if !ok {
+		return nil, nil, false
+	}
+	to, ok := parse("to", true)
+	if !ok {
+		return nil, nil, false
+	}
+	if from != nil && to != nil && to.Before(*from) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Success: false, Error: "to must not be before from"})
+		return nil, nil, false
+	}
+	return from, to, true
 }
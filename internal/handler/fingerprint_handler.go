@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"smarthome-backend/database/models"
+	"smarthome-backend/internal/middleware"
+	"smarthome-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type FingerprintHandler struct {
+	svc service.FingerprintService
+}
+
+func NewFingerprintHandler(s service.FingerprintService) *FingerprintHandler {
+	return &FingerprintHandler{svc: s}
+}
+
+// GetAll - Every template slot and its owner
+// GET /api/fingerprints
+func (h *FingerprintHandler) GetAll(c *gin.Context) {
+	fps, err := h.svc.GetAll()
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "error": "Failed to retrieve fingerprints"})
+		return
+	}
+	c.JSON(200, gin.H{"success": true, "data": fps})
+}
+
+// GetByUserID - Fingerprints of one user
+// GET /api/fingerprints/user/:user_id
+func (h *FingerprintHandler) GetByUserID(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "error": "Invalid user ID"})
+		return
+	}
+
+	fps, err := h.svc.GetByUserID(uint(userID))
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "error": "Failed to retrieve fingerprints"})
+		return
+	}
+	c.JSON(200, gin.H{"success": true, "data": fps})
+}
+
+// StartEnrollment - Put the door sensor in enrollment mode for a user.
+// The result arrives over MQTT; poll the enrollment or listen for "fingerprint_enroll" on /ws.
+// POST /api/fingerprints/enroll {"user_id": 3}
+func (h *FingerprintHandler) StartEnrollment(c *gin.Context) {
+	var req models.FingerprintRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	enrollment, err := h.svc.StartEnrollment(req.UserID, middleware.CurrentUserID(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(404, gin.H{"success": false, "error": "User not found"})
+		case errors.Is(err, service.ErrFingerprintUserInactive):
+			c.JSON(400, gin.H{"success": false, "error": err.Error()})
+		case errors.Is(err, service.ErrFingerprintEnrollBusy), errors.Is(err, service.ErrFingerprintStorageFull):
+			c.JSON(409, gin.H{"success": false, "error": err.Error()})
+		case errors.Is(err, service.ErrFingerprintNoSensor):
+			c.JSON(503, gin.H{"success": false, "error": "Fingerprint sensor is not connected"})
+		default:
+			c.JSON(500, gin.H{"success": false, "error": "Failed to start enrollment"})
+		}
+		return
+	}
+
+	c.JSON(202, gin.H{"success": true, "message": enrollment.Message, "data": enrollment})
+}
+
+// GetEnrollment - Progress of an enrollment session
+// GET /api/fingerprints/enrollments/:id
+func (h *FingerprintHandler) GetEnrollment(c *gin.Context) {
+	enrollment, err := h.svc.GetEnrollment(c.Param("id"))
+	if err != nil {
+		c.JSON(404, gin.H{"success": false, "error": "Enrollment not found"})
+		return
+	}
+	c.JSON(200, gin.H{"success": true, "data": enrollment})
+}
+
+// Delete - Remove a fingerprint and wipe its slot on the sensor
+// DELETE /api/fingerprints/:id
+func (h *FingerprintHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "error": "Invalid fingerprint ID"})
+		return
+	}
+
+	if err := h.svc.Delete(uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"success": false, "error": "Fingerprint not found"})
+			return
+		}
+		c.JSON(500, gin.H{"success": false, "error": "Failed to delete fingerprint"})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "message": "Fingerprint deleted"})
+}
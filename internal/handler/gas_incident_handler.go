@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"smarthome-backend/database/models"
+	"smarthome-backend/internal/middleware"
+	"smarthome-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type GasIncidentHandler struct {
+	svc service.GasPlaybookService
+}
+
+func NewGasIncidentHandler(s service.GasPlaybookService) *GasIncidentHandler {
+	return &GasIncidentHandler{svc: s}
+}
+
+// GetIncidents - Gas emergencies, newest first
+// GET /api/sensor/gas/incidents?limit=50
+func (h *GasIncidentHandler) GetIncidents(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	incidents, err := h.svc.GetIncidents(limit)
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "error": "Failed to retrieve gas incidents"})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "data": incidents, "active": h.svc.GetActiveIncident()})
+}
+
+// GET /api/sensor/gas/incidents/:id
+func (h *GasIncidentHandler) GetIncident(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "error": "Invalid incident ID"})
+		return
+	}
+
+	incident, err := h.svc.GetIncident(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"success": false, "error": "Gas incident not found"})
+			return
+		}
+		c.JSON(500, gin.H{"success": false, "error": "Failed to retrieve gas incident"})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "data": incident})
+}
+
+// GetPlaybook - Current emergency playbook
+// GET /api/admin/gas-playbook
+func (h *GasIncidentHandler) GetPlaybook(c *gin.Context) {
+	c.JSON(200, gin.H{"success": true, "data": h.svc.GetPlaybook()})
+}
+
+// UpdatePlaybook - Replace the emergency playbook
+// PUT /api/admin/gas-playbook
+func (h *GasIncidentHandler) UpdatePlaybook(c *gin.Context) {
+	var req models.GasPlaybook
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	playbook, err := h.svc.UpdatePlaybook(req, middleware.CurrentUserID(c))
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "message": "Gas playbook updated", "data": playbook})
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"smarthome-backend/database/models"
+	"smarthome-backend/internal/middleware"
+	"smarthome-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type GuestCodeHandler struct {
+	svc service.GuestCodeService
+}
+
+func NewGuestCodeHandler(s service.GuestCodeService) *GuestCodeHandler {
+	return &GuestCodeHandler{svc: s}
+}
+
+// Create - The plaintext code is only returned here, share it with the guest now
+func (h *GuestCodeHandler) Create(c *gin.Context) {
+	var req models.GuestCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	user := middleware.CurrentUser(c)
+	created, err := h.svc.Create(req, user.UserID)
+	if err != nil {
+		if errors.Is(err, service.ErrPinInUse) {
+			c.JSON(409, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+		c.JSON(400, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(201, gin.H{"success": true, "message": "Guest code created", "data": created})
+}
+
+func (h *GuestCodeHandler) GetAll(c *gin.Context) {
+	codes, err := h.svc.GetAll()
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "error": "Failed to retrieve guest codes"})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "data": codes})
+}
+
+func (h *GuestCodeHandler) GetByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "error": "Invalid guest code ID"})
+		return
+	}
+
+	code, err := h.svc.GetByID(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"success": false, "error": "Guest code not found"})
+			return
+		}
+		c.JSON(500, gin.H{"success": false, "error": "Failed to retrieve guest code"})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "data": code})
+}
+
+func (h *GuestCodeHandler) Revoke(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "error": "Invalid guest code ID"})
+		return
+	}
+
+	if err := h.svc.Revoke(uint(id)); err != nil {
+		c.JSON(500, gin.H{"success": false, "error": "Failed to revoke guest code"})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "message": "Guest code revoked"})
+}
+
+func (h *GuestCodeHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "error": "Invalid guest code ID"})
+		return
+	}
+
+	if err := h.svc.Delete(uint(id)); err != nil {
+		c.JSON(500, gin.H{"success": false, "error": "Failed to delete guest code"})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "message": "Guest code deleted"})
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"smarthome-backend/internal/mqtt"
+	"smarthome-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandler struct {
+	publisher  *mqtt.Publisher
+	faceClient service.FaceRecognizer
+}
+
+func NewHealthHandler(publisher *mqtt.Publisher, faceClient service.FaceRecognizer) *HealthHandler {
+	return &HealthHandler{publisher: publisher, faceClient: faceClient}
+}
+
+// Check reports service health; MQTT or the face service being down is "degraded" since the REST API still works
+func (h *HealthHandler) Check(c *gin.Context) {
+	status := "healthy"
+	mqttStatus := h.publisher.Status()
+	if !mqttStatus.Connected {
+		status = "degraded"
+	}
+	faceStatus := h.faceClient.Health()
+	if faceStatus.Status != "healthy" {
+		status = "degraded"
+	}
+
+	c.JSON(200, gin.H{
+		"status":       status,
+		"service":      "smart-home-backend",
+		"version":      "1.0.0",
+		"mqtt":         mqttStatus,
+		"face_service": faceStatus,
+	})
+}
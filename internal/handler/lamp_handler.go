@@ -23,7 +23,11 @@ func (h *LampHandler) Create(c *gin.Context) {
 		return
 	}
 
-	err := h.svc.ProcessLamp(req.Status, req.Mode)
+	_, err := h.svc.ProcessLamp(models.DeviceStateChange{
+		Status: req.Status,
+		Mode:   req.Mode,
+		Source: models.DeviceStateSourceDevice,
+	})
 	if err != nil {
 		c.JSON(500, gin.H{"success": false, "error": "Failed to save lamp status"})
 		return
@@ -42,9 +46,14 @@ func (h *LampHandler) GetLatest(c *gin.Context) {
 	c.JSON(200, gin.H{"success": true, "data": data})
 }
 
-func (h *LampHandler) GetAll(c *gin.Context) {
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
-	data, err := h.svc.GetHistory(limit)
+// GetTimeline - Lamp state transitions, newest first
+// GET /api/device/lamp/timeline?from=2024-01-01&to=2024-01-31&source=schedule&user_id=3&limit=50
+func (h *LampHandler) GetTimeline(c *gin.Context) {
+	filter, ok := parseDeviceStateFilter(c)
+	if !ok {
+		return
+	}
+	data, err := h.svc.GetTimeline(filter)
 	if err != nil {
 		c.JSON(500, gin.H{"success": false, "error": "Failed to retrieve data"})
 		return
@@ -52,3 +61,27 @@ func (h *LampHandler) GetAll(c *gin.Context) {
 
 	c.JSON(200, gin.H{"success": true, "data": data})
 }
+
+// parseDeviceStateFilter reads the timeline query shared by lamp and curtain
+func parseDeviceStateFilter(c *gin.Context) (models.DeviceStateFilter, bool) {
+	filter := models.DeviceStateFilter{
+		Source: c.Query("source"),
+		Limit:  parseLimitQuery(c, 50, 1000),
+	}
+	if raw := c.Query("user_id"); raw != "" {
+		userID, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			c.JSON(400, gin.H{"success": false, "error": "Invalid user_id"})
+			return filter, false
+		}
+		id := uint(userID)
+		filter.UserID = &id
+	}
+
+	from, to, ok := parseTimeRange(c)
+	if !ok {
+		return filter, false
+	}
+	filter.From, filter.To = from, to
+	return filter, true
+}
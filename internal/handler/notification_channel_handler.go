@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"smarthome-backend/database/models"
+	"smarthome-backend/internal/middleware"
+	"smarthome-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationChannelHandler - each user manages their own outbound channels
+type NotificationChannelHandler struct {
+	svc service.NotificationChannelService
+}
+
+func NewNotificationChannelHandler(s service.NotificationChannelService) *NotificationChannelHandler {
+	return &NotificationChannelHandler{svc: s}
+}
+
+// GetAll - The caller's channels plus the kinds enabled on this server
+// GET /api/notification-channels
+func (h *NotificationChannelHandler) GetAll(c *gin.Context) {
+	channels, err := h.svc.GetByUser(middleware.CurrentUser(c).UserID)
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "error": "Failed to retrieve channels"})
+		return
+	}
+	if channels == nil {
+		channels = make([]models.NotificationChannel, 0)
+	}
+
+	c.JSON(200, gin.H{
+		"success":         true,
+		"data":            channels,
+		"available_kinds": h.svc.AvailableKinds(),
+	})
+}
+
+// POST /api/notification-channels
+func (h *NotificationChannelHandler) Create(c *gin.Context) {
+	var req models.NotificationChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	channel, err := h.svc.Create(middleware.CurrentUser(c).UserID, req)
+	if err != nil {
+		respondChannelError(c, err, "Failed to create channel")
+		return
+	}
+
+	c.JSON(201, gin.H{"success": true, "message": "Channel created", "data": channel})
+}
+
+// PUT /api/notification-channels/:id
+func (h *NotificationChannelHandler) Update(c *gin.Context) {
+	id, ok := parseChannelID(c)
+	if !ok {
+		return
+	}
+
+	var req models.NotificationChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	channel, err := h.svc.Update(middleware.CurrentUser(c).UserID, id, req)
+	if err != nil {
+		respondChannelError(c, err, "Failed to update channel")
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "message": "Channel updated", "data": channel})
+}
+
+// DELETE /api/notification-channels/:id
+func (h *NotificationChannelHandler) Delete(c *gin.Context) {
+	id, ok := parseChannelID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Delete(middleware.CurrentUser(c).UserID, id); err != nil {
+		respondChannelError(c, err, "Failed to delete channel")
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "message": "Channel deleted"})
+}
+
+// Test - Send a test message now (uses the same retries & rate limit)
+// POST /api/notification-channels/:id/test
+func (h *NotificationChannelHandler) Test(c *gin.Context) {
+	id, ok := parseChannelID(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.svc.SendTest(middleware.CurrentUser(c).UserID, id)
+	if err != nil {
+		respondChannelError(c, err, "Failed to send test message")
+		return
+	}
+	if delivery.Status != models.DeliverySent {
+		c.JSON(502, gin.H{"success": false, "error": "Test message was not delivered", "data": delivery})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "message": "Test message sent", "data": delivery})
+}
+
+// GetDeliveries - Delivery log of the caller's channels
+// GET /api/notification-channels/deliveries?channel_id=1&status=failed&limit=50
+func (h *NotificationChannelHandler) GetDeliveries(c *gin.Context) {
+	var channelID *uint
+	if raw := c.Query("channel_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			c.JSON(400, gin.H{"success": false, "error": "Invalid channel_id"})
+			return
+		}
+		v := uint(id)
+		channelID = &v
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", models.DeliverySent, models.DeliveryFailed, models.DeliveryRateLimited:
+	default:
+		c.JSON(400, gin.H{"success": false, "error": "Invalid status"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	deliveries, err := h.svc.GetDeliveries(middleware.CurrentUser(c).UserID, channelID, status, limit)
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "error": "Failed to retrieve deliveries"})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "data": deliveries, "count": len(deliveries)})
+}
+
+func parseChannelID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "error": "Invalid channel ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondChannelError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrChannelNotFound):
+		c.JSON(404, gin.H{"success": false, "error": err.Error()})
+	case errors.Is(err, service.ErrChannelUnavailable), errors.Is(err, service.ErrInvalidChannelTarget):
+		c.JSON(400, gin.H{"success": false, "error": err.Error()})
+	default:
+		c.JSON(500, gin.H{"success": false, "error": message})
+	}
+}
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+
+	"smarthome-backend/database/models"
+	"smarthome-backend/internal/middleware"
+	"smarthome-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+var notificationTypes = map[string]bool{
+	models.NotifGas:      true,
+	models.NotifDoor:     true,
+	models.NotifSystem:   true,
+	models.NotifIntruder: true,
+	models.NotifAccess:   true,
+	models.NotifDevice:   true,
+	models.NotifApproval: true,
+}
+
+type NotificationHandler struct {
+	svc service.NotificationService
+}
+
+func NewNotificationHandler(s service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{svc: s}
+}
+
+// GetAll - Notifications with the caller's read state
+// GET /api/notifications?type=gas,intruder&unread=true&page=1&page_size=20
+func (h *NotificationHandler) GetAll(c *gin.Context) {
+	types, ok := parseNotificationTypes(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	user := middleware.CurrentUser(c)
+	result, err := h.svc.List(user.UserID, models.NotificationFilter{
+		Types:      types,
+		UnreadOnly: c.Query("unread") == "true",
+		Page:       page,
+		PageSize:   pageSize,
+	})
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "error": "Failed to retrieve notifications"})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "data": result})
+}
+
+// GetUnreadCount - Badge counter
+// GET /api/notifications/unread-count?type=gas
+func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
+	types, ok := parseNotificationTypes(c)
+	if !ok {
+		return
+	}
+
+	count, err := h.svc.CountUnread(middleware.CurrentUser(c).UserID, types)
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "error": "Failed to count notifications"})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "data": gin.H{"unread_count": count}})
+}
+
+// MarkRead - Mark one notification (/:id/read) or a list ({"ids": [...]}) as read
+// POST /api/notifications/:id/read
+// POST /api/notifications/read
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	var ids []uint
+	if c.Param("id") != "" {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.JSON(400, gin.H{"success": false, "error": "Invalid notification ID"})
+			return
+		}
+		ids = []uint{uint(id)}
+	} else {
+		var req models.NotificationReadRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+		ids = req.IDs
+	}
+
+	if err := h.svc.MarkRead(middleware.CurrentUser(c).UserID, ids); err != nil {
+		c.JSON(500, gin.H{"success": false, "error": "Failed to mark notifications as read"})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "message": "Notifications marked as read"})
+}
+
+// MarkAllRead - Mark everything (optionally only some types) as read
+// POST /api/notifications/read-all?type=gas
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	types, ok := parseNotificationTypes(c)
+	if !ok {
+		return
+	}
+
+	marked, err := h.svc.MarkAllRead(middleware.CurrentUser(c).UserID, types)
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "error": "Failed to mark notifications as read"})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "message": "All notifications marked as read", "data": gin.H{"marked": marked}})
+}
+
+// parseNotificationTypes accepts ?type=gas,door and ?type=gas&type=door
+func parseNotificationTypes(c *gin.Context) ([]string, bool) {
+	var types []string
+	for _, raw := range c.QueryArray("type") {
+		for _, t := range strings.Split(raw, ",") {
+			t = strings.TrimSpace(t)
+			if t == "" {
+				continue
+			}
+			if !notificationTypes[t] {
+				c.JSON(400, gin.H{"success": false, "error": "Invalid notification type: " + t})
+				return nil, false
+			}
+			types = append(types, t)
+		}
+	}
+	return types, true
+}
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"smarthome-backend/database/models"
+	"smarthome-backend/internal/middleware"
+	"smarthome-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type SceneHandler struct {
+	svc         service.SceneService
+	defaultWait time.Duration
+}
+
+// NewSceneHandler - defaultWait is used for ?wait=true on activation
+func NewSceneHandler(s service.SceneService, defaultWait time.Duration) *SceneHandler {
+	return &SceneHandler{svc: s, defaultWait: defaultWait}
+}
+
+func (h *SceneHandler) GetAll(c *gin.Context) {
+	scenes, err := h.svc.GetAll()
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "error": "Failed to retrieve scenes"})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "data": scenes})
+}
+
+func (h *SceneHandler) GetByID(c *gin.Context) {
+	id, ok := parseSceneID(c)
+	if !ok {
+		return
+	}
+
+	scene, err := h.svc.GetByID(id)
+	if err != nil {
+		respondSceneError(c, err, "Failed to retrieve scene")
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "data": scene})
+}
+
+func (h *SceneHandler) Create(c *gin.Context) {
+	var req models.SceneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	scene, err := h.svc.Create(req, middleware.CurrentUserID(c))
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(201, gin.H{"success": true, "message": "Scene created", "data": scene})
+}
+
+func (h *SceneHandler) Update(c *gin.Context) {
+	id, ok := parseSceneID(c)
+	if !ok {
+		return
+	}
+
+	var req models.SceneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	scene, err := h.svc.Update(id, req)
+	if err != nil {
+		respondSceneError(c, err, err.Error())
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "message": "Scene updated", "data": scene})
+}
+
+func (h *SceneHandler) Delete(c *gin.Context) {
+	id, ok := parseSceneID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Delete(id); err != nil {
+		c.JSON(500, gin.H{"success": false, "error": "Failed to delete scene"})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "message": "Scene deleted"})
+}
+
+// Activate - Apply every device state of the scene
+// POST /api/scenes/:id/activate?wait=true|<seconds>
+func (h *SceneHandler) Activate(c *gin.Context) {
+	id, ok := parseSceneID(c)
+	if !ok {
+		return
+	}
+
+	wait, err := parseWaitQuery(c, h.defaultWait)
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	activation, err := h.svc.Activate(id, middleware.CurrentUserID(c), wait)
+	if err != nil {
+		respondSceneError(c, err, err.Error())
+		return
+	}
+
+	switch activation.Status {
+	case "failed":
+		c.JSON(502, gin.H{"success": false, "error": "Scene activation failed", "data": activation})
+	case "partial":
+		c.JSON(200, gin.H{"success": true, "message": "Scene partially activated", "data": activation})
+	default:
+		c.JSON(200, gin.H{"success": true, "message": "Scene activated", "data": activation})
+	}
+}
+
+// GetActivations - Activation history of all scenes, or one scene via /:id/activations
+func (h *SceneHandler) GetActivations(c *gin.Context) {
+	var sceneID *uint
+	if c.Param("id") != "" {
+		id, ok := parseSceneID(c)
+		if !ok {
+			return
+		}
+		sceneID = &id
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+
+	activations, err := h.svc.GetActivations(sceneID, limit)
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "error": "Failed to retrieve scene activations"})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "data": activations})
+}
+
+func parseSceneID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "error": "Invalid scene ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondSceneError(c *gin.Context, err error, message string) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(404, gin.H{"success": false, "error": "Scene not found"})
+		return
+	}
+	c.JSON(400, gin.H{"success": false, "error": message})
+}
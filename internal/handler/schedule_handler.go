@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"smarthome-backend/database/models"
+	"smarthome-backend/internal/middleware"
+	"smarthome-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ScheduleHandler struct {
+	svc service.ScheduleService
+}
+
+func NewScheduleHandler(s service.ScheduleService) *ScheduleHandler {
+	return &ScheduleHandler{svc: s}
+}
+
+func (h *ScheduleHandler) GetAll(c *gin.Context) {
+	schedules, err := h.svc.GetAll()
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "error": "Failed to retrieve schedules"})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "data": schedules})
+}
+
+func (h *ScheduleHandler) GetByID(c *gin.Context) {
+	id, ok := parseScheduleID(c)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.svc.GetByID(id)
+	if err != nil {
+		respondScheduleError(c, err, "Failed to retrieve schedule")
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "data": schedule})
+}
+
+func (h *ScheduleHandler) Create(c *gin.Context) {
+	var req models.ScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	schedule, err := h.svc.Create(req, middleware.CurrentUserID(c))
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(201, gin.H{"success": true, "message": "Schedule created", "data": schedule})
+}
+
+func (h *ScheduleHandler) Update(c *gin.Context) {
+	id, ok := parseScheduleID(c)
+	if !ok {
+		return
+	}
+
+	var req models.ScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	schedule, err := h.svc.Update(id, req)
+	if err != nil {
+		respondScheduleError(c, err, err.Error())
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "message": "Schedule updated", "data": schedule})
+}
+
+func (h *ScheduleHandler) Delete(c *gin.Context) {
+	id, ok := parseScheduleID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Delete(id); err != nil {
+		c.JSON(500, gin.H{"success": false, "error": "Failed to delete schedule"})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "message": "Schedule deleted"})
+}
+
+func (h *ScheduleHandler) Pause(c *gin.Context) {
+	h.setPaused(c, true)
+}
+
+func (h *ScheduleHandler) Resume(c *gin.Context) {
+	h.setPaused(c, false)
+}
+
+func (h *ScheduleHandler) setPaused(c *gin.Context, paused bool) {
+	id, ok := parseScheduleID(c)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.svc.SetPaused(id, paused)
+	if err != nil {
+		respondScheduleError(c, err, "Failed to update schedule")
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "data": schedule})
+}
+
+// SkipNext - POST skips the next occurrence, DELETE cancels the skip
+func (h *ScheduleHandler) SkipNext(c *gin.Context) {
+	id, ok := parseScheduleID(c)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.svc.SetSkipNext(id, c.Request.Method != "DELETE")
+	if err != nil {
+		respondScheduleError(c, err, "Failed to update schedule")
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "data": schedule})
+}
+
+func parseScheduleID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "error": "Invalid schedule ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondScheduleError(c *gin.Context, err error, message string) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(404, gin.H{"success": false, "error": "Schedule not found"})
+		return
+	}
+	c.JSON(400, gin.H{"success": false, "error": message})
+}
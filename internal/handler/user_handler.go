@@ -1,19 +1,23 @@
 package handler
 
 import (
+	"errors"
 	"smarthome-backend/database/models"
+	"smarthome-backend/internal/middleware"
 	"smarthome-backend/internal/service"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type UserHandler struct {
-	svc service.UserService
+	svc    service.UserService
+	pinSvc service.PinService
 }
 
-func NewUserHandler(s service.UserService) *UserHandler {
-	return &UserHandler{svc: s}
+func NewUserHandler(s service.UserService, p service.PinService) *UserHandler {
+	return &UserHandler{svc: s, pinSvc: p}
 }
 
 func (h *UserHandler) Register(c *gin.Context) {
@@ -232,3 +236,94 @@ func (h *UserHandler) ReEnrollFace(c *gin.Context) {
 
 	c.JSON(200, gin.H{"success": true, "message": "Face re-enrolled successfully", "data": user})
 }
+
+// Personal PIN Handlers
+
+func (h *UserHandler) GetPin(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "error": "Invalid user ID"})
+		return
+	}
+
+	pin, err := h.pinSvc.GetUserPin(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(200, gin.H{"success": true, "data": gin.H{"configured": false}})
+			return
+		}
+		c.JSON(500, gin.H{"success": false, "error": "Failed to retrieve PIN"})
+		return
+	}
+
+	// Only metadata is exposed; the PIN itself is stored as a hash
+	c.JSON(200, gin.H{"success": true, "data": gin.H{
+		"configured": true,
+		"created_at": pin.CreatedAt,
+		"updated_at": pin.UpdatedAt,
+	}})
+}
+
+func (h *UserHandler) SetPin(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "error": "Invalid user ID"})
+		return
+	}
+
+	var req models.UserPinRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	user := middleware.CurrentUser(c)
+	if err := h.pinSvc.SetUserPin(uint(id), user.UserID, req.Pin); err != nil {
+		var locked *service.PinChangeLockedError
+		if errors.As(err, &locked) {
+			c.Header("Retry-After", strconv.Itoa(locked.LockoutSeconds))
+			c.JSON(429, gin.H{"success": false, "error": err.Error(), "lockout_seconds": locked.LockoutSeconds})
+			return
+		}
+		if errors.Is(err, service.ErrPinRejected) {
+			c.JSON(409, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+		c.JSON(500, gin.H{"success": false, "error": "Failed to set PIN"})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "message": "PIN updated successfully"})
+}
+
+// GeneratePin - Server picks an unused PIN; the plaintext is only returned here
+func (h *UserHandler) GeneratePin(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "error": "Invalid user ID"})
+		return
+	}
+
+	pin, err := h.pinSvc.GenerateUserPin(uint(id))
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "error": "Failed to generate PIN"})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "message": "PIN generated", "data": gin.H{"pin": pin}})
+}
+
+func (h *UserHandler) DeletePin(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.pinSvc.DeleteUserPin(uint(id)); err != nil {
+		c.JSON(500, gin.H{"success": false, "error": "Failed to delete PIN"})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "message": "PIN deleted successfully"})
+}
@@ -0,0 +1,287 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"smarthome-backend/database/models"
+	"smarthome-backend/internal/middleware"
+	"smarthome-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var webhookEventTypes = map[string]bool{
+	models.EventSensorThreshold: true,
+	models.EventLampState:       true,
+	models.EventDoorState:       true,
+	models.EventCurtainState:    true,
+	models.EventAccessLog:       true,
+	models.EventUserApproval:    true,
+}
+
+// WebhookHandler - admin management of outgoing webhooks
+type WebhookHandler struct {
+	svc service.WebhookService
+}
+
+func NewWebhookHandler(s service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{svc: s}
+}
+
+// ==================== ENDPOINTS ====================
+
+// GET /api/admin/webhooks
+func (h *WebhookHandler) GetEndpoints(c *gin.Context) {
+	endpoints, err := h.svc.GetEndpoints()
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "error": "Failed to retrieve webhooks"})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "data": endpoints})
+}
+
+// GET /api/admin/webhooks/:id
+func (h *WebhookHandler) GetEndpoint(c *gin.Context) {
+	id, ok := parseWebhookID(c, "Invalid webhook ID")
+	if !ok {
+		return
+	}
+
+	endpoint, err := h.svc.GetEndpoint(id)
+	if err != nil {
+		respondWebhookError(c, err, "Webhook not found", "Failed to retrieve webhook")
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "data": endpoint})
+}
+
+// CreateEndpoint - The signing secret is only returned here and on rotate
+// POST /api/admin/webhooks
+func (h *WebhookHandler) CreateEndpoint(c *gin.Context) {
+	var req models.WebhookEndpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	endpoint, secret, err := h.svc.CreateEndpoint(req, middleware.CurrentUserID(c))
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "error": "Failed to create webhook"})
+		return
+	}
+
+	c.JSON(201, gin.H{
+		"success": true,
+		"message": "Webhook created. Store the secret now, it will not be shown again",
+		"data":    endpoint,
+		"secret":  secret,
+	})
+}
+
+// PUT /api/admin/webhooks/:id
+func (h *WebhookHandler) UpdateEndpoint(c *gin.Context) {
+	id, ok := parseWebhookID(c, "Invalid webhook ID")
+	if !ok {
+		return
+	}
+
+	var req models.WebhookEndpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	endpoint, err := h.svc.UpdateEndpoint(id, req)
+	if err != nil {
+		respondWebhookError(c, err, "Webhook not found", "Failed to update webhook")
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "message": "Webhook updated", "data": endpoint})
+}
+
+// DELETE /api/admin/webhooks/:id
+func (h *WebhookHandler) DeleteEndpoint(c *gin.Context) {
+	id, ok := parseWebhookID(c, "Invalid webhook ID")
+	if !ok {
+		return
+	}
+
+	if err := h.svc.DeleteEndpoint(id); err != nil {
+		respondWebhookError(c, err, "Webhook not found", "Failed to delete webhook")
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "message": "Webhook deleted"})
+}
+
+// POST /api/admin/webhooks/:id/rotate-secret
+func (h *WebhookHandler) RotateSecret(c *gin.Context) {
+	id, ok := parseWebhookID(c, "Invalid webhook ID")
+	if !ok {
+		return
+	}
+
+	secret, err := h.svc.RotateSecret(id)
+	if err != nil {
+		respondWebhookError(c, err, "Webhook not found", "Failed to rotate secret")
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "message": "Secret rotated", "secret": secret})
+}
+
+// ==================== DELIVERIES ====================
+
+// GetDeliveries - Delivery log
+// GET /api/admin/webhooks/deliveries?endpoint_id=1&status=dead&event_type=door.state&limit=50
+func (h *WebhookHandler) GetDeliveries(c *gin.Context) {
+	filter, ok := parseDeliveryFilter(c)
+	if !ok {
+		return
+	}
+	h.respondDeliveries(c, filter)
+}
+
+// GetDeadLetters - Deliveries that ran out of retries
+// GET /api/admin/webhooks/dead-letters?endpoint_id=1&event_type=access.log&limit=50
+func (h *WebhookHandler) GetDeadLetters(c *gin.Context) {
+	filter, ok := parseDeliveryFilter(c)
+	if !ok {
+		return
+	}
+	filter.Status = models.WebhookDead
+	h.respondDeliveries(c, filter)
+}
+
+// GET /api/admin/webhooks/deliveries/:id
+func (h *WebhookHandler) GetDelivery(c *gin.Context) {
+	id, ok := parseWebhookID(c, "Invalid delivery ID")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.svc.GetDelivery(id)
+	if err != nil {
+		respondWebhookError(c, err, "Delivery not found", "Failed to retrieve delivery")
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "data": delivery})
+}
+
+// Redeliver - Queue the same payload again (new delivery linked via redelivery_of)
+// POST /api/admin/webhooks/deliveries/:id/redeliver
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	id, ok := parseWebhookID(c, "Invalid delivery ID")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.svc.Redeliver(id)
+	if err != nil {
+		if errors.Is(err, service.ErrDeliveryPending) {
+			c.JSON(409, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+		respondWebhookError(c, err, "Delivery not found", "Failed to redeliver")
+		return
+	}
+
+	c.JSON(202, gin.H{"success": true, "message": "Redelivery queued", "data": delivery})
+}
+
+func (h *WebhookHandler) respondDeliveries(c *gin.Context, filter models.WebhookDeliveryFilter) {
+	deliveries, err := h.svc.GetDeliveries(filter)
+	if err != nil {
+		c.JSON(500, gin.H{"success": false, "error": "Failed to retrieve deliveries"})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "data": deliveries, "count": len(deliveries)})
+}
+
+// ==================== THRESHOLDS ====================
+
+// GetThresholds - Bounds for sensor.threshold events
+// GET /api/admin/webhooks/thresholds
+func (h *WebhookHandler) GetThresholds(c *gin.Context) {
+	c.JSON(200, gin.H{"success": true, "data": h.svc.GetThresholds()})
+}
+
+// UpdateThresholds - Replace all bounds, e.g. {"gas": {"high": 500}, "light": {"low": 50}}
+// PUT /api/admin/webhooks/thresholds
+func (h *WebhookHandler) UpdateThresholds(c *gin.Context) {
+	var req models.SensorThresholds
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	if err := h.svc.UpdateThresholds(req, middleware.CurrentUserID(c)); err != nil {
+		if errors.Is(err, service.ErrInvalidThresholds) {
+			c.JSON(400, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+		c.JSON(500, gin.H{"success": false, "error": "Failed to update thresholds"})
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true, "message": "Thresholds updated", "data": h.svc.GetThresholds()})
+}
+
+func parseDeliveryFilter(c *gin.Context) (models.WebhookDeliveryFilter, bool) {
+	filter := models.WebhookDeliveryFilter{
+		Status:    c.Query("status"),
+		EventType: c.Query("event_type"),
+	}
+
+	if raw := c.Query("endpoint_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			c.JSON(400, gin.H{"success": false, "error": "Invalid endpoint_id"})
+			return filter, false
+		}
+		endpointID := uint(id)
+		filter.EndpointID = &endpointID
+	}
+
+	switch filter.Status {
+	case "", models.WebhookPending, models.WebhookDelivered, models.WebhookDead:
+	default:
+		c.JSON(400, gin.H{"success": false, "error": "Invalid status"})
+		return filter, false
+	}
+	if filter.EventType != "" && !webhookEventTypes[filter.EventType] {
+		c.JSON(400, gin.H{"success": false, "error": "Invalid event_type"})
+		return filter, false
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 500 {
+		limit = 50
+	}
+	filter.Limit = limit
+	return filter, true
+}
+
+func parseWebhookID(c *gin.Context, message string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"success": false, "error": message})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondWebhookError(c *gin.Context, err error, notFound, message string) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(404, gin.H{"success": false, "error": notFound})
+		return
+	}
+	c.JSON(500, gin.H{"success": false, "error": message})
+}
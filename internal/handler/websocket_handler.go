@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	"smarthome-backend/internal/websocket"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebSocketHandler struct {
+	hub *websocket.Hub
+}
+
+func NewWebSocketHandler(hub *websocket.Hub) *WebSocketHandler {
+	return &WebSocketHandler{hub: hub}
+}
+
+// Connect upgrades to a WebSocket streaming live sensor/device events
+// GET /ws?since=<seq>&types=temperature,gas
+func (h *WebSocketHandler) Connect(c *gin.Context) {
+	var since uint64
+	resume := false
+	if raw := c.Query("since"); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			c.JSON(400, gin.H{"success": false, "error": "Invalid since parameter"})
+			return
+		}
+		since = parsed
+		resume = true
+	}
+
+	var types []string
+	if raw := c.Query("types"); raw != "" {
+		types = strings.Split(raw, ",")
+	}
+
+	if err := h.hub.ServeWS(c.Writer, c.Request, since, resume, types); err != nil {
+		// Upgrader already wrote the HTTP error response
+		log.Printf("[WS] Upgrade failed: %v", err)
+	}
+}
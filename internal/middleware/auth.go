@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"strconv"
+	"strings"
+
+	"smarthome-backend/database/models"
+	"smarthome-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// ContextUserKey is where the authenticated *models.User is stored in gin.Context
+	ContextUserKey = "user"
+	// DeviceKeyHeader carries the shared secret used by ESP32 devices
+	DeviceKeyHeader = "X-Device-Key"
+)
+
+// AuthRequired validates the bearer token and loads the current user from the DB.
+// The DB lookup makes suspensions/role changes effective immediately instead
+// of waiting for the 24h token to expire.
+func AuthRequired(authSvc service.AuthService, userSvc service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString := extractToken(c)
+		if tokenString == "" {
+			c.AbortWithStatusJSON(401, gin.H{"success": false, "error": "Authorization token required"})
+			return
+		}
+
+		claims, err := authSvc.ValidateToken(tokenString)
+		if err != nil {
+			c.AbortWithStatusJSON(401, gin.H{"success": false, "error": "Invalid or expired token"})
+			return
+		}
+
+		user, err := userSvc.GetByID(claims.UserID)
+		if err != nil || user.UserID == 0 {
+			c.AbortWithStatusJSON(401, gin.H{"success": false, "error": "User no longer exists"})
+			return
+		}
+
+		switch user.Status {
+		case "active":
+		case "pending":
+			c.AbortWithStatusJSON(403, gin.H{"success": false, "error": "Account is pending approval"})
+			return
+		case "suspended":
+			c.AbortWithStatusJSON(403, gin.H{"success": false, "error": "Account is suspended"})
+			return
+		default:
+			c.AbortWithStatusJSON(403, gin.H{"success": false, "error": "Account not active"})
+			return
+		}
+
+		c.Set(ContextUserKey, user)
+		c.Next()
+	}
+}
+
+// RequireRole allows the request only when the current user has one of the roles.
+// Must be chained after AuthRequired.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(roles))
+	for _, r := range roles {
+		allowed[r] = true
+	}
+
+	return func(c *gin.Context) {
+		user := CurrentUser(c)
+		if user == nil {
+			c.AbortWithStatusJSON(401, gin.H{"success": false, "error": "Authentication required"})
+			return
+		}
+		if !allowed[user.Role] {
+			c.AbortWithStatusJSON(403, gin.H{"success": false, "error": "Insufficient permissions"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireSelfOrAdmin lets a user act on their own resource (by URL param) or any resource if admin.
+// Must be chained after AuthRequired.
+func RequireSelfOrAdmin(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := CurrentUser(c)
+		if user == nil {
+			c.AbortWithStatusJSON(401, gin.H{"success": false, "error": "Authentication required"})
+			return
+		}
+		if user.Role == "admin" {
+			c.Next()
+			return
+		}
+
+		id, err := strconv.ParseUint(c.Param(param), 10, 32)
+		if err != nil || uint(id) != user.UserID {
+			c.AbortWithStatusJSON(403, gin.H{"success": false, "error": "Insufficient permissions"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// DeviceAuth protects ingestion endpoints called by ESP32 boards with a shared key
+func DeviceAuth(deviceKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided := c.GetHeader(DeviceKeyHeader)
+		if deviceKey == "" || provided == "" ||
+			subtle.ConstantTimeCompare([]byte(provided), []byte(deviceKey)) != 1 {
+			c.AbortWithStatusJSON(401, gin.H{"success": false, "error": "Invalid device credentials"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// CurrentUser returns the authenticated user or nil
+func CurrentUser(c *gin.Context) *models.User {
+	value, exists := c.Get(ContextUserKey)
+	if !exists {
+		return nil
+	}
+	user, _ := value.(*models.User)
+	return user
+}
+
+// CurrentUserID returns a pointer suitable for nullable user_id columns
+func CurrentUserID(c *gin.Context) *uint {
+	user := CurrentUser(c)
+	if user == nil {
+		return nil
+	}
+	id := user.UserID
+	return &id
+}
+
+func extractToken(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	if strings.HasPrefix(header, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+	}
+
+	// Browsers cannot set headers on WebSocket handshakes
+	if c.IsWebsocket() {
+		return c.Query("token")
+	}
+	return ""
+}
@@ -0,0 +1,378 @@
+package mqtt
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"smarthome-backend/database/models"
+	"smarthome-backend/internal/repository"
+	"smarthome-backend/internal/websocket"
+)
+
+const (
+	defaultCommandTimeout = 10 * time.Second
+	// Upper bound for ?wait on the API so requests don't hang forever
+	maxCommandWait = 30 * time.Second
+)
+
+var ErrUnknownCommand = errors.New("unsupported device or action")
+
+// deviceCommandSpec maps device/action to the control topic and the status the ESP32 should report back
+var deviceCommandSpec = map[string]struct {
+	topic    string
+	expected map[string]string
+}{
+	"door":    {"iotcihuy/home/door/control", map[string]string{"lock": "locked", "unlock": "unlocked"}},
+	"lamp":    {"iotcihuy/home/lamp/control", map[string]string{"on": "on", "off": "off"}},
+	"curtain": {"iotcihuy/home/curtain/control", map[string]string{"open": "open", "close": "closed"}},
+}
+
+// remoteEchoMethod is what door firmware reports for any command it received over MQTT
+const remoteEchoMethod = "remote"
+
+// directCommands are never queued while the broker is offline and never re-sent
+// on timeout: an unlock that runs later, when nobody is at the door (or after a
+// resident locked it again), is worse than a failed one
+var directCommands = map[string]bool{
+	"door/unlock": true,
+}
+
+type pendingCommand struct {
+	cmd      models.DeviceCommand
+	topic    string
+	payload  map[string]string
+	expected string
+	direct   bool // see directCommands
+	timer    *time.Timer
+	done     chan struct{}
+}
+
+// DeviceController publishes control commands with a correlation ID and keeps
+// them pending until the matching */status message confirms the new state.
+// Unconfirmed commands are retried (maxRetries) and then marked timed_out.
+type DeviceController struct {
+	publisher  *Publisher
+	repo       repository.DeviceCommandRepository
+	hub        *websocket.Hub
+	timeout    time.Duration
+	maxRetries int
+
+	mu      sync.Mutex
+	pending map[string]*pendingCommand
+}
+
+func NewDeviceController(
+	publisher *Publisher,
+	repo repository.DeviceCommandRepository,
+	hub *websocket.Hub,
+	timeout time.Duration,
+	maxRetries int,
+) *DeviceController {
+	if timeout <= 0 {
+		timeout = defaultCommandTimeout
+	}
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+	return &DeviceController{
+		publisher:  publisher,
+		repo:       repo,
+		hub:        hub,
+		timeout:    timeout,
+		maxRetries: maxRetries,
+		pending:    make(map[string]*pendingCommand),
+	}
+}
+
+// RecoverPending closes commands that were still pending when the backend stopped
+func (dc *DeviceController) RecoverPending() error {
+	n, err := dc.repo.ExpirePending()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("[CMD] Marked %d stale pending command(s) as timed_out", n)
+	}
+	return nil
+}
+
+// Send publishes the command and returns it in pending state (or failed when publish failed)
+func (dc *DeviceController) Send(req models.DeviceCommandRequest) (*models.DeviceCommand, error) {
+	spec, ok := deviceCommandSpec[req.Device]
+	if !ok {
+		return nil, ErrUnknownCommand
+	}
+	expected, ok := spec.expected[req.Action]
+	if !ok {
+		return nil, ErrUnknownCommand
+	}
+	if req.Source == "" {
+		req.Source = "api"
+	}
+
+	correlationID, err := newCorrelationID()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &pendingCommand{
+		cmd: models.DeviceCommand{
+			CorrelationID: correlationID,
+			Device:        req.Device,
+			Action:        req.Action,
+			Mode:          req.Mode,
+			Method:        req.Method,
+			Status:        "pending",
+			Attempts:      1,
+			Source:        req.Source,
+			SourceID:      req.SourceID,
+			UserID:        req.UserID,
+		},
+		topic:    spec.topic,
+		payload:  map[string]string{"action": req.Action, "correlation_id": correlationID},
+		expected: expected,
+		direct:   directCommands[req.Device+"/"+req.Action],
+		done:     make(chan struct{}),
+	}
+	if req.Mode != "" {
+		p.payload["mode"] = req.Mode
+	}
+	if req.Method != "" {
+		p.payload["method"] = req.Method
+	}
+
+	if err := dc.repo.Create(&p.cmd); err != nil {
+		return nil, fmt.Errorf("failed to record command: %w", err)
+	}
+
+	// Register before publishing so a fast status reply can't be missed
+	dc.mu.Lock()
+	dc.pending[correlationID] = p
+	dc.mu.Unlock()
+
+	queued, err := dc.publish(p)
+	if err != nil {
+		dc.mu.Lock()
+		result := dc.finishLocked(p, "failed", err.Error())
+		dc.mu.Unlock()
+		return result, err
+	}
+
+	dc.mu.Lock()
+	p.cmd.Queued = queued
+	if !queued {
+		// Queued commands start their timeout once flushed (onFlushed)
+		p.timer = time.AfterFunc(dc.timeout, func() { dc.onTimeout(correlationID) })
+	}
+	result := p.cmd
+	dc.mu.Unlock()
+
+	log.Printf("[CMD] %s → %s sent (id=%s, source=%s)", req.Device, req.Action, correlationID, req.Source)
+	dc.broadcast(&result)
+	return &result, nil
+}
+
+// Get returns the live pending command or the stored one
+func (dc *DeviceController) Get(correlationID string) (*models.DeviceCommand, error) {
+	dc.mu.Lock()
+	if p, ok := dc.pending[correlationID]; ok {
+		cmd := p.cmd
+		dc.mu.Unlock()
+		return &cmd, nil
+	}
+	dc.mu.Unlock()
+
+	return dc.repo.GetByCorrelationID(correlationID)
+}
+
+// Wait blocks until the command leaves pending state or the wait elapses
+func (dc *DeviceController) Wait(correlationID string, wait time.Duration) (*models.DeviceCommand, error) {
+	if wait > maxCommandWait {
+		wait = maxCommandWait
+	}
+
+	dc.mu.Lock()
+	p, ok := dc.pending[correlationID]
+	dc.mu.Unlock()
+
+	if ok && wait > 0 {
+		select {
+		case <-p.done:
+		case <-time.After(wait):
+		}
+	}
+	return dc.Get(correlationID)
+}
+
+// DefaultWait covers every retry of a command plus a small margin
+func (dc *DeviceController) DefaultWait() time.Duration {
+	return dc.timeout*time.Duration(dc.maxRetries+1) + time.Second
+}
+
+// Recent lists the latest commands for the API
+func (dc *DeviceController) Recent(device string, limit int) ([]models.DeviceCommand, error) {
+	return dc.repo.GetRecent(device, limit)
+}
+
+// Confirm is called by the */status handlers. correlationID is optional: firmware
+// that doesn't echo it is matched to the oldest pending command expecting this status
+// when it reports the command's method or the "remote" echo, so e.g. a manual or
+// keypad-local unlock is never taken for a backend command.
+// Returns the resolved command, or nil when the status didn't belong to one.
+func (dc *DeviceController) Confirm(device, status, method, correlationID, deviceError string) *models.DeviceCommand {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	var p *pendingCommand
+	if correlationID != "" {
+		if candidate, ok := dc.pending[correlationID]; ok && candidate.cmd.Device == device {
+			p = candidate
+		}
+	} else {
+		for _, candidate := range dc.pending {
+			if candidate.cmd.Device != device || candidate.expected != status {
+				continue
+			}
+			if method != remoteEchoMethod && method != candidate.cmd.Method {
+				continue
+			}
+			if p == nil || candidate.cmd.ID < p.cmd.ID {
+				p = candidate
+			}
+		}
+	}
+	if p == nil {
+		return nil
+	}
+
+	if deviceError != "" || status == "error" {
+		if deviceError == "" {
+			deviceError = "device reported error"
+		}
+		return dc.finishLocked(p, "failed", deviceError)
+	}
+	if status != p.expected {
+		// Intermediate state (e.g. curtain "opening"), keep waiting
+		return nil
+	}
+	return dc.finishLocked(p, "confirmed", "")
+}
+
+func (dc *DeviceController) onTimeout(correlationID string) {
+	dc.mu.Lock()
+	p, ok := dc.pending[correlationID]
+	if !ok {
+		dc.mu.Unlock()
+		return
+	}
+
+	if p.direct || p.cmd.Attempts > dc.maxRetries {
+		dc.finishLocked(p, "timed_out", "no status confirmation from device")
+		dc.mu.Unlock()
+		return
+	}
+
+	p.cmd.Attempts++
+	attempt := p.cmd.Attempts
+	cmd := p.cmd
+	dc.mu.Unlock()
+
+	if err := dc.repo.Update(&cmd); err != nil {
+		log.Printf("[CMD] Failed to update command %s: %v", correlationID, err)
+	}
+
+	log.Printf("[CMD] %s → %s unconfirmed, retry %d (id=%s)", cmd.Device, cmd.Action, attempt-1, correlationID)
+	queued, err := dc.publish(p)
+	if err != nil {
+		dc.mu.Lock()
+		dc.finishLocked(p, "failed", err.Error())
+		dc.mu.Unlock()
+		return
+	}
+
+	dc.mu.Lock()
+	if _, still := dc.pending[correlationID]; still && !queued {
+		p.timer = time.AfterFunc(dc.timeout, func() { dc.onTimeout(correlationID) })
+	}
+	dc.mu.Unlock()
+}
+
+// publish sends direct commands immediately (ErrNotConnected when offline) and queues the rest
+func (dc *DeviceController) publish(p *pendingCommand) (queued bool, err error) {
+	if p.direct {
+		return false, dc.publisher.PublishDirect(p.topic, 1, p.payload)
+	}
+	correlationID := p.cmd.CorrelationID
+	return dc.publisher.PublishTracked(p.topic, 1, p.payload, func(sent bool) { dc.onFlushed(correlationID, sent) })
+}
+
+// onFlushed starts the confirmation timeout once a queued command reaches the broker,
+// so retries don't pile up behind the offline queue
+func (dc *DeviceController) onFlushed(correlationID string, sent bool) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	p, ok := dc.pending[correlationID]
+	if !ok {
+		return
+	}
+	if !sent {
+		dc.finishLocked(p, "failed", ErrQueueExpired.Error())
+		return
+	}
+	p.timer = time.AfterFunc(dc.timeout, func() { dc.onTimeout(correlationID) })
+}
+
+// finishLocked must be called with dc.mu held
+func (dc *DeviceController) finishLocked(p *pendingCommand, status, errMsg string) *models.DeviceCommand {
+	if _, ok := dc.pending[p.cmd.CorrelationID]; !ok {
+		// Already resolved by a concurrent status/timeout
+		result := p.cmd
+		return &result
+	}
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+	delete(dc.pending, p.cmd.CorrelationID)
+
+	p.cmd.Status = status
+	p.cmd.Error = errMsg
+	if status == "confirmed" {
+		now := time.Now()
+		p.cmd.ConfirmedAt = &now
+	}
+	close(p.done)
+
+	result := p.cmd
+	go func() {
+		if err := dc.repo.Update(&result); err != nil {
+			log.Printf("[CMD] Failed to update command %s: %v", result.CorrelationID, err)
+		}
+		dc.broadcast(&result)
+	}()
+
+	log.Printf("[CMD] %s → %s %s (id=%s)", result.Device, result.Action, status, result.CorrelationID)
+	return &result
+}
+
+func (dc *DeviceController) broadcast(cmd *models.DeviceCommand) {
+	if dc.hub == nil {
+		return
+	}
+	dc.hub.Broadcast(websocket.EventDeviceCommand, cmd)
+}
+
+// newCorrelationID returns a random UUIDv4 string
+func newCorrelationID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
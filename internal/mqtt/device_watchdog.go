@@ -0,0 +1,76 @@
+package mqtt
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"smarthome-backend/database/models"
+	"smarthome-backend/internal/service"
+)
+
+// Sensors publish periodically, so silence this long means the node is offline.
+// Lamp/door/curtain only report on change and are not watched.
+const defaultDeviceOfflineTimeout = 5 * time.Minute
+
+// deviceWatchdog raises device offline/online notifications from message gaps.
+// Only devices seen at least once since startup are watched.
+type deviceWatchdog struct {
+	notifSvc service.NotificationService
+	timeout  time.Duration
+
+	mu       sync.Mutex
+	lastSeen map[string]time.Time
+	offline  map[string]bool
+}
+
+func newDeviceWatchdog(notifSvc service.NotificationService, timeout time.Duration) *deviceWatchdog {
+	return &deviceWatchdog{
+		notifSvc: notifSvc,
+		timeout:  timeout,
+		lastSeen: make(map[string]time.Time),
+		offline:  make(map[string]bool),
+	}
+}
+
+func (w *deviceWatchdog) start() {
+	ticker := time.NewTicker(w.timeout / 5)
+	go func() {
+		for range ticker.C {
+			w.check(time.Now())
+		}
+	}()
+}
+
+func (w *deviceWatchdog) seen(device string) {
+	w.mu.Lock()
+	w.lastSeen[device] = time.Now()
+	wasOffline := w.offline[device]
+	delete(w.offline, device)
+	w.mu.Unlock()
+
+	if wasOffline {
+		log.Printf("[WATCHDOG] %s back online", device)
+		go w.notifSvc.Notify(models.NotifDevice, "Device Online", fmt.Sprintf("%s sensor is reporting again", device))
+	}
+}
+
+func (w *deviceWatchdog) check(now time.Time) {
+	var silent []string
+
+	w.mu.Lock()
+	for device, last := range w.lastSeen {
+		if !w.offline[device] && now.Sub(last) > w.timeout {
+			w.offline[device] = true
+			silent = append(silent, device)
+		}
+	}
+	w.mu.Unlock()
+
+	for _, device := range silent {
+		log.Printf("[WATCHDOG] %s offline (no data for %s)", device, w.timeout)
+		w.notifSvc.Notify(models.NotifDevice, "Device Offline",
+			fmt.Sprintf("No %s data received for %d minutes", device, int(w.timeout.Minutes())))
+	}
+}
@@ -2,8 +2,12 @@ package mqtt
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
+	"smarthome-backend/database/models"
 	"smarthome-backend/internal/service"
+	"smarthome-backend/internal/websocket"
+	"strings"
 	"sync"
 	"time"
 
@@ -12,6 +16,8 @@ import (
 
 type MQTTHandler struct {
 	client     mqtt.Client
+	publisher  *Publisher
+	controller *DeviceController
 	gasSvc     service.GasService
 	tempSvc    service.TempService
 	humidSvc   service.HumidService
@@ -20,6 +26,23 @@ type MQTTHandler struct {
 	lampSvc    service.LampService
 	curtainSvc service.CurtainService
 	pinSvc     service.PinService
+	automation service.AutomationService
+	alarm      service.AlarmService
+	gasAlert   service.GasPlaybookService
+	relock     service.DoorRelockService
+	notifSvc   service.NotificationService
+	webhooks   service.WebhookService
+	cameras    service.CameraService
+	fingerSvc  service.FingerprintService
+	buzzerSvc  service.BuzzerService
+
+	// Live event stream for dashboard clients
+	hub *websocket.Hub
+
+	// Sensor offline detection & broker connection state
+	watchdog        *deviceWatchdog
+	connectionLost  bool
+	connectionMutex sync.Mutex
 
 	// Batch sensor persistence
 	batchInterval time.Duration
@@ -45,10 +68,13 @@ type MQTTHandler struct {
 	gasReadings     []int
 	gasReadingMutex sync.Mutex
 	maxGasReadings  int
+	lastGasLevel    string
 }
 
 func NewMQTTHandler(
 	client mqtt.Client,
+	publisher *Publisher,
+	controller *DeviceController,
 	g service.GasService,
 	t service.TempService,
 	h service.HumidService,
@@ -57,9 +83,21 @@ func NewMQTTHandler(
 	lamp service.LampService,
 	curtain service.CurtainService,
 	pin service.PinService,
+	automation service.AutomationService,
+	alarm service.AlarmService,
+	gasAlert service.GasPlaybookService,
+	relock service.DoorRelockService,
+	notifSvc service.NotificationService,
+	webhooks service.WebhookService,
+	cameras service.CameraService,
+	fingerprint service.FingerprintService,
+	buzzer service.BuzzerService,
+	hub *websocket.Hub,
 ) *MQTTHandler {
 	handler := &MQTTHandler{
 		client:             client,
+		publisher:          publisher,
+		controller:         controller,
 		gasSvc:             g,
 		tempSvc:            t,
 		humidSvc:           h,
@@ -68,6 +106,17 @@ func NewMQTTHandler(
 		lampSvc:            lamp,
 		curtainSvc:         curtain,
 		pinSvc:             pin,
+		automation:         automation,
+		alarm:              alarm,
+		gasAlert:           gasAlert,
+		relock:             relock,
+		notifSvc:           notifSvc,
+		webhooks:           webhooks,
+		cameras:            cameras,
+		fingerSvc:          fingerprint,
+		buzzerSvc:          buzzer,
+		watchdog:           newDeviceWatchdog(notifSvc, defaultDeviceOfflineTimeout),
+		hub:                hub,
 		batchInterval:      defaultSensorBatchInterval,
 		lastBuzzerState:    "off",
 		lastLampState:      "off",
@@ -77,9 +126,11 @@ func NewMQTTHandler(
 		lastCurtainMode:    "auto",
 		gasReadings:        make([]int, 0, 5), // Buffer 5 readings
 		maxGasReadings:     5,
+		lastGasLevel:       "normal",
 	}
 
 	handler.startSensorBatcher()
+	handler.watchdog.start()
 
 	return handler
 }
@@ -93,6 +144,34 @@ func (h *MQTTHandler) startSensorBatcher() {
 	}()
 }
 
+// OnConnect runs on every (re)connect: clean sessions lose their
+// subscriptions, so subscribe again before flushing queued commands
+func (h *MQTTHandler) OnConnect(client mqtt.Client) {
+	h.SetupRoutes(client)
+	h.publisher.OnConnect()
+
+	h.connectionMutex.Lock()
+	wasLost := h.connectionLost
+	h.connectionLost = false
+	h.connectionMutex.Unlock()
+
+	if wasLost {
+		go h.notifSvc.Notify(models.NotifDevice, "MQTT Reconnected", "Connection to the MQTT broker was restored")
+	}
+}
+
+// OnConnectionLost marks the broker offline; devices can't be reached until reconnect
+func (h *MQTTHandler) OnConnectionLost(err error) {
+	h.publisher.OnConnectionLost(err)
+
+	h.connectionMutex.Lock()
+	h.connectionLost = true
+	h.connectionMutex.Unlock()
+
+	go h.notifSvc.NotifyThrottled("mqtt_offline", 5*time.Minute, models.NotifDevice, "MQTT Disconnected",
+		fmt.Sprintf("Lost connection to the MQTT broker: %v", err))
+}
+
 func (h *MQTTHandler) SetupRoutes(client mqtt.Client) {
 	h.client = client
 
@@ -105,8 +184,12 @@ func (h *MQTTHandler) SetupRoutes(client mqtt.Client) {
 		"iotcihuy/home/door/status":    h.handleDoorStatus,
 		"iotcihuy/home/door/verify":    h.handlePinVerification,
 		"iotcihuy/home/curtain/status": h.handleCurtainStatus,
+		"iotcihuy/home/buzzer/status":  h.handleBuzzerStatus,
 		"iotcihuy/home/debug":          h.handleDebug,
-		"iotcihuy/home/camera/ip": h.handleCameraIP,
+		"iotcihuy/home/camera/ip":      h.handleCameraIP,
+
+		"iotcihuy/home/fingerprint/match":         h.handleFingerprintMatch,
+		"iotcihuy/home/fingerprint/enroll/result": h.handleFingerprintEnrollResult,
 	}
 
 	log.Printf("[MQTT] Connecting to broker... (Client connected: %v)", client.IsConnected())
@@ -169,45 +252,69 @@ func (h *MQTTHandler) flushSensorCache() {
 	}
 }
 
-// ==================== CONTROL FUNCTIONS (OUTPUT) ====================
-
-func (h *MQTTHandler) PublishDoorControl(action string) error {
-	topic := "iotcihuy/home/door/control"
-	payload := map[string]string{
-		"action": action,
-		"method": "remote",
+// broadcast pushes an event to WebSocket clients (no-op when hub is not wired)
+func (h *MQTTHandler) broadcast(eventType string, data interface{}) {
+	if h.hub == nil {
+		return
 	}
-	jsonPayload, _ := json.Marshal(payload)
-	log.Printf("[MQTT] Publishing to %s: action=%s", topic, action)
+	h.hub.Broadcast(eventType, data)
+}
 
-	token := h.client.Publish(topic, 1, false, jsonPayload)
-	token.Wait()
+// ==================== CONTROL FUNCTIONS (OUTPUT) ====================
 
-	if token.Error() != nil {
-		log.Printf("[MQTT] Publish failed: %s | Error: %v", topic, token.Error())
-	} else {
-		log.Printf("[MQTT] Published: Door control: %s", action)
+// unlockDoor sends a tracked unlock for a credential the backend verified, so the
+// door/status echo is attributed to it instead of looking like a forced unlock
+func (h *MQTTHandler) unlockDoor(method string, userID *uint) error {
+	_, err := h.controller.Send(models.DeviceCommandRequest{
+		Device: "door",
+		Action: "unlock",
+		Method: method,
+		Source: method,
+		UserID: userID,
+	})
+	if err != nil {
+		log.Printf("[MQTT] Door unlock (%s) failed: %v", method, err)
 	}
-	return token.Error()
+	return err
 }
 
-func (h *MQTTHandler) PublishBuzzerControl(action string) {
+// PublishBuzzerControl - Send a buzzer command and record who/what sent it and why
+func (h *MQTTHandler) PublishBuzzerControl(cmd models.BuzzerCommand) (bool, error) {
 	topic := "iotcihuy/home/buzzer/control"
 	payload := map[string]string{
-		"action": action,
-		"source": "auto_alert",
+		"action": cmd.Action,
+	}
+	// Firmware only distinguishes manual presses from automatic alerts
+	if cmd.Source != models.BuzzerSourceManual {
+		payload["source"] = "auto_alert"
+	}
+	log.Printf("[MQTT] Publishing to %s: action=%s (%s)", topic, cmd.Action, cmd.Source)
+
+	// Manual presses are retried on reconnect like other control commands
+	qos := byte(0)
+	if cmd.Source == models.BuzzerSourceManual {
+		qos = 1
 	}
-	jsonPayload, _ := json.Marshal(payload)
-	log.Printf("[MQTT] Publishing to %s: action=%s", topic, action)
 
-	token := h.client.Publish(topic, 0, false, jsonPayload)
-	token.Wait()
+	// Logged before publishing so a fast status report can confirm it
+	entry, _ := h.buzzerSvc.Record(cmd)
 
-	if token.Error() == nil {
-		log.Printf("[MQTT] Published: Buzzer %s", action)
-	} else {
-		log.Printf("[MQTT] Buzzer publish failed: %v", token.Error())
+	queued, err := h.publisher.PublishTracked(topic, qos, payload, func(sent bool) {
+		if !sent && entry != nil {
+			h.buzzerSvc.RecordFailure(entry, ErrQueueExpired)
+		}
+	})
+	if err != nil {
+		log.Printf("[MQTT] Buzzer publish failed: %v", err)
+		if entry != nil {
+			h.buzzerSvc.RecordFailure(entry, err)
+		}
+		return false, err
+	}
+	if !queued {
+		log.Printf("[MQTT] Published: Buzzer %s", cmd.Action)
 	}
+	return queued, nil
 }
 
 func (h *MQTTHandler) PublishLampControl(action string) {
@@ -217,16 +324,13 @@ func (h *MQTTHandler) PublishLampControl(action string) {
 		"action": action,
 		"mode":   "auto",
 	}
-	jsonPayload, _ := json.Marshal(payload)
 	log.Printf("[MQTT] Publishing to %s: action=%s, mode=auto", topic, action)
 
-	token := h.client.Publish(topic, 1, false, jsonPayload)
-	token.Wait()
-
-	if token.Error() == nil {
+	queued, err := h.publisher.Publish(topic, 1, payload)
+	if err != nil {
+		log.Printf("[MQTT] Lamp publish failed: %v", err)
+	} else if !queued {
 		log.Printf("[MQTT] Published: Lamp %s (auto mode)", action)
-	} else {
-		log.Printf("[MQTT] Lamp publish failed: %v", token.Error())
 	}
 }
 
@@ -235,19 +339,16 @@ func (h *MQTTHandler) PublishCurtainControl(action string) error {
 	payload := map[string]string{
 		"action": action,
 	}
-	jsonPayload, _ := json.Marshal(payload)
 	log.Printf("[MQTT] Publishing to %s: action=%s", topic, action)
 
-	token := h.client.Publish(topic, 1, false, jsonPayload)
-	token.Wait()
-
-	if token.Error() == nil {
+	queued, err := h.publisher.Publish(topic, 1, payload)
+	if err != nil {
+		log.Printf("[MQTT] Curtain publish failed: %v", err)
+	} else if !queued {
 		log.Printf("[MQTT] Published: Curtain -> %s", action)
-	} else {
-		log.Printf("[MQTT] Curtain publish failed: %v", token.Error())
 	}
 
-	return token.Error()
+	return err
 }
 
 // ==================== SENSOR HANDLERS (INPUT) ====================
@@ -262,9 +363,13 @@ func (h *MQTTHandler) handleLight(client mqtt.Client, msg mqtt.Message) {
 	}
 
 	log.Printf("Light: %d Lux", data.Lux)
+	h.watchdog.seen("light")
 
 	// Cache for batch persistence
 	h.setLatestLight(data.Lux)
+	h.broadcast(websocket.EventLight, map[string]interface{}{"lux": data.Lux})
+	h.automation.OnReading("light", float64(data.Lux))
+	h.webhooks.OnReading("light", float64(data.Lux))
 }
 
 // IMPROVED GAS HANDLER WITH MOVING AVERAGE
@@ -279,6 +384,7 @@ func (h *MQTTHandler) handleGas(client mqtt.Client, msg mqtt.Message) {
 	}
 
 	log.Printf("Gas: %d PPM (raw)", data.PPM)
+	h.watchdog.seen("gas")
 
 	status := "normal"
 	if data.PPM > 200 {
@@ -288,6 +394,15 @@ func (h *MQTTHandler) handleGas(client mqtt.Client, msg mqtt.Message) {
 		status = "danger"
 	}
 
+	h.broadcast(websocket.EventGas, map[string]interface{}{
+		"gas_ppm": data.PPM,
+		"status":  status,
+	})
+	h.automation.OnReading("gas", float64(data.PPM))
+	h.webhooks.OnReading("gas", float64(data.PPM))
+	h.gasAlert.OnReading(data.PPM)
+	h.notifyGasLevel(status, data.PPM)
+
 	// Danger langsung disimpan; lainnya dibatch (disimpan saat flush 1 menit)
 	if status == "danger" {
 		go func(ppm int) {
@@ -302,6 +417,28 @@ func (h *MQTTHandler) handleGas(client mqtt.Client, msg mqtt.Message) {
 	}
 }
 
+var gasLevelRank = map[string]int{"normal": 0, "warning": 1, "danger": 2}
+
+// notifyGasLevel notifies when the gas level rises (normal → warning → danger)
+func (h *MQTTHandler) notifyGasLevel(status string, ppm int) {
+	h.gasReadingMutex.Lock()
+	previous := h.lastGasLevel
+	h.lastGasLevel = status
+	h.gasReadingMutex.Unlock()
+
+	if gasLevelRank[status] <= gasLevelRank[previous] {
+		return
+	}
+
+	title := "Gas Warning"
+	if status == "danger" {
+		title = "Gas Danger"
+	}
+	// Throttled so a reading hovering around a threshold doesn't spam
+	go h.notifSvc.NotifyThrottled("gas_"+status, 5*time.Minute, models.NotifGas, title,
+		fmt.Sprintf("Gas level %s: %d PPM", status, ppm))
+}
+
 func (h *MQTTHandler) handleTemperature(client mqtt.Client, msg mqtt.Message) {
 	log.Printf("[MQTT] Received on %s: %s", msg.Topic(), string(msg.Payload()))
 
@@ -315,7 +452,11 @@ func (h *MQTTHandler) handleTemperature(client mqtt.Client, msg mqtt.Message) {
 	}
 
 	log.Printf("[MQTT] Temperature: %.1f°C", data.Temperature)
+	h.watchdog.seen("temperature")
 	h.setLatestTemperature(data.Temperature)
+	h.broadcast(websocket.EventTemperature, map[string]interface{}{"temperature": data.Temperature})
+	h.automation.OnReading("temperature", data.Temperature)
+	h.webhooks.OnReading("temperature", data.Temperature)
 }
 
 func (h *MQTTHandler) handleHumidity(client mqtt.Client, msg mqtt.Message) {
@@ -329,21 +470,29 @@ func (h *MQTTHandler) handleHumidity(client mqtt.Client, msg mqtt.Message) {
 	}
 
 	log.Printf("Humidity: %.1f%%", data.Humidity)
+	h.watchdog.seen("humidity")
 	h.setLatestHumidity(data.Humidity)
+	h.broadcast(websocket.EventHumidity, map[string]interface{}{"humidity": data.Humidity})
+	h.automation.OnReading("humidity", data.Humidity)
+	h.webhooks.OnReading("humidity", data.Humidity)
 }
 
 // ==================== DEVICE STATUS HANDLERS ====================
 
 func (h *MQTTHandler) handleLampStatus(client mqtt.Client, msg mqtt.Message) {
 	var req struct {
-		Status string `json:"status"`
-		Mode   string `json:"mode"`
+		Status        string `json:"status"`
+		Mode          string `json:"mode"`
+		CorrelationID string `json:"correlation_id"`
+		Error         string `json:"error"`
 	}
 	if err := json.Unmarshal(msg.Payload(), &req); err != nil {
 		log.Printf("[ERROR] JSON Parse Lamp Failed: %v | Payload: %s", err, string(msg.Payload()))
 		return
 	}
 
+	change := h.stateChange(h.controller.Confirm("lamp", req.Status, "", req.CorrelationID, req.Error), req.Status, req.Mode, req.CorrelationID)
+
 	// Get previous mode from database
 	lastLamp, err := h.lampSvc.GetLatest()
 	previousMode := "auto"
@@ -377,17 +526,31 @@ func (h *MQTTHandler) handleLampStatus(client mqtt.Client, msg mqtt.Message) {
 		log.Printf("Gas: FORCED 0 PPM (Lamp ON - stabilization period)")
 	}
 
+	h.broadcast(websocket.EventLampStatus, map[string]interface{}{
+		"status": req.Status,
+		"mode":   req.Mode,
+	})
+
 	// Save to database when there is any change (status or mode)
 	if modeChanged || statusChanged {
-		go h.lampSvc.ProcessLamp(req.Status, req.Mode)
+		go h.lampSvc.ProcessLamp(change)
 		log.Printf("Lamp: %s (mode: %s)", req.Status, req.Mode)
+		h.webhooks.Publish(models.EventLampState, map[string]interface{}{
+			"status":          req.Status,
+			"mode":            req.Mode,
+			"previous_status": prevStatus,
+			"previous_mode":   previousMode,
+			"correlation_id":  req.CorrelationID,
+		})
 	}
 }
 
 func (h *MQTTHandler) handleDoorStatus(client mqtt.Client, msg mqtt.Message) {
 	var req struct {
-		Status string `json:"status"`
-		Method string `json:"method"`
+		Status        string `json:"status"`
+		Method        string `json:"method"`
+		CorrelationID string `json:"correlation_id"`
+		Error         string `json:"error"`
 	}
 	if err := json.Unmarshal(msg.Payload(), &req); err != nil {
 		log.Printf("[ERROR] JSON Parse Door Failed: %v | Payload: %s", err, string(msg.Payload()))
@@ -401,25 +564,61 @@ func (h *MQTTHandler) handleDoorStatus(client mqtt.Client, msg mqtt.Message) {
 	if req.Method == "app_button" {
 		req.Method = "remote"
 	}
+	if req.Method == "face_recognition" {
+		req.Method = "face"
+	}
+
+	// A confirmed remote command tells us who actually opened the door
+	var userID *uint
+	commanded := false
+	if cmd := h.controller.Confirm("door", req.Status, req.Method, req.CorrelationID, req.Error); cmd != nil && cmd.Status == "confirmed" {
+		userID = cmd.UserID
+		commanded = true
+		// Firmware reports backend commands as "remote"; the command knows the real
+		// method (pin, face, fingerprint, auto_relock, auto, ...)
+		if cmd.Method != "" {
+			req.Method = cmd.Method
+		}
+	}
 
-	go h.doorSvc.ProcessDoor(req.Status, req.Method, nil)
+	go h.doorSvc.ProcessDoor(req.Status, req.Method, userID)
 
-	if req.Status == "unlocked" {
+	h.broadcast(websocket.EventDoorStatus, map[string]interface{}{
+		"status": req.Status,
+		"method": req.Method,
+	})
+	h.webhooks.Publish(models.EventDoorState, map[string]interface{}{
+		"status":         req.Status,
+		"method":         req.Method,
+		"user_id":        userID,
+		"correlation_id": req.CorrelationID,
+	})
+
+	switch req.Status {
+	case "unlocked":
 		log.Printf("Door Access: %s", req.Method)
+		h.alarm.OnDoorUnlocked(req.Method, commanded)
+		h.relock.OnUnlocked(req.Method)
+	case "locked":
+		h.relock.OnLocked()
 	}
 }
 
 func (h *MQTTHandler) handleCurtainStatus(client mqtt.Client, msg mqtt.Message) {
 	var req struct {
-		Status   string `json:"status"`
-		Mode     string `json:"mode"`
-		Position int    `json:"position"`
+		Status        string `json:"status"`
+		Mode          string `json:"mode"`
+		Position      int    `json:"position"`
+		CorrelationID string `json:"correlation_id"`
+		Error         string `json:"error"`
 	}
 	if err := json.Unmarshal(msg.Payload(), &req); err != nil {
 		log.Printf("[ERROR] JSON Parse Curtain Failed: %v | Payload: %s", err, string(msg.Payload()))
 		return
 	}
 
+	change := h.stateChange(h.controller.Confirm("curtain", req.Status, "", req.CorrelationID, req.Error), req.Status, req.Mode, req.CorrelationID)
+
 	// Update in-memory state and detect changes without DB dependency
 	h.curtainMutex.Lock()
 	prevStatus := h.lastCurtainState
@@ -432,84 +631,280 @@ func (h *MQTTHandler) handleCurtainStatus(client mqtt.Client, msg mqtt.Message)
 	statusChanged := prevStatus != req.Status
 	modeChanged := prevMode != req.Mode
 
-	go h.curtainSvc.ProcessCurtain(req.Status, req.Mode)
+	go h.curtainSvc.ProcessCurtain(change)
 
-	// Only log if something actually changed
+	h.broadcast(websocket.EventCurtainStatus, map[string]interface{}{
+		"status":   req.Status,
+		"mode":     req.Mode,
+		"position": req.Position,
+	})
+
+	// Only log (and notify webhooks) if something actually changed
 	if statusChanged || modeChanged {
 		log.Printf("Curtain: %s (mode: %s)", req.Status, req.Mode)
+		h.webhooks.Publish(models.EventCurtainState, map[string]interface{}{
+			"status":          req.Status,
+			"mode":            req.Mode,
+			"position":        req.Position,
+			"previous_status": prevStatus,
+			"previous_mode":   prevMode,
+			"correlation_id":  req.CorrelationID,
+		})
 	}
 }
 
+// stateChange attributes a lamp/curtain report to the command it confirms;
+// anything else was changed on the device itself
+func (h *MQTTHandler) stateChange(cmd *models.DeviceCommand, status, mode, correlationID string) models.DeviceStateChange {
+	change := models.DeviceStateChange{
+		Status:        status,
+		Mode:          mode,
+		Source:        models.DeviceStateSourceDevice,
+		CorrelationID: correlationID,
+	}
+	if cmd != nil && cmd.Status == "confirmed" {
+		change.Source = cmd.Source
+		change.SourceID = cmd.SourceID
+		change.UserID = cmd.UserID
+	}
+	return change
+}
+
+// handleBuzzerStatus - ESP32 reports the buzzer state ({"status":"on"} or raw "on"/"off")
+func (h *MQTTHandler) handleBuzzerStatus(client mqtt.Client, msg mqtt.Message) {
+	var req struct {
+		Status string `json:"status"`
+	}
+	payload := strings.TrimSpace(string(msg.Payload()))
+	if err := json.Unmarshal([]byte(payload), &req); err != nil {
+		req.Status = strings.Trim(payload, `"`)
+	}
+	req.Status = strings.ToLower(req.Status)
+	if req.Status != "on" && req.Status != "off" {
+		log.Printf("[ERROR] Invalid buzzer status payload: %s", payload)
+		return
+	}
+
+	h.buzzerMutex.Lock()
+	changed := h.lastBuzzerState != req.Status
+	h.lastBuzzerState = req.Status
+	h.buzzerMutex.Unlock()
+
+	if !changed {
+		return
+	}
+
+	entry, err := h.buzzerSvc.OnStateReport(req.Status)
+	if err != nil {
+		log.Printf("[ERROR] Failed to save buzzer state: %v", err)
+	}
+	log.Printf("Buzzer: %s", req.Status)
+
+	event := map[string]interface{}{"status": req.Status}
+	if entry != nil {
+		event["source"] = entry.Source
+		event["reason"] = entry.Reason
+		event["user_id"] = entry.UserID
+	}
+	h.broadcast(websocket.EventBuzzerStatus, event)
+}
+
 func (h *MQTTHandler) handleDebug(client mqtt.Client, msg mqtt.Message) {
 	// Debug telemetry disabled for cleaner output
-	// Data still received and processed by WebSocket
+	// Sensor/device events are streamed to WebSocket clients by their own handlers
 }
 
 // ==================== PIN VERIFICATION HANDLER ====================
 
-// handlePinVerification -
+// handlePinVerification - Verify keypad PIN with attempt limiting and unlock the door
 func (h *MQTTHandler) handlePinVerification(client mqtt.Client, msg mqtt.Message) {
 	var req struct {
-		Pin string `json:"pin"`
+		Pin      string `json:"pin"`
+		DeviceID string `json:"device_id"`
 	}
 
 	if err := json.Unmarshal(msg.Payload(), &req); err != nil {
-		h.publishPinVerificationResponse(false, "Invalid format")
+		h.publishPinVerificationResponse(&models.PinVerifyResult{Message: "Invalid format"})
 		return
 	}
 
-	// Get universal PIN from database
-	pinData, err := h.pinSvc.GetUniversalPin()
+	// device_id is unauthenticated, so every keypad message shares one counter
+	result, err := h.pinSvc.VerifyPin("keypad", req.Pin)
 	if err != nil {
-		log.Printf("[ERROR] Failed to retrieve PIN from database: %v", err)
-		h.publishPinVerificationResponse(false, "Database error")
+		log.Printf("[ERROR] PIN verification failed: %v", err)
+		h.publishPinVerificationResponse(&models.PinVerifyResult{Message: "Database error"})
 		return
 	}
 
-	// Verify PIN
-	if req.Pin != pinData.UniversalPin {
-		log.Println("Invalid PIN")
-		h.publishPinVerificationResponse(false, "Invalid PIN")
+	if !result.Valid {
+		if result.Locked {
+			log.Printf("Keypad locked (%ds)", result.LockoutSeconds)
+		} else {
+			log.Printf("Invalid PIN from %q (%d attempts left)", req.DeviceID, result.RemainingAttempts)
+		}
+		h.publishPinVerificationResponse(result)
 		return
 	}
 
 	log.Println("Valid PIN")
 
 	// Send unlock command via MQTT
-	h.PublishDoorControl("unlock")
+	if err := h.unlockDoor("pin", result.UserID); err != nil {
+		result.Message = "PIN verified, but the door could not be unlocked"
+		h.publishPinVerificationResponse(result)
+		return
+	}
 
-	// Save access log to database (async)
-	go h.doorSvc.ProcessDoor("unlocked", "pin", nil)
+	// Door status and auto-relock are recorded once by handleDoorStatus on confirmation
+	// (access log already written by PinService)
 
 	// Send success response to ESP32
-	h.publishPinVerificationResponse(true, "PIN verified, door unlocked")
+	h.publishPinVerificationResponse(result)
 }
 
 // publishPinVerificationResponse - Send PIN verification result back to ESP32
-func (h *MQTTHandler) publishPinVerificationResponse(valid bool, message string) {
+func (h *MQTTHandler) publishPinVerificationResponse(result *models.PinVerifyResult) {
 	topic := "iotcihuy/home/door/verify/response"
 
 	payload := map[string]interface{}{
-		"valid":   valid,
-		"message": message,
+		"valid":              result.Valid,
+		"message":            result.Message,
+		"remaining_attempts": result.RemainingAttempts,
+		"locked":             result.Locked,
+		"lockout_seconds":    result.LockoutSeconds,
+	}
+	if result.GuestLabel != "" {
+		payload["guest_label"] = result.GuestLabel
+	}
+
+	// A late verify response is useless to the keypad, so it is never queued
+	if err := h.publisher.PublishDirect(topic, 1, payload); err != nil {
+		log.Printf("[MQTT] PIN response publish failed: %v", err)
 	}
 
-	jsonPayload, _ := json.Marshal(payload)
+	h.broadcast(websocket.EventPinVerification, payload)
+}
+
+// ==================== FINGERPRINT HANDLERS ====================
+
+// handleFingerprintMatch - The sensor matched (or failed to match) a finger; map the slot to a user and unlock
+func (h *MQTTHandler) handleFingerprintMatch(client mqtt.Client, msg mqtt.Message) {
+	var match models.FingerprintMatch
+	if err := json.Unmarshal(msg.Payload(), &match); err != nil {
+		h.publishFingerprintResponse(&models.FingerprintVerifyResult{Message: "Invalid format"})
+		return
+	}
+
+	result, err := h.fingerSvc.VerifyMatch(match)
+	if err != nil {
+		log.Printf("[ERROR] Fingerprint verification failed: %v", err)
+		h.publishFingerprintResponse(&models.FingerprintVerifyResult{Message: "Database error"})
+		return
+	}
 
-	token := h.client.Publish(topic, 1, false, jsonPayload)
-	token.Wait()
+	if !result.Valid {
+		log.Printf("Fingerprint rejected: %s", result.Message)
+		h.publishFingerprintResponse(result)
+		return
+	}
+
+	// Send unlock command via MQTT
+	if err := h.unlockDoor("fingerprint", result.UserID); err != nil {
+		result.Message = "Fingerprint verified, but the door could not be unlocked"
+		h.publishFingerprintResponse(result)
+		return
+	}
+
+	// Door status and auto-relock are recorded once by handleDoorStatus on confirmation
+	// (access log already written by FingerprintService)
+	h.publishFingerprintResponse(result)
+}
+
+// publishFingerprintResponse - Send the verification result back to the door unit
+func (h *MQTTHandler) publishFingerprintResponse(result *models.FingerprintVerifyResult) {
+	topic := "iotcihuy/home/fingerprint/match/response"
+
+	// Same as the PIN response: a late answer is useless, never queue it
+	if err := h.publisher.PublishDirect(topic, 1, result); err != nil {
+		log.Printf("[MQTT] Fingerprint response publish failed: %v", err)
+	}
+
+	h.broadcast(websocket.EventFingerprintMatch, result)
+}
+
+// handleFingerprintEnrollResult - The sensor finished (or aborted) an enrollment started from the API
+func (h *MQTTHandler) handleFingerprintEnrollResult(client mqtt.Client, msg mqtt.Message) {
+	var result models.FingerprintEnrollResult
+	if err := json.Unmarshal(msg.Payload(), &result); err != nil {
+		log.Printf("[FINGERPRINT] Invalid enroll result payload: %v", err)
+		return
+	}
+
+	if _, err := h.fingerSvc.OnEnrollResult(result); err != nil {
+		log.Printf("[FINGERPRINT] Enroll result %s ignored: %v", result.EnrollmentID, err)
+	}
+}
+
+// PublishFingerprintEnroll - Ask the door unit to record a finger into templateIndex
+func (h *MQTTHandler) PublishFingerprintEnroll(enrollmentID string, templateIndex int) error {
+	topic := "iotcihuy/home/fingerprint/enroll"
+	payload := map[string]interface{}{
+		"command":        "enroll",
+		"enrollment_id":  enrollmentID,
+		"template_index": templateIndex,
+	}
+
+	// Enrollment needs someone standing at the door, so it is never queued
+	if err := h.publisher.PublishDirect(topic, 1, payload); err != nil {
+		log.Printf("[MQTT] Publish failed: %s | Error: %v", topic, err)
+		return err
+	}
+	log.Printf("[MQTT] Published: Fingerprint enroll (slot %d)", templateIndex)
+	return nil
+}
+
+// PublishFingerprintDelete - Wipe a template slot on the door unit
+func (h *MQTTHandler) PublishFingerprintDelete(templateIndex int) error {
+	topic := "iotcihuy/home/fingerprint/delete"
+	payload := map[string]interface{}{
+		"command":        "delete",
+		"template_index": templateIndex,
+	}
+
+	queued, err := h.publisher.Publish(topic, 1, payload)
+	if err != nil {
+		log.Printf("[MQTT] Publish failed: %s | Error: %v", topic, err)
+	} else if queued {
+		log.Printf("[MQTT] Fingerprint delete (slot %d) queued until reconnect", templateIndex)
+	} else {
+		log.Printf("[MQTT] Published: Fingerprint delete (slot %d)", templateIndex)
+	}
+	return err
 }
 
 // ==================== CAMERA HANDLER ====================
 
 func (h *MQTTHandler) handleCameraIP(client mqtt.Client, msg mqtt.Message) {
-	// IP diterima dalam bentuk raw string dari ESP32
-	ipAddress := string(msg.Payload())
-
-	// Log IP ke terminal agar mudah di-copy
-	log.Printf("------------------------------------------------")
-	log.Printf("[CAMERA] ESP32-CAM ONLINE / IP CHANGED")
-	log.Printf("[CAMERA] Stream URL : http://%s", ipAddress)
-	log.Printf("[CAMERA] Still Image: http://%s/capture", ipAddress)
-	log.Printf("------------------------------------------------")
-}
\ No newline at end of file
+	// Firmware baru kirim JSON {"device_id","name","ip","stream_port"},
+	// firmware lama kirim IP sebagai raw string
+	var ann models.CameraAnnouncement
+	payload := strings.TrimSpace(string(msg.Payload()))
+	if strings.HasPrefix(payload, "{") {
+		if err := json.Unmarshal([]byte(payload), &ann); err != nil {
+			log.Printf("[CAMERA] Invalid announcement payload: %v", err)
+			return
+		}
+	} else {
+		ann.IP = payload
+	}
+
+	camera, err := h.cameras.Announce(ann)
+	if err != nil {
+		log.Printf("[CAMERA] Failed to register camera (%q): %v", payload, err)
+		return
+	}
+
+	log.Printf("[CAMERA] %s online at %s (camera id %d, proxied via /api/camera/%d/stream)",
+		camera.DeviceID, camera.IPAddress, camera.ID, camera.ID)
+	h.broadcast(websocket.EventCameraStatus, camera)
+}
@@ -0,0 +1,264 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+const (
+	defaultQueueSize   = 100
+	defaultQueueMaxAge = 2 * time.Minute
+	publishTimeout     = 10 * time.Second
+)
+
+var (
+	ErrQueueFull    = errors.New("MQTT offline and command queue is full")
+	ErrNotConnected = errors.New("MQTT broker not connected")
+	ErrQueueExpired = errors.New("expired in offline queue")
+)
+
+type queuedMessage struct {
+	topic    string
+	qos      byte
+	payload  []byte
+	queuedAt time.Time
+
+	onFlushed func(sent bool) // optional, see PublishTracked
+}
+
+// Publisher wraps the paho client for outgoing commands.
+// While the broker is unreachable, commands go to a bounded in-memory queue
+// and are flushed on reconnect. Entries older than maxAge are discarded.
+// Door unlocks bypass the queue entirely (PublishDirect) so a stale unlock
+// is never executed after the fact.
+type Publisher struct {
+	client mqtt.Client
+
+	mu       sync.Mutex
+	queue    []queuedMessage
+	maxQueue int
+	maxAge   time.Duration
+	dropped  int
+	flushing bool // OnConnect is draining the queue
+
+	connected          bool
+	lastConnectedAt    *time.Time
+	lastDisconnectedAt *time.Time
+	lastError          string
+}
+
+// PublisherStatus is exposed on /health
+type PublisherStatus struct {
+	Connected          bool       `json:"connected"`
+	QueueLength        int        `json:"queue_length"`
+	QueueCapacity      int        `json:"queue_capacity"`
+	Dropped            int        `json:"dropped"`
+	LastConnectedAt    *time.Time `json:"last_connected_at,omitempty"`
+	LastDisconnectedAt *time.Time `json:"last_disconnected_at,omitempty"`
+	LastError          string     `json:"last_error,omitempty"`
+}
+
+func NewPublisher(client mqtt.Client, maxQueue int, maxAge time.Duration) *Publisher {
+	if maxQueue <= 0 {
+		maxQueue = defaultQueueSize
+	}
+	if maxAge <= 0 {
+		maxAge = defaultQueueMaxAge
+	}
+	return &Publisher{
+		client:   client,
+		maxQueue: maxQueue,
+		maxAge:   maxAge,
+	}
+}
+
+// Publish sends payload (marshalled to JSON unless already []byte).
+// queued=true means the broker is offline and the message will be sent on reconnect.
+func (p *Publisher) Publish(topic string, qos byte, payload interface{}) (queued bool, err error) {
+	return p.PublishTracked(topic, qos, payload, nil)
+}
+
+// PublishTracked is Publish with a callback for queued messages: onFlushed(true)
+// once the message reaches the broker after reconnect, onFlushed(false) when it
+// expired in the queue. It is not called when the message was sent right away.
+func (p *Publisher) PublishTracked(topic string, qos byte, payload interface{}, onFlushed func(sent bool)) (queued bool, err error) {
+	data, err := encodePayload(payload)
+	if err != nil {
+		return false, err
+	}
+
+	// While the queue is flushing, new commands go behind it to keep their order
+	p.mu.Lock()
+	direct := p.connected && !p.flushing && p.client.IsConnectionOpen()
+	p.mu.Unlock()
+
+	if direct {
+		if err := p.send(topic, qos, data); err == nil {
+			return false, nil
+		} else if p.client.IsConnectionOpen() {
+			// Broker is up but rejected/timed out: report instead of queueing
+			return false, err
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.queue) >= p.maxQueue {
+		p.dropped++
+		log.Printf("[MQTT] Queue full (%d), dropping command for %s", p.maxQueue, topic)
+		return false, ErrQueueFull
+	}
+
+	p.queue = append(p.queue, queuedMessage{topic: topic, qos: qos, payload: data, queuedAt: time.Now(), onFlushed: onFlushed})
+	if p.connected {
+		log.Printf("[MQTT] Flush in progress, queued command for %s (%d in queue)", topic, len(p.queue))
+	} else {
+		log.Printf("[MQTT] Offline, queued command for %s (%d in queue)", topic, len(p.queue))
+	}
+
+	// Reconnected while we were queueing: nobody else will flush it
+	if p.connected && !p.flushing {
+		p.flushing = true
+		go p.flush()
+	}
+	return true, nil
+}
+
+// PublishDirect never queues; used for replies that are useless once late (e.g. PIN result)
+// and for commands that must not run late (door unlock)
+func (p *Publisher) PublishDirect(topic string, qos byte, payload interface{}) error {
+	data, err := encodePayload(payload)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	connected := p.connected && p.client.IsConnectionOpen()
+	p.mu.Unlock()
+
+	if !connected {
+		return ErrNotConnected
+	}
+	return p.send(topic, qos, data)
+}
+
+// OnConnect marks the connection up and flushes queued commands in order
+func (p *Publisher) OnConnect() {
+	p.mu.Lock()
+	now := time.Now()
+	p.connected = true
+	p.lastConnectedAt = &now
+	p.lastError = ""
+
+	if len(p.queue) == 0 || p.flushing {
+		p.mu.Unlock()
+		return
+	}
+	p.flushing = true
+	p.mu.Unlock()
+
+	p.flush()
+}
+
+// flush sends queued commands one by one without holding p.mu across the send.
+// The caller sets p.flushing; flush clears it when done.
+func (p *Publisher) flush() {
+	sent, expired := 0, 0
+	for {
+		p.mu.Lock()
+		if len(p.queue) == 0 || !p.connected {
+			p.flushing = false
+			remaining := len(p.queue)
+			p.mu.Unlock()
+			log.Printf("[MQTT] Flushed offline queue: %d sent, %d expired, %d remaining", sent, expired, remaining)
+			return
+		}
+		msg := p.queue[0]
+		p.queue = p.queue[1:]
+		if time.Since(msg.queuedAt) > p.maxAge {
+			p.dropped++
+			expired++
+			p.mu.Unlock()
+			if msg.onFlushed != nil {
+				msg.onFlushed(false)
+			}
+			continue
+		}
+		p.mu.Unlock()
+
+		if err := p.send(msg.topic, msg.qos, msg.payload); err != nil {
+			// Connection dropped again: keep the rest for the next reconnect
+			p.mu.Lock()
+			p.queue = append([]queuedMessage{msg}, p.queue...)
+			p.flushing = false
+			remaining := len(p.queue)
+			p.mu.Unlock()
+			log.Printf("[MQTT] Flushed offline queue: %d sent, %d expired, %d remaining", sent, expired, remaining)
+			return
+		}
+		sent++
+		if msg.onFlushed != nil {
+			msg.onFlushed(true)
+		}
+	}
+}
+
+// OnConnectionLost marks the connection down so new commands are queued
+func (p *Publisher) OnConnectionLost(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	p.connected = false
+	p.lastDisconnectedAt = &now
+	if err != nil {
+		p.lastError = err.Error()
+	}
+}
+
+func (p *Publisher) IsConnected() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.connected && p.client.IsConnectionOpen()
+}
+
+func (p *Publisher) Status() PublisherStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return PublisherStatus{
+		Connected:          p.connected && p.client.IsConnectionOpen(),
+		QueueLength:        len(p.queue),
+		QueueCapacity:      p.maxQueue,
+		Dropped:            p.dropped,
+		LastConnectedAt:    p.lastConnectedAt,
+		LastDisconnectedAt: p.lastDisconnectedAt,
+		LastError:          p.lastError,
+	}
+}
+
+// send blocks up to publishTimeout, so it must be called without p.mu held
+func (p *Publisher) send(topic string, qos byte, data []byte) error {
+	token := p.client.Publish(topic, qos, false, data)
+	if !token.WaitTimeout(publishTimeout) {
+		return errors.New("publish timed out")
+	}
+	return token.Error()
+}
+
+func encodePayload(payload interface{}) ([]byte, error) {
+	switch v := payload.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	default:
+		return json.Marshal(v)
+	}
+}
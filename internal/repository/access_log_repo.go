@@ -2,6 +2,7 @@ package repository
 
 import (
 	"smarthome-backend/database/models"
+	"strings"
 
 	"gorm.io/gorm"
 )
@@ -11,6 +12,9 @@ type AccessLogRepository interface {
 	GetAll(limit int) ([]models.AccessLog, error)
 	GetByUserID(userID uint, limit int) ([]models.AccessLog, error)
 	GetByStatus(status string, limit int) ([]models.AccessLog, error)
+	EnsureGuestLabelColumn() error
+	EnsureCaptureColumn() error
+	EnsureMethodEnum() error
 }
 
 type accessLogRepository struct {
@@ -42,3 +46,38 @@ func (r *accessLogRepository) GetByStatus(status string, limit int) ([]models.Ac
 	err := r.db.Preload("User").Where("status = ?", status).Order("timestamp DESC").Limit(limit).Find(&logs).Error
 	return logs, err
 }
+
+// EnsureGuestLabelColumn - Add guest_label to access_logs created before guest codes existed
+func (r *accessLogRepository) EnsureGuestLabelColumn() error {
+	migrator := r.db.Migrator()
+	if migrator.HasColumn(&models.AccessLog{}, "guest_label") {
+		return nil
+	}
+	return migrator.AddColumn(&models.AccessLog{}, "GuestLabel")
+}
+
+// EnsureCaptureColumn - Add capture_id to access_logs created before captures were stored
+func (r *accessLogRepository) EnsureCaptureColumn() error {
+	migrator := r.db.Migrator()
+	if migrator.HasColumn(&models.AccessLog{}, "capture_id") {
+		return nil
+	}
+	return migrator.AddColumn(&models.AccessLog{}, "CaptureID")
+}
+
+// EnsureMethodEnum - Add 'fingerprint' to access_logs.method on databases created before fingerprint unlock
+func (r *accessLogRepository) EnsureMethodEnum() error {
+	columns, err := r.db.Migrator().ColumnTypes(&models.AccessLog{})
+	if err != nil {
+		return err
+	}
+	for _, column := range columns {
+		if column.Name() != "method" {
+			continue
+		}
+		if columnType, ok := column.ColumnType(); ok && strings.Contains(columnType, "fingerprint") {
+			return nil
+		}
+	}
+	return r.db.Exec("ALTER TABLE access_logs MODIFY method ENUM('face','pin','remote','fingerprint','unknown') DEFAULT 'unknown'").Error
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"smarthome-backend/database/models"
+
+	"gorm.io/gorm"
+)
+
+type AlarmRepository interface {
+	Create(event *models.AlarmEvent) error
+	GetLatest() (*models.AlarmEvent, error)
+	GetLatestArmed() (*models.AlarmEvent, error)
+	GetByID(id uint) (*models.AlarmEvent, error)
+	GetEvents(limit int) ([]models.AlarmEvent, error)
+}
+
+type alarmRepository struct {
+	db *gorm.DB
+}
+
+func NewAlarmRepository(db *gorm.DB) AlarmRepository {
+	return &alarmRepository{db: db}
+}
+
+func (r *alarmRepository) Create(event *models.AlarmEvent) error {
+	return r.db.Create(event).Error
+}
+
+func (r *alarmRepository) GetLatest() (*models.AlarmEvent, error) {
+	var event models.AlarmEvent
+	err := r.db.Order("id DESC").First(&event).Error
+	return &event, err
+}
+
+// GetLatestArmed - Last armed_home/armed_away event (restores the mode behind "triggered")
+func (r *alarmRepository) GetLatestArmed() (*models.AlarmEvent, error) {
+	var event models.AlarmEvent
+	err := r.db.Where("state IN ?", []string{models.AlarmArmedHome, models.AlarmArmedAway}).
+		Order("id DESC").First(&event).Error
+	return &event, err
+}
+
+func (r *alarmRepository) GetByID(id uint) (*models.AlarmEvent, error) {
+	var event models.AlarmEvent
+	err := r.db.First(&event, id).Error
+	return &event, err
+}
+
+// GetEvents - History without snapshot images (fetch one event for the image)
+func (r *alarmRepository) GetEvents(limit int) ([]models.AlarmEvent, error) {
+	var events []models.AlarmEvent
+	err := r.db.Omit("image_base64").Order("id DESC").Limit(limit).Find(&events).Error
+	return events, err
+}
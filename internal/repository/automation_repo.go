@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"smarthome-backend/database/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AutomationRepository interface {
+	Create(rule *models.AutomationRule) error
+	Update(rule *models.AutomationRule) error
+	Delete(id uint) error
+	GetByID(id uint) (*models.AutomationRule, error)
+	GetAll() ([]models.AutomationRule, error)
+	GetEnabled() ([]models.AutomationRule, error)
+	SetEnabled(id uint, enabled bool) error
+	MarkFired(id uint, firedAt time.Time) error
+
+	CreateLog(entry *models.AutomationRuleLog) error
+	GetLogs(ruleID *uint, limit int) ([]models.AutomationRuleLog, error)
+}
+
+type automationRepository struct {
+	db *gorm.DB
+}
+
+func NewAutomationRepository(db *gorm.DB) AutomationRepository {
+	return &automationRepository{db: db}
+}
+
+func (r *automationRepository) Create(rule *models.AutomationRule) error {
+	return r.db.Create(rule).Error
+}
+
+// Update - Save editable fields (last_fired_at is owned by the engine)
+func (r *automationRepository) Update(rule *models.AutomationRule) error {
+	return r.db.Model(rule).Select("name", "enabled", "conditions", "actions", "cooldown_seconds").Updates(rule).Error
+}
+
+func (r *automationRepository) Delete(id uint) error {
+	return r.db.Delete(&models.AutomationRule{}, id).Error
+}
+
+func (r *automationRepository) GetByID(id uint) (*models.AutomationRule, error) {
+	var rule models.AutomationRule
+	err := r.db.First(&rule, id).Error
+	return &rule, err
+}
+
+func (r *automationRepository) GetAll() ([]models.AutomationRule, error) {
+	var rules []models.AutomationRule
+	err := r.db.Order("id ASC").Find(&rules).Error
+	return rules, err
+}
+
+func (r *automationRepository) GetEnabled() ([]models.AutomationRule, error) {
+	var rules []models.AutomationRule
+	err := r.db.Where("enabled = ?", true).Order("id ASC").Find(&rules).Error
+	return rules, err
+}
+
+func (r *automationRepository) SetEnabled(id uint, enabled bool) error {
+	return r.db.Model(&models.AutomationRule{}).Where("id = ?", id).Update("enabled", enabled).Error
+}
+
+func (r *automationRepository) MarkFired(id uint, firedAt time.Time) error {
+	return r.db.Model(&models.AutomationRule{}).Where("id = ?", id).UpdateColumn("last_fired_at", firedAt).Error
+}
+
+func (r *automationRepository) CreateLog(entry *models.AutomationRuleLog) error {
+	return r.db.Create(entry).Error
+}
+
+// GetLogs - Latest firings, for one rule or all rules
+func (r *automationRepository) GetLogs(ruleID *uint, limit int) ([]models.AutomationRuleLog, error) {
+	var logs []models.AutomationRuleLog
+	query := r.db.Order("fired_at DESC").Limit(limit)
+	if ruleID != nil {
+		query = query.Where("rule_id = ?", *ruleID)
+	}
+	err := query.Find(&logs).Error
+	return logs, err
+}
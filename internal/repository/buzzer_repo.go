@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"smarthome-backend/database/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type BuzzerRepository interface {
+	Create(entry *models.BuzzerLog) error
+	GetLatest() (*models.BuzzerLog, error)
+	GetPendingCommand(status string, since time.Time) (*models.BuzzerLog, error)
+	Confirm(id uint, at time.Time) error
+	MarkFailed(id uint, errMsg string) error
+	GetHistory(filter models.BuzzerLogFilter) ([]models.BuzzerLog, error)
+	EnsureColumns() error
+}
+
+type buzzerRepository struct {
+	db *gorm.DB
+}
+
+func NewBuzzerRepository(db *gorm.DB) BuzzerRepository {
+	return &buzzerRepository{db: db}
+}
+
+func (r *buzzerRepository) Create(entry *models.BuzzerLog) error {
+	return r.db.Create(entry).Error
+}
+
+func (r *buzzerRepository) GetLatest() (*models.BuzzerLog, error) {
+	var entry models.BuzzerLog
+	if err := r.db.Preload("User").Order("timestamp DESC, buzzer_id DESC").First(&entry).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// GetPendingCommand - newest unconfirmed backend command for status sent after since
+func (r *buzzerRepository) GetPendingCommand(status string, since time.Time) (*models.BuzzerLog, error) {
+	var entry models.BuzzerLog
+	err := r.db.
+		Where("status = ? AND confirmed_at IS NULL AND (error IS NULL OR error = '') AND source <> ? AND timestamp >= ?", status, models.BuzzerSourceDevice, since).
+		Order("timestamp DESC, buzzer_id DESC").
+		First(&entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *buzzerRepository) Confirm(id uint, at time.Time) error {
+	return r.db.Model(&models.BuzzerLog{}).Where("buzzer_id = ?", id).Update("confirmed_at", at).Error
+}
+
+func (r *buzzerRepository) MarkFailed(id uint, errMsg string) error {
+	return r.db.Model(&models.BuzzerLog{}).Where("buzzer_id = ?", id).Update("error", errMsg).Error
+}
+
+func (r *buzzerRepository) GetHistory(filter models.BuzzerLogFilter) ([]models.BuzzerLog, error) {
+	var entries []models.BuzzerLog
+	query := r.db.Preload("User")
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Source != "" {
+		query = query.Where("source = ?", filter.Source)
+	}
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.From != nil {
+		query = query.Where("timestamp >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("timestamp <= ?", *filter.To)
+	}
+	err := query.Order("timestamp DESC, buzzer_id DESC").Limit(filter.Limit).Find(&entries).Error
+	return entries, err
+}
+
+// EnsureColumns adds the columns introduced when buzzer commands started being logged
+func (r *buzzerRepository) EnsureColumns() error {
+	migrator := r.db.Migrator()
+	if !migrator.HasTable(&models.BuzzerLog{}) {
+		return migrator.CreateTable(&models.BuzzerLog{})
+	}
+	for _, field := range []string{"Source", "UserID", "ConfirmedAt", "Error"} {
+		if migrator.HasColumn(&models.BuzzerLog{}, field) {
+			continue
+		}
+		if err := migrator.AddColumn(&models.BuzzerLog{}, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"smarthome-backend/database/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type CameraCaptureRepository interface {
+	Create(capture *models.CameraCapture) error
+	GetByID(id uint) (*models.CameraCapture, error)
+	GetOlderThan(cutoff time.Time, limit int) ([]models.CameraCapture, error)
+	DeleteByIDs(ids []uint) error
+	CountByPath(imagePath string) (int64, error)
+	IsLinkedToUser(captureID, userID uint) (bool, error)
+	EnsureColumns() error
+}
+
+type cameraCaptureRepository struct {
+	db *gorm.DB
+}
+
+func NewCameraCaptureRepository(db *gorm.DB) CameraCaptureRepository {
+	return &cameraCaptureRepository{db: db}
+}
+
+func (r *cameraCaptureRepository) Create(capture *models.CameraCapture) error {
+	return r.db.Create(capture).Error
+}
+
+func (r *cameraCaptureRepository) GetByID(id uint) (*models.CameraCapture, error) {
+	var capture models.CameraCapture
+	if err := r.db.First(&capture, id).Error; err != nil {
+		return nil, err
+	}
+	return &capture, nil
+}
+
+func (r *cameraCaptureRepository) GetOlderThan(cutoff time.Time, limit int) ([]models.CameraCapture, error) {
+	var captures []models.CameraCapture
+	err := r.db.Where("timestamp < ?", cutoff).Order("capture_id ASC").Limit(limit).Find(&captures).Error
+	return captures, err
+}
+
+// DeleteByIDs removes the rows and unlinks them from access logs in one transaction
+func (r *cameraCaptureRepository) DeleteByIDs(ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.AccessLog{}).
+			Where("capture_id IN ?", ids).
+			Updates(map[string]interface{}{"capture_id": nil, "image_path": ""}).Error; err != nil {
+			return err
+		}
+		return tx.Where("capture_id IN ?", ids).Delete(&models.CameraCapture{}).Error
+	})
+}
+
+// CountByPath - identical frames share a file, so it may only go once no row uses it
+func (r *cameraCaptureRepository) CountByPath(imagePath string) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.CameraCapture{}).Where("image_path = ?", imagePath).Count(&count).Error
+	return count, err
+}
+
+// IsLinkedToUser reports whether the capture shows the user or backs one of their access logs
+func (r *cameraCaptureRepository) IsLinkedToUser(captureID, userID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.AccessLog{}).
+		Where("capture_id = ? AND user_id = ?", captureID, userID).
+		Count(&count).Error
+	if err != nil || count > 0 {
+		return count > 0, err
+	}
+	err = r.db.Model(&models.CameraCapture{}).
+		Where("capture_id = ? AND user_id = ?", captureID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// EnsureColumns adds the columns introduced when captures started being stored
+func (r *cameraCaptureRepository) EnsureColumns() error {
+	migrator := r.db.Migrator()
+	if !migrator.HasTable(&models.CameraCapture{}) {
+		return migrator.CreateTable(&models.CameraCapture{})
+	}
+	for _, field := range []string{"UserID", "ContentHash", "ContentType", "SizeBytes"} {
+		if migrator.HasColumn(&models.CameraCapture{}, field) {
+			continue
+		}
+		if err := migrator.AddColumn(&models.CameraCapture{}, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"smarthome-backend/database/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CameraRepository interface {
+	Upsert(camera *models.Camera) error
+	GetAll() ([]models.Camera, error)
+	GetByID(id uint) (*models.Camera, error)
+	GetByDeviceID(deviceID string) (*models.Camera, error)
+	EnsureTable() error
+}
+
+type cameraRepository struct {
+	db *gorm.DB
+}
+
+func NewCameraRepository(db *gorm.DB) CameraRepository {
+	return &cameraRepository{db: db}
+}
+
+// Upsert - a camera re-announcing itself updates its address and last-seen time
+func (r *cameraRepository) Upsert(camera *models.Camera) error {
+	columns := []string{"ip_address", "stream_port", "last_seen_at", "updated_at"}
+	if camera.Name != "" {
+		columns = append(columns, "name")
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "device_id"}},
+		DoUpdates: clause.AssignmentColumns(columns),
+	}).Create(camera).Error
+}
+
+func (r *cameraRepository) GetAll() ([]models.Camera, error) {
+	var cameras []models.Camera
+	err := r.db.Order("id ASC").Find(&cameras).Error
+	return cameras, err
+}
+
+func (r *cameraRepository) GetByID(id uint) (*models.Camera, error) {
+	var camera models.Camera
+	if err := r.db.First(&camera, id).Error; err != nil {
+		return nil, err
+	}
+	return &camera, nil
+}
+
+func (r *cameraRepository) GetByDeviceID(deviceID string) (*models.Camera, error) {
+	var camera models.Camera
+	if err := r.db.Where("device_id = ?", deviceID).First(&camera).Error; err != nil {
+		return nil, err
+	}
+	return &camera, nil
+}
+
+// EnsureTable creates cameras on databases set up before the registry existed
+func (r *cameraRepository) EnsureTable() error {
+	migrator := r.db.Migrator()
+	if migrator.HasTable(&models.Camera{}) {
+		return nil
+	}
+	return migrator.CreateTable(&models.Camera{})
+}
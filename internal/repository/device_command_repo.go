@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"smarthome-backend/database/models"
+
+	"gorm.io/gorm"
+)
+
+type DeviceCommandRepository interface {
+	Create(cmd *models.DeviceCommand) error
+	Update(cmd *models.DeviceCommand) error
+	GetByCorrelationID(correlationID string) (*models.DeviceCommand, error)
+	GetRecent(device string, limit int) ([]models.DeviceCommand, error)
+	ExpirePending() (int64, error)
+}
+
+type deviceCommandRepository struct {
+	db *gorm.DB
+}
+
+func NewDeviceCommandRepository(db *gorm.DB) DeviceCommandRepository {
+	return &deviceCommandRepository{db: db}
+}
+
+func (r *deviceCommandRepository) Create(cmd *models.DeviceCommand) error {
+	return r.db.Create(cmd).Error
+}
+
+func (r *deviceCommandRepository) Update(cmd *models.DeviceCommand) error {
+	return r.db.Model(&models.DeviceCommand{}).Where("id = ?", cmd.ID).Updates(map[string]interface{}{
+		"status":       cmd.Status,
+		"attempts":     cmd.Attempts,
+		"error":        cmd.Error,
+		"confirmed_at": cmd.ConfirmedAt,
+	}).Error
+}
+
+func (r *deviceCommandRepository) GetByCorrelationID(correlationID string) (*models.DeviceCommand, error) {
+	var cmd models.DeviceCommand
+	err := r.db.Where("correlation_id = ?", correlationID).First(&cmd).Error
+	return &cmd, err
+}
+
+// GetRecent - Latest commands, optionally filtered by device
+func (r *deviceCommandRepository) GetRecent(device string, limit int) ([]models.DeviceCommand, error) {
+	var cmds []models.DeviceCommand
+	query := r.db.Order("created_at DESC").Limit(limit)
+	if device != "" {
+		query = query.Where("device = ?", device)
+	}
+	err := query.Find(&cmds).Error
+	return cmds, err
+}
+
+// ExpirePending - Commands left pending by a previous process can never be confirmed
+func (r *deviceCommandRepository) ExpirePending() (int64, error) {
+	result := r.db.Model(&models.DeviceCommand{}).
+		Where("status = ?", "pending").
+		Updates(map[string]interface{}{"status": "timed_out", "error": "backend restarted"})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"fmt"
+	"smarthome-backend/database/models"
+
+	"gorm.io/gorm"
+)
+
+// Current-state projection of each device: the single lamp_status / curtain_status row
+var deviceStateProjections = map[string]struct{ table, idColumn string }{
+	"lamp":    {"lamp_status", "lamp_id"},
+	"curtain": {"curtain_status", "curtain_id"},
+}
+
+type DeviceStateRepository interface {
+	Append(event *models.DeviceStateEvent) (bool, error)
+	GetTimeline(filter models.DeviceStateFilter) ([]models.DeviceStateEvent, error)
+	EnsureTable() error
+}
+
+type deviceStateRepository struct {
+	db *gorm.DB
+}
+
+func NewDeviceStateRepository(db *gorm.DB) DeviceStateRepository {
+	return &deviceStateRepository{db: db}
+}
+
+// Append stores the event and moves the projection to it in one transaction.
+// Previous status/mode are taken from the projection; when neither changed
+// nothing is written and false is returned.
+func (r *deviceStateRepository) Append(event *models.DeviceStateEvent) (bool, error) {
+	projection, ok := deviceStateProjections[event.Device]
+	if !ok {
+		return false, fmt.Errorf("unknown device %q", event.Device)
+	}
+
+	appended := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var current struct {
+			ID     uint
+			Status string
+			Mode   string
+		}
+		query := fmt.Sprintf("SELECT %s AS id, status, mode FROM %s ORDER BY timestamp DESC, %s DESC LIMIT 1 FOR UPDATE",
+			projection.idColumn, projection.table, projection.idColumn)
+		if err := tx.Raw(query).Scan(&current).Error; err != nil {
+			return err
+		}
+		if current.ID != 0 && current.Status == event.Status && current.Mode == event.Mode {
+			return nil
+		}
+
+		event.PreviousStatus = current.Status
+		event.PreviousMode = current.Mode
+		if err := tx.Create(event).Error; err != nil {
+			return err
+		}
+
+		if current.ID == 0 {
+			query = fmt.Sprintf("INSERT INTO %s (status, mode, timestamp) VALUES (?, ?, ?)", projection.table)
+			if err := tx.Exec(query, event.Status, event.Mode, event.Timestamp).Error; err != nil {
+				return err
+			}
+		} else {
+			query = fmt.Sprintf("UPDATE %s SET status = ?, mode = ?, timestamp = ? WHERE %s = ?", projection.table, projection.idColumn)
+			if err := tx.Exec(query, event.Status, event.Mode, event.Timestamp, current.ID).Error; err != nil {
+				return err
+			}
+		}
+		appended = true
+		return nil
+	})
+	return appended, err
+}
+
+func (r *deviceStateRepository) GetTimeline(filter models.DeviceStateFilter) ([]models.DeviceStateEvent, error) {
+	var events []models.DeviceStateEvent
+	query := r.db.Preload("User").Where("device = ?", filter.Device)
+	if filter.Source != "" {
+		query = query.Where("source = ?", filter.Source)
+	}
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.From != nil {
+		query = query.Where("timestamp >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("timestamp <= ?", *filter.To)
+	}
+	err := query.Order("timestamp DESC, event_id DESC").Limit(filter.Limit).Find(&events).Error
+	return events, err
+}
+
+// EnsureTable creates device_state_events on databases set up before it was added to schema.sql
+func (r *deviceStateRepository) EnsureTable() error {
+	migrator := r.db.Migrator()
+	if migrator.HasTable(&models.DeviceStateEvent{}) {
+		return nil
+	}
+	return migrator.CreateTable(&models.DeviceStateEvent{})
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"smarthome-backend/database/models"
+	"strings"
 
 	"gorm.io/gorm"
 )
@@ -11,6 +12,7 @@ type DoorRepository interface {
 	Update(door *models.DoorStatus) error
 	GetLatest() (*models.DoorStatus, error)
 	GetHistory(limit int) ([]models.DoorStatus, error)
+	EnsureMethodEnum() error
 }
 
 type doorRepository struct {
@@ -65,3 +67,20 @@ func (r *doorRepository) GetHistory(limit int) ([]models.DoorStatus, error) {
 	err := r.db.Order("timestamp DESC").Limit(limit).Find(&doors).Error
 	return doors, err
 }
+
+// EnsureMethodEnum - Extend door_status.method on databases created before auto-relock / fingerprint
+func (r *doorRepository) EnsureMethodEnum() error {
+	columns, err := r.db.Migrator().ColumnTypes(&models.DoorStatus{})
+	if err != nil {
+		return err
+	}
+	for _, column := range columns {
+		if column.Name() != "method" {
+			continue
+		}
+		if columnType, ok := column.ColumnType(); ok && strings.Contains(columnType, "fingerprint") {
+			return nil
+		}
+	}
+	return r.db.Exec("ALTER TABLE door_status MODIFY method ENUM('face','pin','remote','fingerprint','auto','auto_relock') DEFAULT 'remote'").Error
+}
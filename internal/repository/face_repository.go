@@ -13,6 +13,13 @@ type FaceRepository interface {
 	GetRecentLogs(limit int) ([]models.FaceRecognitionLog, error)
 	GetUnresolvedAlerts() ([]models.FaceAlert, error)
 	ResolveAlert(id int) error
+
+	GetLogs(filter models.FaceLogFilter) ([]models.FaceRecognitionLog, error)
+	GetAlerts(filter models.FaceAlertFilter) ([]models.FaceAlert, error)
+	GetAlert(id int) (*models.FaceAlert, error)
+	ResolveAlerts(ids []int, resolvedBy *uint) (int64, error)
+
+	EnsureTables() error
 }
 
 type faceRepository struct {
@@ -46,5 +53,95 @@ func (r *faceRepository) GetUnresolvedAlerts() ([]models.FaceAlert, error) {
 }
 
 func (r *faceRepository) ResolveAlert(id int) error {
-	return r.db.Model(&models.FaceAlert{}).Where("id = ?", id).Update("resolved", true).Error
+	_, err := r.ResolveAlerts([]int{id}, nil)
+	return err
+}
+
+func (r *faceRepository) GetLogs(filter models.FaceLogFilter) ([]models.FaceRecognitionLog, error) {
+	var results []models.FaceRecognitionLog
+	query := r.db.Model(&models.FaceRecognitionLog{})
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.Recognized != nil {
+		query = query.Where("recognized = ?", *filter.Recognized)
+	}
+	if filter.Source != "" {
+		query = query.Where("source = ?", filter.Source)
+	}
+	if filter.From != nil {
+		query = query.Where("timestamp >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("timestamp <= ?", *filter.To)
+	}
+	err := query.Order("timestamp DESC, id DESC").Limit(filter.Limit).Find(&results).Error
+	return results, err
+}
+
+// GetAlerts skips the (large) image column unless IncludeImages is set
+func (r *faceRepository) GetAlerts(filter models.FaceAlertFilter) ([]models.FaceAlert, error) {
+	var results []models.FaceAlert
+	query := r.db.Model(&models.FaceAlert{})
+	if filter.IncludeImages {
+		query = query.Select("*, image_base64 IS NOT NULL AND image_base64 <> '' AS has_image")
+	} else {
+		query = query.Select("id, alert_type, source, resolved, resolved_by, resolved_at, timestamp, created_at, " +
+			"image_base64 IS NOT NULL AND image_base64 <> '' AS has_image")
+	}
+	if filter.Resolved != nil {
+		query = query.Where("resolved = ?", *filter.Resolved)
+	}
+	if filter.AlertType != "" {
+		query = query.Where("alert_type = ?", filter.AlertType)
+	}
+	if filter.From != nil {
+		query = query.Where("timestamp >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("timestamp <= ?", *filter.To)
+	}
+	err := query.Order("timestamp DESC, id DESC").Limit(filter.Limit).Find(&results).Error
+	return results, err
+}
+
+func (r *faceRepository) GetAlert(id int) (*models.FaceAlert, error) {
+	var alert models.FaceAlert
+	err := r.db.Select("*, image_base64 IS NOT NULL AND image_base64 <> '' AS has_image").
+		First(&alert, id).Error
+	return &alert, err
+}
+
+// ResolveAlerts marks unresolved alerts as resolved; returns how many changed
+func (r *faceRepository) ResolveAlerts(ids []int, resolvedBy *uint) (int64, error) {
+	result := r.db.Model(&models.FaceAlert{}).
+		Where("id IN ? AND resolved = ?", ids, false).
+		Updates(map[string]interface{}{
+			"resolved":    true,
+			"resolved_by": resolvedBy,
+			"resolved_at": time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
+
+// EnsureTables creates the face tables (and columns added later) on older databases
+func (r *faceRepository) EnsureTables() error {
+	migrator := r.db.Migrator()
+	if !migrator.HasTable(&models.FaceRecognitionLog{}) {
+		if err := migrator.CreateTable(&models.FaceRecognitionLog{}); err != nil {
+			return err
+		}
+	}
+	if !migrator.HasTable(&models.FaceAlert{}) {
+		return migrator.CreateTable(&models.FaceAlert{})
+	}
+	for _, field := range []string{"Source", "ResolvedBy", "ResolvedAt"} {
+		if migrator.HasColumn(&models.FaceAlert{}, field) {
+			continue
+		}
+		if err := migrator.AddColumn(&models.FaceAlert{}, field); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"smarthome-backend/database/models"
+
+	"gorm.io/gorm"
+)
+
+type FingerprintRepository interface {
+	Create(fp *models.FingerprintData) error
+	GetAll() ([]models.FingerprintData, error)
+	GetByID(id uint) (*models.FingerprintData, error)
+	GetByUserID(userID uint) ([]models.FingerprintData, error)
+	GetByTemplateIndex(index int) (*models.FingerprintData, error)
+	GetUsedIndices() ([]int, error)
+	Delete(id uint) error
+	EnsureTable() error
+}
+
+type fingerprintRepository struct {
+	db *gorm.DB
+}
+
+func NewFingerprintRepository(db *gorm.DB) FingerprintRepository {
+	return &fingerprintRepository{db: db}
+}
+
+func (r *fingerprintRepository) Create(fp *models.FingerprintData) error {
+	return r.db.Create(fp).Error
+}
+
+func (r *fingerprintRepository) GetAll() ([]models.FingerprintData, error) {
+	var fps []models.FingerprintData
+	err := r.db.Preload("User").Order("template_index ASC").Find(&fps).Error
+	return fps, err
+}
+
+func (r *fingerprintRepository) GetByID(id uint) (*models.FingerprintData, error) {
+	var fp models.FingerprintData
+	if err := r.db.Preload("User").First(&fp, id).Error; err != nil {
+		return nil, err
+	}
+	return &fp, nil
+}
+
+func (r *fingerprintRepository) GetByUserID(userID uint) ([]models.FingerprintData, error) {
+	var fps []models.FingerprintData
+	err := r.db.Where("user_id = ?", userID).Order("template_index ASC").Find(&fps).Error
+	return fps, err
+}
+
+func (r *fingerprintRepository) GetByTemplateIndex(index int) (*models.FingerprintData, error) {
+	var fp models.FingerprintData
+	if err := r.db.Preload("User").Where("template_index = ?", index).First(&fp).Error; err != nil {
+		return nil, err
+	}
+	return &fp, nil
+}
+
+func (r *fingerprintRepository) GetUsedIndices() ([]int, error) {
+	var indices []int
+	err := r.db.Model(&models.FingerprintData{}).Order("template_index ASC").Pluck("template_index", &indices).Error
+	return indices, err
+}
+
+func (r *fingerprintRepository) Delete(id uint) error {
+	return r.db.Delete(&models.FingerprintData{}, id).Error
+}
+
+// EnsureTable creates fingerprint_data and its unique slot index on older databases
+func (r *fingerprintRepository) EnsureTable() error {
+	migrator := r.db.Migrator()
+	if !migrator.HasTable(&models.FingerprintData{}) {
+		return migrator.CreateTable(&models.FingerprintData{})
+	}
+	if migrator.HasIndex(&models.FingerprintData{}, "uniq_template_index") ||
+		migrator.HasIndex(&models.FingerprintData{}, "TemplateIndex") {
+		return nil
+	}
+	return r.db.Exec("ALTER TABLE fingerprint_data ADD UNIQUE KEY uniq_template_index (template_index)").Error
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"smarthome-backend/database/models"
+
+	"gorm.io/gorm"
+)
+
+type GasIncidentRepository interface {
+	Create(incident *models.GasIncident) error
+	Update(incident *models.GasIncident) error
+	GetActive() (*models.GasIncident, error)
+	GetByID(id uint) (*models.GasIncident, error)
+	GetAll(limit int) ([]models.GasIncident, error)
+}
+
+type gasIncidentRepository struct {
+	db *gorm.DB
+}
+
+func NewGasIncidentRepository(db *gorm.DB) GasIncidentRepository {
+	return &gasIncidentRepository{db: db}
+}
+
+func (r *gasIncidentRepository) Create(incident *models.GasIncident) error {
+	return r.db.Create(incident).Error
+}
+
+func (r *gasIncidentRepository) Update(incident *models.GasIncident) error {
+	return r.db.Save(incident).Error
+}
+
+func (r *gasIncidentRepository) GetActive() (*models.GasIncident, error) {
+	var incident models.GasIncident
+	err := r.db.Where("status = ?", "active").Order("id DESC").First(&incident).Error
+	return &incident, err
+}
+
+func (r *gasIncidentRepository) GetByID(id uint) (*models.GasIncident, error) {
+	var incident models.GasIncident
+	err := r.db.First(&incident, id).Error
+	return &incident, err
+}
+
+func (r *gasIncidentRepository) GetAll(limit int) ([]models.GasIncident, error) {
+	var incidents []models.GasIncident
+	err := r.db.Order("started_at DESC").Limit(limit).Find(&incidents).Error
+	return incidents, err
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"smarthome-backend/database/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type GuestCodeRepository interface {
+	Create(code *models.GuestCode) error
+	GetAll() ([]models.GuestCode, error)
+	GetByID(id uint) (*models.GuestCode, error)
+	GetUsable(now time.Time) ([]models.GuestCode, error)
+	ConsumeUse(id uint, now time.Time) (bool, error)
+	Revoke(id uint) error
+	Delete(id uint) error
+}
+
+type guestCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewGuestCodeRepository(db *gorm.DB) GuestCodeRepository {
+	return &guestCodeRepository{db: db}
+}
+
+func (r *guestCodeRepository) Create(code *models.GuestCode) error {
+	return r.db.Create(code).Error
+}
+
+func (r *guestCodeRepository) GetAll() ([]models.GuestCode, error) {
+	var codes []models.GuestCode
+	err := r.db.Order("created_at DESC").Find(&codes).Error
+	return codes, err
+}
+
+func (r *guestCodeRepository) GetByID(id uint) (*models.GuestCode, error) {
+	var code models.GuestCode
+	err := r.db.First(&code, id).Error
+	return &code, err
+}
+
+// GetUsable - Not revoked, inside the validity window and with uses left
+func (r *guestCodeRepository) GetUsable(now time.Time) ([]models.GuestCode, error) {
+	var codes []models.GuestCode
+	err := r.db.
+		Where("revoked = ?", false).
+		Where("valid_from <= ? AND valid_until > ?", now, now).
+		Where("max_uses = 0 OR use_count < max_uses").
+		Find(&codes).Error
+	return codes, err
+}
+
+// ConsumeUse - Atomically count one use; false when the code was used up (or revoked) meanwhile
+func (r *guestCodeRepository) ConsumeUse(id uint, now time.Time) (bool, error) {
+	result := r.db.Model(&models.GuestCode{}).
+		Where("id = ? AND revoked = ?", id, false).
+		Where("max_uses = 0 OR use_count < max_uses").
+		Updates(map[string]interface{}{
+			"use_count":    gorm.Expr("use_count + 1"),
+			"last_used_at": now,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *guestCodeRepository) Revoke(id uint) error {
+	return r.db.Model(&models.GuestCode{}).Where("id = ?", id).Update("revoked", true).Error
+}
+
+func (r *guestCodeRepository) Delete(id uint) error {
+	return r.db.Delete(&models.GuestCode{}, id).Error
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"smarthome-backend/database/models"
+
+	"gorm.io/gorm"
+)
+
+type NotificationChannelRepository interface {
+	Create(channel *models.NotificationChannel) error
+	Update(channel *models.NotificationChannel) error
+	Delete(userID, id uint) (bool, error)
+	GetByID(id uint) (*models.NotificationChannel, error)
+	GetByUser(userID uint) ([]models.NotificationChannel, error)
+	// GetSubscribed returns enabled channels of active users subscribed to notifType
+	GetSubscribed(notifType string) ([]models.NotificationChannel, error)
+
+	CreateDelivery(delivery *models.NotificationDelivery) error
+	GetDeliveries(userID uint, channelID *uint, status string, limit int) ([]models.NotificationDelivery, error)
+}
+
+type notificationChannelRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationChannelRepository(db *gorm.DB) NotificationChannelRepository {
+	return &notificationChannelRepository{db: db}
+}
+
+func (r *notificationChannelRepository) Create(channel *models.NotificationChannel) error {
+	return r.db.Create(channel).Error
+}
+
+func (r *notificationChannelRepository) Update(channel *models.NotificationChannel) error {
+	return r.db.Model(channel).Select("kind", "name", "target", "types", "enabled").Updates(channel).Error
+}
+
+// Delete only removes channels owned by userID
+func (r *notificationChannelRepository) Delete(userID, id uint) (bool, error) {
+	result := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.NotificationChannel{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *notificationChannelRepository) GetByID(id uint) (*models.NotificationChannel, error) {
+	var channel models.NotificationChannel
+	err := r.db.First(&channel, id).Error
+	return &channel, err
+}
+
+func (r *notificationChannelRepository) GetByUser(userID uint) ([]models.NotificationChannel, error) {
+	var channels []models.NotificationChannel
+	err := r.db.Where("user_id = ?", userID).Order("id ASC").Find(&channels).Error
+	return channels, err
+}
+
+func (r *notificationChannelRepository) GetSubscribed(notifType string) ([]models.NotificationChannel, error) {
+	var channels []models.NotificationChannel
+	err := r.db.
+		Joins("JOIN users u ON u.user_id = notification_channels.user_id").
+		Where("notification_channels.enabled = ? AND u.status = ?", true, "active").
+		Where("JSON_CONTAINS(notification_channels.types, JSON_QUOTE(?))", notifType).
+		Find(&channels).Error
+	return channels, err
+}
+
+func (r *notificationChannelRepository) CreateDelivery(delivery *models.NotificationDelivery) error {
+	return r.db.Create(delivery).Error
+}
+
+func (r *notificationChannelRepository) GetDeliveries(userID uint, channelID *uint, status string, limit int) ([]models.NotificationDelivery, error) {
+	var deliveries []models.NotificationDelivery
+	query := r.db.Where("user_id = ?", userID)
+	if channelID != nil {
+		query = query.Where("channel_id = ?", *channelID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
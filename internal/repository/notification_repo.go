@@ -2,14 +2,25 @@ package repository
 
 import (
 	"smarthome-backend/database/models"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type NotificationRepository interface {
 	Create(notif *models.Notification) error
 	GetAll(limit int) ([]models.Notification, error)
 	GetByType(notifType string, limit int) ([]models.Notification, error)
+
+	// Per-user read state
+	List(userID uint, filter models.NotificationFilter) ([]models.Notification, int64, error)
+	CountUnread(userID uint, types []string) (int64, error)
+	MarkRead(userID uint, ids []uint) error
+	MarkAllRead(userID uint, types []string) (int64, error)
+
+	EnsureTypeEnum() error
 }
 
 type notificationRepository struct {
@@ -35,3 +46,93 @@ func (r *notificationRepository) GetByType(notifType string, limit int) ([]model
 	err := r.db.Where("type = ?", notifType).Order("timestamp DESC").Limit(limit).Find(&notifs).Error
 	return notifs, err
 }
+
+// withReadState joins the user's read markers (nr.notif_id is NULL when unread)
+func (r *notificationRepository) withReadState(userID uint, types []string) *gorm.DB {
+	query := r.db.Model(&models.Notification{}).
+		Joins("LEFT JOIN notification_reads nr ON nr.notif_id = notifications.notif_id AND nr.user_id = ?", userID)
+	if len(types) > 0 {
+		query = query.Where("notifications.type IN ?", types)
+	}
+	return query
+}
+
+func (r *notificationRepository) List(userID uint, filter models.NotificationFilter) ([]models.Notification, int64, error) {
+	// Fresh query per statement, a gorm chain is not reusable after Count
+	query := func() *gorm.DB {
+		q := r.withReadState(userID, filter.Types)
+		if filter.UnreadOnly {
+			q = q.Where("nr.notif_id IS NULL")
+		}
+		return q
+	}
+
+	var total int64
+	if err := query().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var notifs []models.Notification
+	err := query().
+		Select("notifications.*, nr.notif_id IS NOT NULL AS is_read").
+		Order("notifications.timestamp DESC, notifications.notif_id DESC").
+		Offset((filter.Page - 1) * filter.PageSize).
+		Limit(filter.PageSize).
+		Find(&notifs).Error
+	return notifs, total, err
+}
+
+func (r *notificationRepository) CountUnread(userID uint, types []string) (int64, error) {
+	var count int64
+	err := r.withReadState(userID, types).Where("nr.notif_id IS NULL").Count(&count).Error
+	return count, err
+}
+
+// MarkRead - Already-read ids are ignored
+func (r *notificationRepository) MarkRead(userID uint, ids []uint) error {
+	var existing []uint
+	if err := r.db.Model(&models.Notification{}).Where("notif_id IN ?", ids).Pluck("notif_id", &existing).Error; err != nil {
+		return err
+	}
+	if len(existing) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	reads := make([]models.NotificationRead, 0, len(existing))
+	for _, id := range existing {
+		reads = append(reads, models.NotificationRead{NotifID: id, UserID: userID, ReadAt: now})
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&reads).Error
+}
+
+// MarkAllRead - Marks every unread notification (optionally of some types) in one statement
+func (r *notificationRepository) MarkAllRead(userID uint, types []string) (int64, error) {
+	query := `INSERT IGNORE INTO notification_reads (notif_id, user_id, read_at)
+		SELECT n.notif_id, ?, NOW() FROM notifications n`
+	args := []interface{}{userID}
+	if len(types) > 0 {
+		query += " WHERE n.type IN ?"
+		args = append(args, types)
+	}
+
+	result := r.db.Exec(query, args...)
+	return result.RowsAffected, result.Error
+}
+
+// EnsureTypeEnum - Extend notifications.type on databases created before the new types
+func (r *notificationRepository) EnsureTypeEnum() error {
+	columns, err := r.db.Migrator().ColumnTypes(&models.Notification{})
+	if err != nil {
+		return err
+	}
+	for _, column := range columns {
+		if column.Name() != "type" {
+			continue
+		}
+		if columnType, ok := column.ColumnType(); ok && strings.Contains(columnType, "approval") {
+			return nil
+		}
+	}
+	return r.db.Exec("ALTER TABLE notifications MODIFY type ENUM('gas','door','system','intruder','access','device','approval') DEFAULT 'system'").Error
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"smarthome-backend/database/models"
+
+	"gorm.io/gorm"
+)
+
+type PinAttemptRepository interface {
+	GetBySource(source string) (*models.PinAttempt, error)
+	Save(attempt *models.PinAttempt) error
+}
+
+type pinAttemptRepository struct {
+	db *gorm.DB
+}
+
+func NewPinAttemptRepository(db *gorm.DB) PinAttemptRepository {
+	return &pinAttemptRepository{db: db}
+}
+
+func (r *pinAttemptRepository) GetBySource(source string) (*models.PinAttempt, error) {
+	var attempt models.PinAttempt
+	err := r.db.Where("source = ?", source).First(&attempt).Error
+	return &attempt, err
+}
+
+// Save - Insert or update (ID == 0 means new source)
+func (r *pinAttemptRepository) Save(attempt *models.PinAttempt) error {
+	if attempt.ID == 0 {
+		return r.db.Create(attempt).Error
+	}
+	return r.db.Model(&models.PinAttempt{}).
+		Where("id = ?", attempt.ID).
+		Updates(map[string]interface{}{
+			"failed_count":    attempt.FailedCount,
+			"lockout_level":   attempt.LockoutLevel,
+			"locked_until":    attempt.LockedUntil,
+			"last_attempt_at": attempt.LastAttemptAt,
+		}).Error
+}
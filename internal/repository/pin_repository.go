@@ -8,8 +8,11 @@ import (
 
 type PinRepository interface {
 	GetUniversalPin() (*models.PinCode, error)
-	Create(pin string, setBy uint) error
-	Update(pin string, setBy uint) error
+	Create(pinHash string, setBy uint) error
+	Update(pinHash string, setBy uint) error
+	EnsureHashColumn() error
+	GetLegacyPins() ([]models.PinCode, error)
+	ReplaceLegacyPin(id uint, pinHash string) error
 }
 
 type pinRepository struct {
@@ -30,16 +33,16 @@ func (r *pinRepository) GetUniversalPin() (*models.PinCode, error) {
 }
 
 // Create - Insert PIN pertama kali
-func (r *pinRepository) Create(pin string, setBy uint) error {
+func (r *pinRepository) Create(pinHash string, setBy uint) error {
 	newPin := models.PinCode{
-		UniversalPin: pin,
-		SetBy:        setBy,
+		PinHash: pinHash,
+		SetBy:   setBy,
 	}
 	return r.db.Create(&newPin).Error
 }
 
 // Update - Update PIN yang sudah ada
-func (r *pinRepository) Update(pin string, setBy uint) error {
+func (r *pinRepository) Update(pinHash string, setBy uint) error {
 	// Get latest PIN ID first
 	var latestID uint
 	err := r.db.Raw("SELECT id FROM pin_codes ORDER BY updated_at DESC LIMIT 1").Scan(&latestID).Error
@@ -47,7 +50,49 @@ func (r *pinRepository) Update(pin string, setBy uint) error {
 		return err
 	}
 
-	// Update using the retrieved ID
-	query := "UPDATE pin_codes SET universal_pin = ?, set_by = ?, updated_at = NOW() WHERE id = ?"
-	return r.db.Exec(query, pin, setBy, latestID).Error
+	// Update using the retrieved ID (plaintext column always cleared)
+	query := "UPDATE pin_codes SET pin_hash = ?, universal_pin = NULL, set_by = ?, updated_at = NOW() WHERE id = ?"
+	return r.db.Exec(query, pinHash, setBy, latestID).Error
+}
+
+// EnsureHashColumn - Upgrade old pin_codes tables (plaintext only) in place
+func (r *pinRepository) EnsureHashColumn() error {
+	migrator := r.db.Migrator()
+	if !migrator.HasColumn(&models.PinCode{}, "pin_hash") {
+		if err := migrator.AddColumn(&models.PinCode{}, "PinHash"); err != nil {
+			return err
+		}
+	}
+
+	// Plaintext column must accept NULL once a row is hashed
+	columns, err := migrator.ColumnTypes(&models.PinCode{})
+	if err != nil {
+		return err
+	}
+	for _, column := range columns {
+		if column.Name() != "universal_pin" {
+			continue
+		}
+		if nullable, ok := column.Nullable(); ok && nullable {
+			return nil
+		}
+		return r.db.Exec("ALTER TABLE pin_codes MODIFY universal_pin VARCHAR(6) NULL").Error
+	}
+	return nil
+}
+
+// GetLegacyPins - Rows that still only have a plaintext PIN
+func (r *pinRepository) GetLegacyPins() ([]models.PinCode, error) {
+	var pins []models.PinCode
+	err := r.db.
+		Where("universal_pin IS NOT NULL AND universal_pin <> ''").
+		Where("pin_hash IS NULL OR pin_hash = ''").
+		Find(&pins).Error
+	return pins, err
+}
+
+// ReplaceLegacyPin - Store hash and wipe plaintext (updated_at kept as-is)
+func (r *pinRepository) ReplaceLegacyPin(id uint, pinHash string) error {
+	query := "UPDATE pin_codes SET pin_hash = ?, universal_pin = NULL, updated_at = updated_at WHERE id = ?"
+	return r.db.Exec(query, pinHash, id).Error
 }
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"smarthome-backend/database/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SceneRepository interface {
+	Create(scene *models.Scene) error
+	Update(scene *models.Scene) error
+	Delete(id uint) error
+	GetByID(id uint) (*models.Scene, error)
+	GetAll() ([]models.Scene, error)
+	MarkActivated(id uint, userID *uint, activatedAt time.Time) error
+
+	CreateActivation(entry *models.SceneActivation) error
+	GetActivations(sceneID *uint, limit int) ([]models.SceneActivation, error)
+}
+
+type sceneRepository struct {
+	db *gorm.DB
+}
+
+func NewSceneRepository(db *gorm.DB) SceneRepository {
+	return &sceneRepository{db: db}
+}
+
+func (r *sceneRepository) Create(scene *models.Scene) error {
+	return r.db.Create(scene).Error
+}
+
+// Update - Save editable fields (last_activated_* is owned by Activate)
+func (r *sceneRepository) Update(scene *models.Scene) error {
+	return r.db.Model(scene).Select("name", "description", "actions").Updates(scene).Error
+}
+
+func (r *sceneRepository) Delete(id uint) error {
+	return r.db.Delete(&models.Scene{}, id).Error
+}
+
+func (r *sceneRepository) GetByID(id uint) (*models.Scene, error) {
+	var scene models.Scene
+	err := r.db.First(&scene, id).Error
+	return &scene, err
+}
+
+func (r *sceneRepository) GetAll() ([]models.Scene, error) {
+	var scenes []models.Scene
+	err := r.db.Order("name ASC").Find(&scenes).Error
+	return scenes, err
+}
+
+func (r *sceneRepository) MarkActivated(id uint, userID *uint, activatedAt time.Time) error {
+	return r.db.Model(&models.Scene{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"last_activated_at": activatedAt,
+		"last_activated_by": userID,
+	}).Error
+}
+
+func (r *sceneRepository) CreateActivation(entry *models.SceneActivation) error {
+	return r.db.Create(entry).Error
+}
+
+// GetActivations - Latest activations, for one scene or all scenes
+func (r *sceneRepository) GetActivations(sceneID *uint, limit int) ([]models.SceneActivation, error) {
+	var activations []models.SceneActivation
+	query := r.db.Order("activated_at DESC").Limit(limit)
+	if sceneID != nil {
+		query = query.Where("scene_id = ?", *sceneID)
+	}
+	err := query.Find(&activations).Error
+	return activations, err
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"smarthome-backend/database/models"
+
+	"gorm.io/gorm"
+)
+
+type ScheduleRepository interface {
+	Create(schedule *models.Schedule) error
+	Update(schedule *models.Schedule) error
+	Delete(id uint) error
+	GetByID(id uint) (*models.Schedule, error)
+	GetAll() ([]models.Schedule, error)
+	GetActive() ([]models.Schedule, error)
+	UpdateFields(id uint, fields map[string]interface{}) error
+}
+
+type scheduleRepository struct {
+	db *gorm.DB
+}
+
+func NewScheduleRepository(db *gorm.DB) ScheduleRepository {
+	return &scheduleRepository{db: db}
+}
+
+func (r *scheduleRepository) Create(schedule *models.Schedule) error {
+	return r.db.Create(schedule).Error
+}
+
+// Update - Save editable fields (run bookkeeping is written by the scheduler)
+func (r *scheduleRepository) Update(schedule *models.Schedule) error {
+	return r.db.Model(schedule).
+		Select("name", "cron", "timezone", "device", "action", "mode", "paused", "next_run_at").
+		Updates(schedule).Error
+}
+
+func (r *scheduleRepository) Delete(id uint) error {
+	return r.db.Delete(&models.Schedule{}, id).Error
+}
+
+func (r *scheduleRepository) GetByID(id uint) (*models.Schedule, error) {
+	var schedule models.Schedule
+	err := r.db.First(&schedule, id).Error
+	return &schedule, err
+}
+
+func (r *scheduleRepository) GetAll() ([]models.Schedule, error) {
+	var schedules []models.Schedule
+	err := r.db.Order("id ASC").Find(&schedules).Error
+	return schedules, err
+}
+
+// GetActive - Schedules that are not paused
+func (r *scheduleRepository) GetActive() ([]models.Schedule, error) {
+	var schedules []models.Schedule
+	err := r.db.Where("paused = ?", false).Find(&schedules).Error
+	return schedules, err
+}
+
+func (r *scheduleRepository) UpdateFields(id uint, fields map[string]interface{}) error {
+	return r.db.Model(&models.Schedule{}).Where("id = ?", id).Updates(fields).Error
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"smarthome-backend/database/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SettingRepository interface {
+	Get(key string) (*models.SystemSetting, error)
+	Set(key, value string, updatedBy *uint) error
+}
+
+type settingRepository struct {
+	db *gorm.DB
+}
+
+func NewSettingRepository(db *gorm.DB) SettingRepository {
+	return &settingRepository{db: db}
+}
+
+func (r *settingRepository) Get(key string) (*models.SystemSetting, error) {
+	var setting models.SystemSetting
+	err := r.db.Where("setting_key = ?", key).First(&setting).Error
+	return &setting, err
+}
+
+// Set - Upsert by key
+func (r *settingRepository) Set(key, value string, updatedBy *uint) error {
+	setting := models.SystemSetting{
+		Key:       key,
+		Value:     value,
+		UpdatedBy: updatedBy,
+		UpdatedAt: time.Now(),
+	}
+	return r.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&setting).Error
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"smarthome-backend/database/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UserPinRepository interface {
+	GetByUserID(userID uint) (*models.UserPin, error)
+	GetAllActive() ([]models.UserPin, error)
+	Upsert(userID uint, pinHash string) error
+	Delete(userID uint) error
+}
+
+type userPinRepository struct {
+	db *gorm.DB
+}
+
+func NewUserPinRepository(db *gorm.DB) UserPinRepository {
+	return &userPinRepository{db: db}
+}
+
+func (r *userPinRepository) GetByUserID(userID uint) (*models.UserPin, error) {
+	var pin models.UserPin
+	err := r.db.Where("user_id = ?", userID).First(&pin).Error
+	return &pin, err
+}
+
+// GetAllActive - PINs of users whose account is active (pending/suspended can't unlock)
+func (r *userPinRepository) GetAllActive() ([]models.UserPin, error) {
+	var pins []models.UserPin
+	err := r.db.
+		Joins("JOIN users ON users.user_id = user_pins.user_id").
+		Where("users.status = ?", "active").
+		Find(&pins).Error
+	return pins, err
+}
+
+func (r *userPinRepository) Upsert(userID uint, pinHash string) error {
+	pin := models.UserPin{
+		UserID:  userID,
+		PinHash: pinHash,
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"pin_hash": pinHash, "updated_at": gorm.Expr("NOW()")}),
+	}).Create(&pin).Error
+}
+
+func (r *userPinRepository) Delete(userID uint) error {
+	return r.db.Where("user_id = ?", userID).Delete(&models.UserPin{}).Error
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"smarthome-backend/database/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type WebhookRepository interface {
+	CreateEndpoint(endpoint *models.WebhookEndpoint) error
+	UpdateEndpoint(endpoint *models.WebhookEndpoint) error
+	UpdateSecret(id uint, secret string) error
+	DeleteEndpoint(id uint) error
+	GetEndpoint(id uint) (*models.WebhookEndpoint, error)
+	GetEndpoints() ([]models.WebhookEndpoint, error)
+	// GetSubscribed returns enabled endpoints subscribed to eventType
+	GetSubscribed(eventType string) ([]models.WebhookEndpoint, error)
+
+	CreateDeliveries(deliveries []models.WebhookDelivery) error
+	CreateDelivery(delivery *models.WebhookDelivery) error
+	SaveAttempt(delivery *models.WebhookDelivery) error
+	GetDelivery(id uint) (*models.WebhookDelivery, error)
+	GetDeliveries(filter models.WebhookDeliveryFilter) ([]models.WebhookDelivery, error)
+	// GetDue returns pending deliveries whose next attempt is due
+	GetDue(now time.Time, limit int) ([]models.WebhookDelivery, error)
+}
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+func (r *webhookRepository) CreateEndpoint(endpoint *models.WebhookEndpoint) error {
+	return r.db.Create(endpoint).Error
+}
+
+func (r *webhookRepository) UpdateEndpoint(endpoint *models.WebhookEndpoint) error {
+	return r.db.Model(endpoint).Select("url", "description", "events", "enabled").Updates(endpoint).Error
+}
+
+func (r *webhookRepository) UpdateSecret(id uint, secret string) error {
+	return r.db.Model(&models.WebhookEndpoint{}).Where("id = ?", id).Update("secret", secret).Error
+}
+
+func (r *webhookRepository) DeleteEndpoint(id uint) error {
+	return r.db.Delete(&models.WebhookEndpoint{}, id).Error
+}
+
+func (r *webhookRepository) GetEndpoint(id uint) (*models.WebhookEndpoint, error) {
+	var endpoint models.WebhookEndpoint
+	err := r.db.First(&endpoint, id).Error
+	return &endpoint, err
+}
+
+func (r *webhookRepository) GetEndpoints() ([]models.WebhookEndpoint, error) {
+	var endpoints []models.WebhookEndpoint
+	err := r.db.Order("id ASC").Find(&endpoints).Error
+	return endpoints, err
+}
+
+func (r *webhookRepository) GetSubscribed(eventType string) ([]models.WebhookEndpoint, error) {
+	var endpoints []models.WebhookEndpoint
+	err := r.db.
+		Where("enabled = ?", true).
+		Where("JSON_CONTAINS(events, JSON_QUOTE(?))", eventType).
+		Find(&endpoints).Error
+	return endpoints, err
+}
+
+func (r *webhookRepository) CreateDeliveries(deliveries []models.WebhookDelivery) error {
+	return r.db.Create(&deliveries).Error
+}
+
+func (r *webhookRepository) CreateDelivery(delivery *models.WebhookDelivery) error {
+	return r.db.Create(delivery).Error
+}
+
+// SaveAttempt stores the outcome of one delivery attempt
+func (r *webhookRepository) SaveAttempt(delivery *models.WebhookDelivery) error {
+	return r.db.Model(delivery).
+		Select("status", "attempts", "next_attempt_at", "response_code", "last_error", "delivered_at").
+		Updates(delivery).Error
+}
+
+func (r *webhookRepository) GetDelivery(id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.db.First(&delivery, id).Error
+	return &delivery, err
+}
+
+func (r *webhookRepository) GetDeliveries(filter models.WebhookDeliveryFilter) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	query := r.db.Model(&models.WebhookDelivery{})
+	if filter.EndpointID != nil {
+		query = query.Where("endpoint_id = ?", *filter.EndpointID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.EventType != "" {
+		query = query.Where("event_type = ?", filter.EventType)
+	}
+	err := query.Order("created_at DESC, id DESC").Limit(filter.Limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *webhookRepository) GetDue(now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.
+		Where("status = ? AND next_attempt_at <= ?", models.WebhookPending, now).
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
@@ -1,7 +1,10 @@
 package router
 
 import (
+	"log"
 	"smarthome-backend/internal/handler"
+	"smarthome-backend/internal/middleware"
+	"smarthome-backend/internal/service"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -20,26 +23,67 @@ type AppConfig struct {
 	DoorHandler    *handler.DoorHandler
 	LampHandler    *handler.LampHandler
 	CurtainHandler *handler.CurtainHandler
+	BuzzerHandler  *handler.BuzzerHandler
 
 	// User & Auth Handlers
 	UserHandler      *handler.UserHandler
 	AccessLogHandler *handler.AccessLogHandler
 	AuthHandler      *handler.AuthHandler
 	AdminHandler     *handler.AdminHandler
+	GuestCodeHandler *handler.GuestCodeHandler
 
 	// Device Control Handler
 	DeviceControlHandler *handler.DeviceControlHandler
 
+	// Automation Rules & Schedules
+	AutomationHandler *handler.AutomationHandler
+	ScheduleHandler   *handler.ScheduleHandler
+	SceneHandler      *handler.SceneHandler
+
+	// Notifications
+	NotificationHandler        *handler.NotificationHandler
+	NotificationChannelHandler *handler.NotificationChannelHandler
+	WebhookHandler             *handler.WebhookHandler
+
+	// Security alarm & gas emergencies
+	AlarmHandler       *handler.AlarmHandler
+	GasIncidentHandler *handler.GasIncidentHandler
+
 	// Face Recognition Handler
 	FaceHandler *handler.FaceHandler
 
+	// ESP32-CAM registry, proxy & captures
+	CameraHandler *handler.CameraHandler
+
+	// Fingerprint reader on the door unit
+	FingerprintHandler *handler.FingerprintHandler
+
 	// Dashboard Handler
 	DashboardHandler *handler.DashboardHandler
+
+	// Live Event Stream
+	WebSocketHandler *handler.WebSocketHandler
+
+	// Health Check
+	HealthHandler *handler.HealthHandler
+
+	// Authentication
+	AuthService  service.AuthService
+	UserService  service.UserService
+	DeviceAPIKey string
+
+	// Proxies whose X-Forwarded-For is believed; nil trusts none
+	TrustedProxies []string
 }
 
 func InitRouter(cfg AppConfig) *gin.Engine {
 	r := gin.Default()
 
+	// ClientIP keys the PIN lockout, so forwarded headers are only honoured from known proxies
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatal("[ROUTER] Invalid TRUSTED_PROXIES: ", err)
+	}
+
 	// ==================== NGROK-FRIENDLY CORS CONFIG ====================
 	r.Use(cors.New(cors.Config{
 		AllowAllOrigins: true,
@@ -95,39 +139,69 @@ func InitRouter(cfg AppConfig) *gin.Engine {
 	})
 
 	// Health Check
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"status":  "healthy",
-			"service": "smart-home-backend",
-			"version": "1.0.0",
-		})
-	})
+	r.GET("/health", cfg.HealthHandler.Check)
+
+	// ==================== AUTH MIDDLEWARE ====================
+	requireUser := middleware.AuthRequired(cfg.AuthService, cfg.UserService)
+	requireMember := middleware.RequireRole("admin", "member")
+	requireAdmin := middleware.RequireRole("admin")
+	requireDevice := middleware.DeviceAuth(cfg.DeviceAPIKey)
+
+	// WebSocket live events (replaces dashboard polling, token via ?token=)
+	r.GET("/ws", requireUser, cfg.WebSocketHandler.Connect)
 
 	// API Routes
 	api := r.Group("/api")
 	{
+		// ==================== AUTH ENDPOINTS (PUBLIC) ====================
+		auth := api.Group("/auth")
+		{
+			auth.POST("/register", cfg.AuthHandler.Register)
+			auth.POST("/login", cfg.AuthHandler.Login)
+		}
+
+		// ==================== DEVICE INGESTION (ESP32, X-Device-Key) ====================
+		ingest := api.Group("", requireDevice)
+		{
+			// Sensor readings
+			ingest.POST("/sensor/gas", cfg.GasHandler.Create)
+			ingest.POST("/sensor/temperature", cfg.TempHandler.Create)
+			ingest.POST("/sensor/humidity", cfg.HumidHandler.Create)
+			ingest.POST("/sensor/light", cfg.LightHandler.Create)
+
+			// Device status reports
+			ingest.POST("/device/door", cfg.DoorHandler.Create)
+			ingest.POST("/device/door/verify-pin", cfg.DoorHandler.VerifyPin)
+			ingest.POST("/device/lamp", cfg.LampHandler.Create)
+			ingest.POST("/device/curtain", cfg.CurtainHandler.Create)
+
+			// Access attempts & ESP32-CAM frames
+			ingest.POST("/access-log/", cfg.AccessLogHandler.Create)
+			ingest.POST("/face/recognize", cfg.FaceHandler.RecognizeFace)
+
+			// Python face service callbacks
+			ingest.POST("/face/recognition-logs", cfg.FaceHandler.IngestRecognition)
+			ingest.POST("/face/alerts", cfg.FaceHandler.IngestAlert)
+		}
+
+		// Everything below requires a valid token from an active account
+		authed := api.Group("", requireUser)
+
 		// ==================== DASHBOARD ENDPOINT ====================
-		api.GET("/dashboard/initial", cfg.DashboardHandler.GetInitialData)
+		authed.GET("/dashboard/initial", cfg.DashboardHandler.GetInitialData)
 
 		// ==================== SENSOR ENDPOINTS ====================
-		sensor := api.Group("/sensor")
+		sensor := authed.Group("/sensor")
 		{
-			// Gas Sensor
-			sensor.POST("/gas", cfg.GasHandler.Create)
 			sensor.GET("/gas", cfg.GasHandler.GetAll)
-
-			// Temperature Sensor
-			sensor.POST("/temperature", cfg.TempHandler.Create)
 			sensor.GET("/temperature", cfg.TempHandler.GetAll)
-
-			// Humidity Sensor
-			sensor.POST("/humidity", cfg.HumidHandler.Create)
 			sensor.GET("/humidity", cfg.HumidHandler.GetAll)
-
-			// Light Sensor
-			sensor.POST("/light", cfg.LightHandler.Create)
 			sensor.GET("/light", cfg.LightHandler.GetAll)
 
+			// Gas emergency incidents
+			sensor.GET("/gas/incidents", cfg.GasIncidentHandler.GetIncidents)
+			sensor.GET("/gas/incidents/:id", cfg.GasIncidentHandler.GetIncident)
+
 			// Analytics Endpoints
 			sensor.GET("/stats", cfg.SensorAnalyticsHandler.GetStatistics)
 			sensor.GET("/data", cfg.SensorAnalyticsHandler.GetPaginatedData)
@@ -135,26 +209,28 @@ func InitRouter(cfg AppConfig) *gin.Engine {
 		}
 
 		// ==================== DEVICE STATUS ENDPOINTS ====================
-		device := api.Group("/device")
+		device := authed.Group("/device")
 		{
 			// Door Status
-			device.POST("/door", cfg.DoorHandler.Create)
 			device.GET("/door/latest", cfg.DoorHandler.GetLatest)
 			device.GET("/door/history", cfg.DoorHandler.GetAll)
-			device.POST("/door/verify-pin", cfg.DoorHandler.VerifyPin)
 
 			// Lamp Status
-			device.POST("/lamp", cfg.LampHandler.Create)
 			device.GET("/lamp/latest", cfg.LampHandler.GetLatest)
-			device.GET("/lamp/history", cfg.LampHandler.GetAll)
+			device.GET("/lamp/history", cfg.LampHandler.GetTimeline)
+			device.GET("/lamp/timeline", cfg.LampHandler.GetTimeline)
 
 			// Curtain Status
-			device.POST("/curtain", cfg.CurtainHandler.Create)
 			device.GET("/curtain/latest", cfg.CurtainHandler.GetLatest)
+			device.GET("/curtain/timeline", cfg.CurtainHandler.GetTimeline)
+
+			// Buzzer Status & activity log
+			device.GET("/buzzer/latest", cfg.BuzzerHandler.GetLatest)
+			device.GET("/buzzer/history", cfg.BuzzerHandler.GetHistory)
 		}
 
-		// ==================== DEVICE CONTROL ENDPOINTS ====================
-		control := api.Group("/control")
+		// ==================== DEVICE CONTROL ENDPOINTS (ACTIVE MEMBERS) ====================
+		control := authed.Group("/control", requireMember)
 		{
 			// Universal control endpoint
 			control.POST("/device", cfg.DeviceControlHandler.Control)
@@ -166,31 +242,115 @@ func InitRouter(cfg AppConfig) *gin.Engine {
 
 			// Manual Buzzer Control
 			control.POST("/buzzer", cfg.DeviceControlHandler.ControlBuzzer)
+
+			// Command tracking (correlation ID from the control response)
+			control.GET("/commands", cfg.DeviceControlHandler.GetCommands)
+			control.GET("/commands/:id", cfg.DeviceControlHandler.GetCommand)
 		}
 
-		// ==================== USER ENDPOINTS ====================
-		user := api.Group("/user")
+		// ==================== AUTOMATION RULE ENDPOINTS ====================
+		automation := authed.Group("/automations", requireMember)
 		{
-			user.GET("/", cfg.UserHandler.GetAll)
-			user.GET("/:id", cfg.UserHandler.GetByID)
-			user.PUT("/:id", cfg.UserHandler.Update)
-			user.DELETE("/:id", cfg.UserHandler.Delete)
+			automation.GET("", cfg.AutomationHandler.GetAll)
+			automation.GET("/logs", cfg.AutomationHandler.GetLogs)
+			automation.GET("/:id", cfg.AutomationHandler.GetByID)
+			automation.GET("/:id/logs", cfg.AutomationHandler.GetLogs)
+
+			// Rules drive devices unattended, so changes are admin-only
+			automation.POST("", requireAdmin, cfg.AutomationHandler.Create)
+			automation.PUT("/:id", requireAdmin, cfg.AutomationHandler.Update)
+			automation.PUT("/:id/enabled", requireAdmin, cfg.AutomationHandler.SetEnabled)
+			automation.DELETE("/:id", requireAdmin, cfg.AutomationHandler.Delete)
+		}
 
-			// Profile management
-			user.PUT("/:id/profile", cfg.UserHandler.UpdateProfile)
-			user.PUT("/:id/password", cfg.UserHandler.ChangePassword)
-			user.POST("/:id/re-enroll-face", cfg.UserHandler.ReEnrollFace)
+		// ==================== SCHEDULE ENDPOINTS ====================
+		schedule := authed.Group("/schedules", requireMember)
+		{
+			schedule.GET("", cfg.ScheduleHandler.GetAll)
+			schedule.GET("/:id", cfg.ScheduleHandler.GetByID)
+
+			// Schedules may lock/unlock the door, so editing them is admin-only
+			schedule.POST("", requireAdmin, cfg.ScheduleHandler.Create)
+			schedule.PUT("/:id", requireAdmin, cfg.ScheduleHandler.Update)
+			schedule.DELETE("/:id", requireAdmin, cfg.ScheduleHandler.Delete)
+
+			schedule.POST("/:id/pause", cfg.ScheduleHandler.Pause)
+			schedule.POST("/:id/resume", cfg.ScheduleHandler.Resume)
+			schedule.POST("/:id/skip-next", cfg.ScheduleHandler.SkipNext)
+			schedule.DELETE("/:id/skip-next", cfg.ScheduleHandler.SkipNext)
 		}
 
-		// ==================== AUTH ENDPOINTS ====================
-		auth := api.Group("/auth")
+		// ==================== SCENE ENDPOINTS ====================
+		scene := authed.Group("/scenes", requireMember)
 		{
-			auth.POST("/register", cfg.AuthHandler.Register)
-			auth.POST("/login", cfg.AuthHandler.Login)
+			scene.GET("", cfg.SceneHandler.GetAll)
+			scene.GET("/activations", cfg.SceneHandler.GetActivations)
+			scene.GET("/:id", cfg.SceneHandler.GetByID)
+			scene.GET("/:id/activations", cfg.SceneHandler.GetActivations)
+			scene.POST("/:id/activate", cfg.SceneHandler.Activate)
+
+			// Scenes may include door unlock, so editing them is admin-only
+			scene.POST("", requireAdmin, cfg.SceneHandler.Create)
+			scene.PUT("/:id", requireAdmin, cfg.SceneHandler.Update)
+			scene.DELETE("/:id", requireAdmin, cfg.SceneHandler.Delete)
+		}
+
+		// ==================== NOTIFICATION ENDPOINTS ====================
+		notification := authed.Group("/notifications", requireMember)
+		{
+			notification.GET("", cfg.NotificationHandler.GetAll)
+			notification.GET("/unread-count", cfg.NotificationHandler.GetUnreadCount)
+			notification.POST("/read", cfg.NotificationHandler.MarkRead)
+			notification.POST("/read-all", cfg.NotificationHandler.MarkAllRead)
+			notification.POST("/:id/read", cfg.NotificationHandler.MarkRead)
+		}
+
+		// Outbound channels (webhook / email / chat bot) of the current user
+		notificationChannel := authed.Group("/notification-channels", requireMember)
+		{
+			notificationChannel.GET("", cfg.NotificationChannelHandler.GetAll)
+			notificationChannel.GET("/deliveries", cfg.NotificationChannelHandler.GetDeliveries)
+			notificationChannel.POST("", cfg.NotificationChannelHandler.Create)
+			notificationChannel.PUT("/:id", cfg.NotificationChannelHandler.Update)
+			notificationChannel.DELETE("/:id", cfg.NotificationChannelHandler.Delete)
+			notificationChannel.POST("/:id/test", cfg.NotificationChannelHandler.Test)
+		}
+
+		// ==================== ALARM ENDPOINTS ====================
+		alarm := authed.Group("/alarm", requireMember)
+		{
+			alarm.GET("", cfg.AlarmHandler.GetStatus)
+			alarm.PUT("", cfg.AlarmHandler.SetState)
+			alarm.GET("/events", cfg.AlarmHandler.GetEvents)
+			alarm.GET("/events/:id", cfg.AlarmHandler.GetEvent)
+		}
+
+		// ==================== USER ENDPOINTS ====================
+		user := authed.Group("/user")
+		{
+			// Admin-only: listing, role/status changes, deletion
+			user.GET("/", requireAdmin, cfg.UserHandler.GetAll)
+			user.PUT("/:id", requireAdmin, cfg.UserHandler.Update)
+			user.DELETE("/:id", requireAdmin, cfg.UserHandler.Delete)
+
+			// Own account (or admin)
+			self := user.Group("/:id", middleware.RequireSelfOrAdmin("id"))
+			{
+				self.GET("", cfg.UserHandler.GetByID)
+				self.PUT("/profile", cfg.UserHandler.UpdateProfile)
+				self.PUT("/password", cfg.UserHandler.ChangePassword)
+				self.POST("/re-enroll-face", cfg.UserHandler.ReEnrollFace)
+
+				// Personal keypad PIN
+				self.GET("/pin", cfg.UserHandler.GetPin)
+				self.PUT("/pin", cfg.UserHandler.SetPin)
+				self.POST("/pin/generate", cfg.UserHandler.GeneratePin)
+				self.DELETE("/pin", cfg.UserHandler.DeletePin)
+			}
 		}
 
 		// ==================== ADMIN ENDPOINTS ====================
-		admin := api.Group("/admin")
+		admin := authed.Group("/admin", requireAdmin)
 		{
 			// User Approval
 			admin.GET("/users/pending", cfg.AdminHandler.GetPendingUsers)
@@ -207,24 +367,79 @@ func InitRouter(cfg AppConfig) *gin.Engine {
 			// Universal PIN Management
 			admin.GET("/pin", cfg.AdminHandler.GetUniversalPin)
 			admin.POST("/pin", cfg.AdminHandler.SetUniversalPin)
+			admin.PUT("/pin/universal", cfg.AdminHandler.SetUniversalPinStatus)
+
+			// Guest Access Codes
+			admin.GET("/guest-codes", cfg.GuestCodeHandler.GetAll)
+			admin.POST("/guest-codes", cfg.GuestCodeHandler.Create)
+			admin.GET("/guest-codes/:id", cfg.GuestCodeHandler.GetByID)
+			admin.POST("/guest-codes/:id/revoke", cfg.GuestCodeHandler.Revoke)
+			admin.DELETE("/guest-codes/:id", cfg.GuestCodeHandler.Delete)
+
+			// Door Auto-Relock
+			admin.GET("/door/auto-relock", cfg.DoorHandler.GetAutoRelock)
+			admin.PUT("/door/auto-relock", cfg.DoorHandler.UpdateAutoRelock)
+
+			// Gas Emergency Playbook
+			admin.GET("/gas-playbook", cfg.GasIncidentHandler.GetPlaybook)
+			admin.PUT("/gas-playbook", cfg.GasIncidentHandler.UpdatePlaybook)
+
+			// Outgoing webhooks (signed domain events)
+			admin.GET("/webhooks", cfg.WebhookHandler.GetEndpoints)
+			admin.POST("/webhooks", cfg.WebhookHandler.CreateEndpoint)
+			admin.GET("/webhooks/thresholds", cfg.WebhookHandler.GetThresholds)
+			admin.PUT("/webhooks/thresholds", cfg.WebhookHandler.UpdateThresholds)
+			admin.GET("/webhooks/deliveries", cfg.WebhookHandler.GetDeliveries)
+			admin.GET("/webhooks/dead-letters", cfg.WebhookHandler.GetDeadLetters)
+			admin.GET("/webhooks/deliveries/:id", cfg.WebhookHandler.GetDelivery)
+			admin.POST("/webhooks/deliveries/:id/redeliver", cfg.WebhookHandler.Redeliver)
+			admin.GET("/webhooks/:id", cfg.WebhookHandler.GetEndpoint)
+			admin.PUT("/webhooks/:id", cfg.WebhookHandler.UpdateEndpoint)
+			admin.DELETE("/webhooks/:id", cfg.WebhookHandler.DeleteEndpoint)
+			admin.POST("/webhooks/:id/rotate-secret", cfg.WebhookHandler.RotateSecret)
 		}
 
 		// ==================== ACCESS LOG ENDPOINTS ====================
-		accessLog := api.Group("/access-log")
+		accessLog := authed.Group("/access-log")
 		{
-			accessLog.POST("/", cfg.AccessLogHandler.Create)
-			accessLog.GET("/", cfg.AccessLogHandler.GetAll)
-			accessLog.GET("/user/:user_id", cfg.AccessLogHandler.GetByUserID)
-			accessLog.GET("/status/:status", cfg.AccessLogHandler.GetByStatus)
+			accessLog.GET("/", requireAdmin, cfg.AccessLogHandler.GetAll)
+			accessLog.GET("/user/:user_id", middleware.RequireSelfOrAdmin("user_id"), cfg.AccessLogHandler.GetByUserID)
+			accessLog.GET("/status/:status", requireAdmin, cfg.AccessLogHandler.GetByStatus)
 		}
 
 		// ==================== FACE RECOGNITION ENDPOINTS ====================
-		face := api.Group("/face")
+		face := authed.Group("/face", requireAdmin)
 		{
-			face.POST("/recognize", cfg.FaceHandler.RecognizeFace)
 			face.POST("/enroll", cfg.FaceHandler.EnrollFace)
 			face.POST("/reload", cfg.FaceHandler.ReloadFaces)
-			face.GET("/logs", cfg.FaceHandler.GetAccessLogs)
+			face.GET("/logs", cfg.FaceHandler.GetLogs)
+			face.GET("/alerts", cfg.FaceHandler.GetAlerts)
+			face.POST("/alerts/resolve", cfg.FaceHandler.ResolveAlerts)
+			face.GET("/alerts/:id", cfg.FaceHandler.GetAlert)
+			face.POST("/alerts/:id/resolve", cfg.FaceHandler.ResolveAlerts)
+		}
+
+		// ==================== FINGERPRINT ENDPOINTS ====================
+		fingerprint := authed.Group("/fingerprints")
+		{
+			fingerprint.GET("", requireAdmin, cfg.FingerprintHandler.GetAll)
+			fingerprint.GET("/user/:user_id", middleware.RequireSelfOrAdmin("user_id"), cfg.FingerprintHandler.GetByUserID)
+			fingerprint.POST("/enroll", requireAdmin, cfg.FingerprintHandler.StartEnrollment)
+			fingerprint.GET("/enrollments/:id", requireAdmin, cfg.FingerprintHandler.GetEnrollment)
+			fingerprint.DELETE("/:id", requireAdmin, cfg.FingerprintHandler.Delete)
+		}
+
+		// ==================== CAMERA ENDPOINTS ====================
+		camera := authed.Group("/camera")
+		{
+			// Live view is proxied through the backend (camera LAN IP never leaves the server)
+			camera.GET("", requireMember, cfg.CameraHandler.GetAll)
+			camera.GET("/:id", requireMember, cfg.CameraHandler.GetByID)
+			camera.GET("/:id/snapshot", requireMember, cfg.CameraHandler.Snapshot)
+			camera.GET("/:id/stream", requireMember, cfg.CameraHandler.Stream)
+
+			// Admins see every frame, other users only frames of themselves
+			camera.GET("/captures/:id/image", cfg.CameraHandler.GetCaptureImage)
 		}
 	}
 
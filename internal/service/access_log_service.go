@@ -14,11 +14,12 @@ type AccessLogService interface {
 }
 
 type accessLogService struct {
-	repo repository.AccessLogRepository
+	repo   repository.AccessLogRepository
+	events EventPublisher
 }
 
-func NewAccessLogService(r repository.AccessLogRepository) AccessLogService {
-	return &accessLogService{repo: r}
+func NewAccessLogService(r repository.AccessLogRepository, events EventPublisher) AccessLogService {
+	return &accessLogService{repo: r, events: events}
 }
 
 func (s *accessLogService) LogAccess(req models.AccessLogRequest) error {
@@ -27,6 +28,7 @@ func (s *accessLogService) LogAccess(req models.AccessLogRequest) error {
 		Method:    req.Method,
 		Status:    req.Status,
 		ImagePath: req.ImagePath,
+		CaptureID: req.CaptureID,
 	}
 
 	err := s.repo.Create(accessLog)
@@ -36,6 +38,7 @@ func (s *accessLogService) LogAccess(req models.AccessLogRequest) error {
 	}
 
 	log.Printf("Access logged: method=%s, status=%s", req.Method, req.Status)
+	s.events.Publish(models.EventAccessLog, accessLog)
 	return nil
 }
 
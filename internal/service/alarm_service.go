@@ -0,0 +1,211 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"smarthome-backend/database/models"
+	"smarthome-backend/internal/repository"
+	"sync"
+	"time"
+)
+
+var ErrInvalidAlarmTransition = errors.New("invalid alarm state transition")
+
+type AlarmService interface {
+	GetStatus() models.AlarmStatus
+	SetState(state string, userID *uint) (models.AlarmStatus, error)
+	GetEvents(limit int) ([]models.AlarmEvent, error)
+	GetEvent(id uint) (*models.AlarmEvent, error)
+
+	// Intrusion hooks
+	OnDoorUnlocked(method string, commanded bool)
+	OnUnknownFace(image string)
+
+	// SetBuzzer wires the siren once the MQTT handler exists
+	SetBuzzer(buzzer Buzzer)
+}
+
+type alarmService struct {
+	repo     repository.AlarmRepository
+	notifSvc NotificationService
+
+	mu     sync.Mutex
+	status models.AlarmStatus
+	siren  Buzzer
+}
+
+func NewAlarmService(r repository.AlarmRepository, notifSvc NotificationService) AlarmService {
+	s := &alarmService{
+		repo:     r,
+		notifSvc: notifSvc,
+		status:   models.AlarmStatus{State: models.AlarmDisarmed},
+	}
+	s.restore()
+	return s
+}
+
+// restore loads the last persisted state so a restart doesn't silently disarm
+func (s *alarmService) restore() {
+	latest, err := s.repo.GetLatest()
+	if err != nil {
+		return
+	}
+	since := latest.CreatedAt
+	s.status = models.AlarmStatus{State: latest.State, Since: &since, ChangedBy: latest.UserID}
+
+	if latest.State == models.AlarmTriggered {
+		s.status.TriggerReason = latest.Reason
+		if armed, err := s.repo.GetLatestArmed(); err == nil {
+			s.status.ArmedState = armed.State
+		}
+	}
+	log.Printf("[ALARM] Restored state: %s", s.status.State)
+}
+
+func (s *alarmService) SetBuzzer(buzzer Buzzer) {
+	s.mu.Lock()
+	s.siren = buzzer
+	s.mu.Unlock()
+}
+
+func (s *alarmService) GetStatus() models.AlarmStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+func (s *alarmService) GetEvents(limit int) ([]models.AlarmEvent, error) {
+	return s.repo.GetEvents(limit)
+}
+
+func (s *alarmService) GetEvent(id uint) (*models.AlarmEvent, error) {
+	return s.repo.GetByID(id)
+}
+
+// SetState - Arm (disarmed → armed_*), switch armed mode, or disarm.
+// A triggered alarm can only be disarmed, which also silences the buzzer.
+func (s *alarmService) SetState(state string, userID *uint) (models.AlarmStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := s.status.State
+	switch state {
+	case models.AlarmDisarmed:
+	case models.AlarmArmedHome, models.AlarmArmedAway:
+		if current == models.AlarmTriggered {
+			return s.status, fmt.Errorf("%w: disarm the triggered alarm first", ErrInvalidAlarmTransition)
+		}
+	default:
+		return s.status, fmt.Errorf("%w: unknown state %q", ErrInvalidAlarmTransition, state)
+	}
+	if state == current {
+		return s.status, nil
+	}
+
+	event := &models.AlarmEvent{
+		State:         state,
+		PreviousState: current,
+		Source:        "api",
+		UserID:        userID,
+	}
+	if err := s.repo.Create(event); err != nil {
+		return s.status, err
+	}
+
+	since := event.CreatedAt
+	s.status = models.AlarmStatus{State: state, Since: &since, ChangedBy: userID}
+
+	if current == models.AlarmTriggered && s.siren != nil {
+		s.siren.PublishBuzzerControl(models.BuzzerCommand{
+			Action: "off",
+			Source: models.BuzzerSourceAlarm,
+			Reason: "Alarm disarmed",
+			UserID: userID,
+		})
+	}
+
+	log.Printf("[ALARM] %s → %s", current, state)
+	return s.status, nil
+}
+
+// OnDoorUnlocked - Only unlocks confirming a backend command (PIN, face, fingerprint,
+// authenticated API user, scene, ...) are authorized; a status report merely naming
+// one of those methods proves nothing. Anything else, like a key or a forced lock,
+// trips the alarm while armed_away (residents come and go in armed_home).
+func (s *alarmService) OnDoorUnlocked(method string, commanded bool) {
+	if commanded {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.status.State != models.AlarmArmedAway {
+		return
+	}
+	if method == "" {
+		method = "unknown"
+	}
+	s.tripLocked(fmt.Sprintf("Door unlocked without authorization (method: %s)", method), "door", nil)
+}
+
+// OnUnknownFace - Unknown faces only trip armed_away (visitors are expected when home)
+func (s *alarmService) OnUnknownFace(image string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.status.State != models.AlarmArmedAway {
+		return
+	}
+
+	var snapshot *string
+	if image != "" {
+		snapshot = &image
+	}
+	s.tripLocked("Unknown face detected at the door", "face", snapshot)
+}
+
+// tripLocked must be called with s.mu held
+func (s *alarmService) tripLocked(reason, source string, image *string) {
+	armed := s.status.State
+
+	event := &models.AlarmEvent{
+		State:         models.AlarmTriggered,
+		PreviousState: armed,
+		Reason:        reason,
+		Source:        source,
+		ImageBase64:   image,
+	}
+	if err := s.repo.Create(event); err != nil {
+		log.Printf("[ALARM] Failed to save alarm event: %v", err)
+		event.CreatedAt = time.Now()
+	}
+
+	since := event.CreatedAt
+	s.status = models.AlarmStatus{
+		State:         models.AlarmTriggered,
+		ArmedState:    armed,
+		Since:         &since,
+		TriggerReason: reason,
+	}
+	log.Printf("[ALARM] TRIGGERED: %s", reason)
+
+	if s.siren != nil {
+		s.siren.PublishBuzzerControl(models.BuzzerCommand{
+			Action: "on",
+			Source: models.BuzzerSourceAlarm,
+			Reason: reason,
+		})
+	}
+
+	go func() {
+		if err := s.notifSvc.Create(models.NotificationRequest{
+			Title:   "Intruder Alert",
+			Message: reason,
+			Type:    "intruder",
+		}); err != nil {
+			log.Printf("[ALARM] Failed to create notification: %v", err)
+		}
+	}()
+}
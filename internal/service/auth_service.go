@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"smarthome-backend/database/models"
@@ -17,14 +18,14 @@ type AuthService interface {
 }
 
 type authService struct {
-	pythonClient *PythonFaceClient
-	jwtSecret    string
+	faceClient FaceRecognizer
+	jwtSecret  string
 }
 
-func NewAuthService(pythonURL string, secret string) AuthService {
+func NewAuthService(faceClient FaceRecognizer, secret string) AuthService {
 	return &authService{
-		pythonClient: NewPythonFaceClient(pythonURL),
-		jwtSecret:    secret,
+		faceClient: faceClient,
+		jwtSecret:  secret,
 	}
 }
 
@@ -54,11 +55,19 @@ func (s *authService) ValidateToken(tokenString string) (*models.User, error) {
 	}
 
 	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
+		userID, ok := claims["user_id"].(float64)
+		if !ok {
+			return nil, errors.New("invalid token claims")
+		}
+		email, _ := claims["email"].(string)
+		name, _ := claims["name"].(string)
+		role, _ := claims["role"].(string)
+
 		user := &models.User{
-			UserID: uint(claims["user_id"].(float64)),
-			Email:  claims["email"].(string),
-			Name:   claims["name"].(string),
-			Role:   claims["role"].(string),
+			UserID: uint(userID),
+			Email:  email,
+			Name:   name,
+			Role:   role,
 		}
 		return user, nil
 	}
@@ -67,20 +76,20 @@ func (s *authService) ValidateToken(tokenString string) (*models.User, error) {
 }
 
 func (s *authService) EnrollFaceWithPython(userID uint, name string, faceImage string) (string, error) {
-	resp, err := s.pythonClient.EnrollFace(int(userID), name, faceImage)
+	resp, err := s.faceClient.EnrollFace(context.Background(), int(userID), name, faceImage)
 	if err != nil {
 		return "", fmt.Errorf("failed to enroll face: %w", err)
 	}
 
 	if !resp.Success {
-		return "", errors.New(resp.Message)
+		return "", resp.failure()
 	}
 
 	return resp.File, nil
 }
 
 func (s *authService) ValidateFaceWithPython(faceImage string) (bool, error) {
-	resp, err := s.pythonClient.ValidateFace(faceImage)
+	resp, err := s.faceClient.ValidateFace(context.Background(), faceImage)
 	if err != nil {
 		return false, fmt.Errorf("failed to validate face: %w", err)
 	}
@@ -0,0 +1,347 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"smarthome-backend/database/models"
+	"smarthome-backend/internal/repository"
+	"sync"
+	"time"
+)
+
+type AutomationService interface {
+	Create(req models.AutomationRuleRequest, createdBy *uint) (*models.AutomationRule, error)
+	Update(id uint, req models.AutomationRuleRequest) (*models.AutomationRule, error)
+	Delete(id uint) error
+	GetByID(id uint) (*models.AutomationRule, error)
+	GetAll() ([]models.AutomationRule, error)
+	SetEnabled(id uint, enabled bool) error
+	GetLogs(ruleID *uint, limit int) ([]models.AutomationRuleLog, error)
+
+	// OnReading is called by the MQTT handler for every sensor reading
+	OnReading(sensor string, value float64)
+	Reload() error
+}
+
+const defaultRuleCooldown = 300
+
+// ruleState is the in-memory evaluation state of one rule
+type ruleState struct {
+	trueSince map[int]time.Time // condition index → since when it holds
+	fired     bool              // fired for the current "satisfied" period (edge-triggered)
+}
+
+type automationService struct {
+	repo     repository.AutomationRepository
+	actuator DeviceActuator
+
+	mu     sync.Mutex
+	rules  []models.AutomationRule
+	states map[uint]*ruleState
+	latest map[string]float64 // last value per sensor
+}
+
+func NewAutomationService(r repository.AutomationRepository, actuator DeviceActuator) AutomationService {
+	return &automationService{
+		repo:     r,
+		actuator: actuator,
+		states:   make(map[uint]*ruleState),
+		latest:   make(map[string]float64),
+	}
+}
+
+func (s *automationService) Create(req models.AutomationRuleRequest, createdBy *uint) (*models.AutomationRule, error) {
+	rule := &models.AutomationRule{CreatedBy: createdBy}
+	if err := applyRuleRequest(rule, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(rule); err != nil {
+		return nil, err
+	}
+	return rule, s.Reload()
+}
+
+func (s *automationService) Update(id uint, req models.AutomationRuleRequest) (*models.AutomationRule, error) {
+	rule, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyRuleRequest(rule, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(rule); err != nil {
+		return nil, err
+	}
+
+	s.resetState(id)
+	return rule, s.Reload()
+}
+
+func (s *automationService) Delete(id uint) error {
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+	s.resetState(id)
+	return s.Reload()
+}
+
+func (s *automationService) GetByID(id uint) (*models.AutomationRule, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *automationService) GetAll() ([]models.AutomationRule, error) {
+	return s.repo.GetAll()
+}
+
+func (s *automationService) SetEnabled(id uint, enabled bool) error {
+	if _, err := s.repo.GetByID(id); err != nil {
+		return err
+	}
+	if err := s.repo.SetEnabled(id, enabled); err != nil {
+		return err
+	}
+	s.resetState(id)
+	return s.Reload()
+}
+
+func (s *automationService) GetLogs(ruleID *uint, limit int) ([]models.AutomationRuleLog, error) {
+	return s.repo.GetLogs(ruleID, limit)
+}
+
+// Reload refreshes the cached enabled rules (called after every change and at startup)
+func (s *automationService) Reload() error {
+	rules, err := s.repo.GetEnabled()
+	if err != nil {
+		return fmt.Errorf("failed to load automation rules: %w", err)
+	}
+
+	s.mu.Lock()
+	// MarkFired runs in the background; keep the newer in-memory timestamp for cooldowns
+	lastFired := make(map[uint]*time.Time, len(s.rules))
+	for _, rule := range s.rules {
+		lastFired[rule.ID] = rule.LastFiredAt
+	}
+	for i := range rules {
+		if prev := lastFired[rules[i].ID]; prev != nil &&
+			(rules[i].LastFiredAt == nil || prev.After(*rules[i].LastFiredAt)) {
+			rules[i].LastFiredAt = prev
+		}
+	}
+	s.rules = rules
+	s.mu.Unlock()
+
+	log.Printf("[AUTOMATION] %d rule(s) active", len(rules))
+	return nil
+}
+
+func (s *automationService) resetState(id uint) {
+	s.mu.Lock()
+	delete(s.states, id)
+	s.mu.Unlock()
+}
+
+// OnReading evaluates every enabled rule. Rules are edge-triggered: they fire
+// once when all conditions become true and re-arm once a condition turns false.
+// Actions run in the background so MQTT callbacks never block on publishing.
+func (s *automationService) OnReading(sensor string, value float64) {
+	now := time.Now()
+	var toFire []models.AutomationRule
+
+	s.mu.Lock()
+	s.latest[sensor] = value
+
+	for i := range s.rules {
+		rule := &s.rules[i]
+		state := s.states[rule.ID]
+		if state == nil {
+			state = &ruleState{trueSince: make(map[int]time.Time)}
+			s.states[rule.ID] = state
+		}
+
+		if !s.evaluate(rule, state, now) {
+			state.fired = false
+			continue
+		}
+		if state.fired {
+			continue
+		}
+		if rule.LastFiredAt != nil && now.Sub(*rule.LastFiredAt) < time.Duration(rule.CooldownSeconds)*time.Second {
+			// Still cooling down: stay armed and fire on a later reading
+			continue
+		}
+
+		state.fired = true
+		firedAt := now
+		rule.LastFiredAt = &firedAt
+		toFire = append(toFire, *rule)
+	}
+	s.mu.Unlock()
+
+	for _, rule := range toFire {
+		go s.fire(rule, sensor, value, now)
+	}
+}
+
+// evaluate must be called with s.mu held; it also updates "for N seconds" tracking
+func (s *automationService) evaluate(rule *models.AutomationRule, state *ruleState, now time.Time) bool {
+	satisfied := true
+
+	for i, cond := range rule.Conditions {
+		switch cond.Type {
+		case "sensor":
+			value, known := s.latest[cond.Sensor]
+			if !known || !compare(value, cond.Operator, cond.Value) {
+				delete(state.trueSince, i)
+				satisfied = false
+				continue
+			}
+
+			since, ok := state.trueSince[i]
+			if !ok {
+				since = now
+				state.trueSince[i] = now
+			}
+			if now.Sub(since) < time.Duration(cond.DurationSeconds)*time.Second {
+				satisfied = false
+			}
+		case "time":
+			if !inTimeWindow(cond.After, cond.Before, cond.Days, now) {
+				satisfied = false
+			}
+		default:
+			satisfied = false
+		}
+	}
+
+	return satisfied
+}
+
+func (s *automationService) fire(rule models.AutomationRule, sensor string, value float64, firedAt time.Time) {
+	log.Printf("[AUTOMATION] Rule %q fired (%s=%.2f)", rule.Name, sensor, value)
+
+	if err := s.repo.MarkFired(rule.ID, firedAt); err != nil {
+		log.Printf("[AUTOMATION] Failed to update rule %d: %v", rule.ID, err)
+	}
+
+	ruleID := rule.ID
+	results := make(models.RuleActionResults, 0, len(rule.Actions))
+	failed := 0
+
+	for _, action := range rule.Actions {
+		result := models.RuleActionResult{Device: action.Device, Action: action.Action}
+
+		cmd, err := s.actuator.Send(commandRequest(action.Device, action.Action, action.Mode, "automation", &ruleID, nil))
+		if cmd != nil {
+			result.CorrelationID = cmd.CorrelationID
+			result.Status = cmd.Status
+		}
+		if err != nil {
+			failed++
+			result.Status = "failed"
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+
+	status := "success"
+	if failed == len(results) {
+		status = "failed"
+	} else if failed > 0 {
+		status = "partial"
+	}
+
+	entry := &models.AutomationRuleLog{
+		RuleID:       rule.ID,
+		RuleName:     rule.Name,
+		TriggerType:  sensor,
+		TriggerValue: value,
+		Status:       status,
+		Results:      results,
+		FiredAt:      firedAt,
+	}
+	if err := s.repo.CreateLog(entry); err != nil {
+		log.Printf("[AUTOMATION] Failed to save rule log: %v", err)
+	}
+}
+
+func applyRuleRequest(rule *models.AutomationRule, req models.AutomationRuleRequest) error {
+	for i, cond := range req.Conditions {
+		switch cond.Type {
+		case "sensor":
+			if cond.Sensor == "" || cond.Operator == "" {
+				return fmt.Errorf("condition %d: sensor and operator are required", i+1)
+			}
+		case "time":
+			if cond.After == "" && cond.Before == "" && len(cond.Days) == 0 {
+				return fmt.Errorf("condition %d: after, before or days is required", i+1)
+			}
+		}
+	}
+	for i, action := range req.Actions {
+		if err := validateDeviceAction(action.Device, action.Action, false); err != nil {
+			return fmt.Errorf("action %d: %w", i+1, err)
+		}
+	}
+
+	rule.Name = req.Name
+	rule.Conditions = req.Conditions
+	rule.Actions = req.Actions
+	rule.Enabled = true
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	rule.CooldownSeconds = defaultRuleCooldown
+	if req.CooldownSeconds != nil {
+		rule.CooldownSeconds = *req.CooldownSeconds
+	}
+	return nil
+}
+
+func compare(value float64, operator string, target float64) bool {
+	switch operator {
+	case ">":
+		return value > target
+	case ">=":
+		return value >= target
+	case "<":
+		return value < target
+	case "<=":
+		return value <= target
+	case "==":
+		return value == target
+	case "!=":
+		return value != target
+	}
+	return false
+}
+
+// inTimeWindow checks optional weekdays and an "HH:MM" window (after > before spans midnight)
+func inTimeWindow(after, before string, days []string, now time.Time) bool {
+	if len(days) > 0 {
+		today := weekdayNames[now.Weekday()]
+		allowed := false
+		for _, day := range days {
+			if day == today {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+
+	current := now.Format("15:04")
+	switch {
+	case after != "" && before != "":
+		if after <= before {
+			return current >= after && current < before
+		}
+		return current >= after || current < before
+	case after != "":
+		return current >= after
+	case before != "":
+		return current < before
+	}
+	return true
+}
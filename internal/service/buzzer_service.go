@@ -0,0 +1,121 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"smarthome-backend/database/models"
+	"smarthome-backend/internal/repository"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// A status report within this window after a command confirms it instead of
+// being logged as a separate device-side change
+const buzzerConfirmWindow = 30 * time.Second
+
+type BuzzerService interface {
+	// Record logs a command the backend sent (manual, gas, alarm)
+	Record(cmd models.BuzzerCommand) (*models.BuzzerLog, error)
+	// RecordFailure marks a logged command that never reached the broker
+	RecordFailure(entry *models.BuzzerLog, err error)
+	// OnStateReport handles a state change reported on buzzer/status
+	OnStateReport(status string) (*models.BuzzerLog, error)
+	GetState() models.BuzzerState
+	GetHistory(filter models.BuzzerLogFilter) ([]models.BuzzerLog, error)
+}
+
+type buzzerService struct {
+	repo repository.BuzzerRepository
+
+	mu    sync.Mutex
+	state models.BuzzerState
+}
+
+func NewBuzzerService(repo repository.BuzzerRepository) BuzzerService {
+	return &buzzerService{
+		repo:  repo,
+		state: models.BuzzerState{Status: "off"},
+	}
+}
+
+func (s *buzzerService) Record(cmd models.BuzzerCommand) (*models.BuzzerLog, error) {
+	entry := &models.BuzzerLog{
+		Status:    cmd.Action,
+		Source:    cmd.Source,
+		Reason:    truncate(cmd.Reason, 200),
+		UserID:    cmd.UserID,
+		Timestamp: time.Now(),
+	}
+	if err := s.repo.Create(entry); err != nil {
+		log.Printf("[BUZZER] Failed to log %s (%s): %v", cmd.Action, cmd.Source, err)
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (s *buzzerService) RecordFailure(entry *models.BuzzerLog, err error) {
+	entry.Error = truncate(err.Error(), 255)
+	if err := s.repo.MarkFailed(entry.BuzzerID, entry.Error); err != nil {
+		log.Printf("[BUZZER] Failed to mark %s (%s) as failed: %v", entry.Status, entry.Source, err)
+	}
+}
+
+// OnStateReport confirms the matching command, or logs the change as coming
+// from the device itself (local trigger, reboot, ...)
+func (s *buzzerService) OnStateReport(status string) (*models.BuzzerLog, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	s.state.ReportedAt = &now
+	changed := s.state.Status != status
+	if changed {
+		s.state.Status = status
+		s.state.Since = &now
+	}
+	s.mu.Unlock()
+
+	if !changed {
+		return nil, nil
+	}
+
+	pending, err := s.repo.GetPendingCommand(status, now.Add(-buzzerConfirmWindow))
+	if err == nil {
+		if err := s.repo.Confirm(pending.BuzzerID, now); err != nil {
+			return nil, err
+		}
+		pending.ConfirmedAt = &now
+		return pending, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	entry := &models.BuzzerLog{
+		Status:      status,
+		Source:      models.BuzzerSourceDevice,
+		Reason:      "Reported by device",
+		ConfirmedAt: &now,
+		Timestamp:   now,
+	}
+	if err := s.repo.Create(entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (s *buzzerService) GetState() models.BuzzerState {
+	s.mu.Lock()
+	state := s.state
+	s.mu.Unlock()
+
+	if latest, err := s.repo.GetLatest(); err == nil {
+		state.LastLog = latest
+	}
+	return state
+}
+
+func (s *buzzerService) GetHistory(filter models.BuzzerLogFilter) ([]models.BuzzerLog, error) {
+	return s.repo.GetHistory(filter)
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"smarthome-backend/database/models"
+	"smarthome-backend/internal/repository"
+	"strings"
+	"time"
+)
+
+var (
+	ErrCaptureInvalidImage = errors.New("image is not valid base64 JPEG/PNG data")
+	ErrCaptureTooLarge     = errors.New("image exceeds the maximum capture size")
+)
+
+const (
+	captureRetentionInterval = time.Hour
+	captureRetentionBatch    = 200
+)
+
+// captureExtensions - content types accepted from the ESP32-CAM
+var captureExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+}
+
+// CameraCapturePolicy - zero Retention keeps captures forever
+type CameraCapturePolicy struct {
+	Dir       string
+	MaxBytes  int
+	Retention time.Duration
+}
+
+type CameraCaptureService interface {
+	Save(imageBase64, detectedFace string, userID *uint) (*models.CameraCapture, error)
+	GetByID(id uint) (*models.CameraCapture, error)
+	CanView(user *models.User, capture *models.CameraCapture) (bool, error)
+	FilePath(capture *models.CameraCapture) string
+	Purge(cutoff time.Time) (int, error)
+	StartRetention()
+}
+
+type cameraCaptureService struct {
+	repo   repository.CameraCaptureRepository
+	policy CameraCapturePolicy
+}
+
+func NewCameraCaptureService(repo repository.CameraCaptureRepository, policy CameraCapturePolicy) CameraCaptureService {
+	if policy.Dir == "" {
+		policy.Dir = "./uploads/camera_captures"
+	}
+	if policy.MaxBytes <= 0 {
+		policy.MaxBytes = 5 << 20
+	}
+	if err := os.MkdirAll(policy.Dir, 0o755); err != nil {
+		log.Printf("[CAPTURE] Failed to create %s: %v", policy.Dir, err)
+	}
+	return &cameraCaptureService{repo: repo, policy: policy}
+}
+
+// Save decodes the frame, writes it as <sha256>.<ext> (once per content) and records a capture row
+func (s *cameraCaptureService) Save(imageBase64, detectedFace string, userID *uint) (*models.CameraCapture, error) {
+	data, err := decodeCaptureImage(imageBase64)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > s.policy.MaxBytes {
+		return nil, ErrCaptureTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := captureExtensions[contentType]
+	if !ok {
+		return nil, ErrCaptureInvalidImage
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	name := hash + ext
+	if err := s.writeOnce(name, data); err != nil {
+		return nil, err
+	}
+
+	capture := &models.CameraCapture{
+		ImagePath:    name,
+		DetectedFace: truncate(detectedFace, 100),
+		UserID:       userID,
+		ContentHash:  hash,
+		ContentType:  contentType,
+		SizeBytes:    len(data),
+		Timestamp:    time.Now(),
+	}
+	if err := s.repo.Create(capture); err != nil {
+		return nil, err
+	}
+	return capture, nil
+}
+
+// writeOnce skips the write when the same content is already on disk; the
+// temp file + rename keeps a half-written frame from ever being served
+func (s *cameraCaptureService) writeOnce(name string, data []byte) error {
+	target := filepath.Join(s.policy.Dir, name)
+	if _, err := os.Stat(target); err == nil {
+		return nil
+	}
+
+	tmp, err := os.CreateTemp(s.policy.Dir, ".capture-*")
+	if err != nil {
+		return fmt.Errorf("create capture file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write capture file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write capture file: %w", err)
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+func (s *cameraCaptureService) GetByID(id uint) (*models.CameraCapture, error) {
+	return s.repo.GetByID(id)
+}
+
+// CanView - admins see every capture, other users only frames of themselves
+func (s *cameraCaptureService) CanView(user *models.User, capture *models.CameraCapture) (bool, error) {
+	if user == nil {
+		return false, nil
+	}
+	if user.Role == "admin" {
+		return true, nil
+	}
+	if capture.UserID != nil && *capture.UserID == user.UserID {
+		return true, nil
+	}
+	return s.repo.IsLinkedToUser(capture.CaptureID, user.UserID)
+}
+
+// FilePath resolves the stored name inside the upload dir (never outside it)
+func (s *cameraCaptureService) FilePath(capture *models.CameraCapture) string {
+	return filepath.Join(s.policy.Dir, filepath.Base(capture.ImagePath))
+}
+
+// Purge deletes captures taken before cutoff; files go once no remaining row shares them
+func (s *cameraCaptureService) Purge(cutoff time.Time) (int, error) {
+	purged := 0
+	for {
+		batch, err := s.repo.GetOlderThan(cutoff, captureRetentionBatch)
+		if err != nil {
+			return purged, err
+		}
+		if len(batch) == 0 {
+			return purged, nil
+		}
+
+		ids := make([]uint, 0, len(batch))
+		paths := make(map[string]struct{})
+		for _, capture := range batch {
+			ids = append(ids, capture.CaptureID)
+			paths[capture.ImagePath] = struct{}{}
+		}
+		if err := s.repo.DeleteByIDs(ids); err != nil {
+			return purged, err
+		}
+		purged += len(ids)
+
+		for path := range paths {
+			remaining, err := s.repo.CountByPath(path)
+			if err != nil || remaining > 0 {
+				continue
+			}
+			file := filepath.Join(s.policy.Dir, filepath.Base(path))
+			if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+				log.Printf("[CAPTURE] Failed to remove %s: %v", file, err)
+			}
+		}
+
+		if len(batch) < captureRetentionBatch {
+			return purged, nil
+		}
+	}
+}
+
+// StartRetention purges expired captures now and then every hour
+func (s *cameraCaptureService) StartRetention() {
+	if s.policy.Retention <= 0 {
+		log.Println("[CAPTURE] Retention disabled, captures are kept forever")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(captureRetentionInterval)
+		defer ticker.Stop()
+		for {
+			purged, err := s.Purge(time.Now().Add(-s.policy.Retention))
+			if err != nil {
+				log.Printf("[CAPTURE] Retention run failed: %v", err)
+			} else if purged > 0 {
+				log.Printf("[CAPTURE] Retention removed %d capture(s)", purged)
+			}
+			<-ticker.C
+		}
+	}()
+	log.Printf("[CAPTURE] Retention job started (keep %s)", s.policy.Retention)
+}
+
+// decodeCaptureImage strips an optional data URI prefix and decodes the payload
+func decodeCaptureImage(imageBase64 string) ([]byte, error) {
+	payload := strings.TrimSpace(imageBase64)
+	if strings.HasPrefix(payload, "data:") {
+		idx := strings.Index(payload, ",")
+		if idx < 0 {
+			return nil, ErrCaptureInvalidImage
+		}
+		payload = payload[idx+1:]
+	}
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil || len(data) == 0 {
+		return nil, ErrCaptureInvalidImage
+	}
+	return data, nil
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"smarthome-backend/database/models"
+	"smarthome-backend/internal/repository"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrInvalidCameraAddress = errors.New("camera address must be a private LAN IP address")
+	ErrInvalidCameraPort    = errors.New("camera stream port is not an allowed camera port")
+	ErrCameraUnreachable    = errors.New("camera is not reachable")
+)
+
+// ESP32-CAM CameraWebServer: still image on :80/capture, MJPEG on :<stream_port>/stream
+const (
+	cameraSnapshotPath    = "/capture"
+	cameraSnapshotPort    = 80
+	cameraStreamPath      = "/stream"
+	cameraDefaultPort     = 81
+	cameraSnapshotTimeout = 10 * time.Second
+	cameraConnectTimeout  = 5 * time.Second
+)
+
+var cameraDeviceIDPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// Ports the CameraWebServer firmware streams on; the announcement comes over the
+// public broker, so nothing else may be reached through the proxy
+var cameraStreamPorts = map[int]bool{80: true, 81: true}
+
+// CameraView is what the API returns: no LAN address, only backend URLs
+type CameraView struct {
+	models.Camera
+	SnapshotURL string `json:"snapshot_url"`
+	StreamURL   string `json:"stream_url"`
+}
+
+type CameraService interface {
+	Announce(ann models.CameraAnnouncement) (*models.Camera, error)
+	GetAll() ([]CameraView, error)
+	GetByID(id uint) (*CameraView, error)
+	OpenSnapshot(ctx context.Context, id uint) (*http.Response, error)
+	OpenStream(ctx context.Context, id uint) (*http.Response, error)
+}
+
+type cameraService struct {
+	repo repository.CameraRepository
+
+	snapshotClient *http.Client
+	streamClient   *http.Client // no overall timeout, the stream ends with the viewer's request
+}
+
+func NewCameraService(repo repository.CameraRepository) CameraService {
+	// Checked again at dial time, whatever address the URL ends up resolving to
+	dialer := &net.Dialer{Timeout: cameraConnectTimeout, Control: cameraDialControl}
+	noRedirect := func(req *http.Request, via []*http.Request) error { return http.ErrUseLastResponse }
+	return &cameraService{
+		repo: repo,
+		snapshotClient: &http.Client{
+			Timeout:       cameraSnapshotTimeout,
+			Transport:     &http.Transport{DialContext: dialer.DialContext},
+			CheckRedirect: noRedirect,
+		},
+		streamClient: &http.Client{
+			Transport: &http.Transport{
+				DialContext:           dialer.DialContext,
+				ResponseHeaderTimeout: cameraConnectTimeout,
+			},
+			CheckRedirect: noRedirect,
+		},
+	}
+}
+
+// Announce records the camera's current address and bumps last_seen_at
+func (s *cameraService) Announce(ann models.CameraAnnouncement) (*models.Camera, error) {
+	deviceID := strings.TrimSpace(ann.DeviceID)
+	if deviceID == "" {
+		deviceID = models.DefaultCameraDeviceID
+	}
+	if !cameraDeviceIDPattern.MatchString(deviceID) {
+		return nil, fmt.Errorf("invalid camera device_id %q", deviceID)
+	}
+
+	ip := strings.TrimSpace(ann.IP)
+	if !cameraAddressAllowed(net.ParseIP(ip)) {
+		return nil, ErrInvalidCameraAddress
+	}
+
+	port := ann.StreamPort
+	if port == 0 {
+		port = cameraDefaultPort
+	}
+	if !cameraStreamPorts[port] {
+		return nil, ErrInvalidCameraPort
+	}
+
+	camera := &models.Camera{
+		DeviceID:   deviceID,
+		Name:       truncate(strings.TrimSpace(ann.Name), 100),
+		IPAddress:  ip,
+		StreamPort: port,
+		LastSeenAt: time.Now(),
+	}
+	if camera.Name == "" {
+		camera.Name = deviceID
+	}
+	if err := s.repo.Upsert(camera); err != nil {
+		return nil, err
+	}
+	// The upsert does not report the id of an existing row
+	return s.repo.GetByDeviceID(deviceID)
+}
+
+func (s *cameraService) GetAll() ([]CameraView, error) {
+	cameras, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	views := make([]CameraView, 0, len(cameras))
+	for _, camera := range cameras {
+		views = append(views, cameraView(camera))
+	}
+	return views, nil
+}
+
+func (s *cameraService) GetByID(id uint) (*CameraView, error) {
+	camera, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	view := cameraView(*camera)
+	return &view, nil
+}
+
+// OpenSnapshot fetches one JPEG from the camera; the caller closes the body
+func (s *cameraService) OpenSnapshot(ctx context.Context, id uint) (*http.Response, error) {
+	camera, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("http://%s%s", net.JoinHostPort(camera.IPAddress, fmt.Sprint(cameraSnapshotPort)), cameraSnapshotPath)
+	return s.open(ctx, s.snapshotClient, url)
+}
+
+// OpenStream connects to the camera's MJPEG stream; it stays open until ctx is
+// cancelled (viewer disconnects) or the camera drops it. The caller closes the body.
+func (s *cameraService) OpenStream(ctx context.Context, id uint) (*http.Response, error) {
+	camera, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("http://%s%s", net.JoinHostPort(camera.IPAddress, fmt.Sprint(camera.StreamPort)), cameraStreamPath)
+	return s.open(ctx, s.streamClient, url)
+}
+
+func (s *cameraService) open(ctx context.Context, client *http.Client, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCameraUnreachable, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: camera answered %d", ErrCameraUnreachable, resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// cameraAddressAllowed - only private LAN unicast addresses (no loopback,
+// link-local/metadata, unspecified or multicast)
+func cameraAddressAllowed(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsUnspecified() || ip.IsMulticast() {
+		return false
+	}
+	return ip.IsPrivate()
+}
+
+// cameraDialControl rejects connections to anything but a camera address and port
+func cameraDialControl(network, address string, _ syscall.RawConn) error {
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return err
+	}
+	if !cameraAddressAllowed(net.ParseIP(host)) {
+		return ErrInvalidCameraAddress
+	}
+	if port != cameraSnapshotPort && !cameraStreamPorts[port] {
+		return ErrInvalidCameraPort
+	}
+	return nil
+}
+
+func cameraView(camera models.Camera) CameraView {
+	return CameraView{
+		Camera:      camera,
+		SnapshotURL: fmt.Sprintf("/api/camera/%d/snapshot", camera.ID),
+		StreamURL:   fmt.Sprintf("/api/camera/%d/stream", camera.ID),
+	}
+}
@@ -1,58 +1,44 @@
 package service
 
 import (
-	"log"
 	"smarthome-backend/database/models"
 	"smarthome-backend/internal/repository"
-	"time"
+	"sync"
 )
 
 type CurtainService interface {
-	ProcessCurtain(status string, mode string) error
+	ProcessCurtain(change models.DeviceStateChange) (*models.DeviceStateEvent, error)
 	GetLatest() (*models.CurtainStatus, error)
+	GetTimeline(filter models.DeviceStateFilter) ([]models.DeviceStateEvent, error)
 }
 
 type curtainService struct {
-	repo repository.CurtainRepository
-}
+	repo   repository.CurtainRepository
+	events repository.DeviceStateRepository
 
-func NewCurtainService(r repository.CurtainRepository) CurtainService {
-	return &curtainService{repo: r}
+	mu sync.Mutex // status reports are processed concurrently
 }
 
-func (s *curtainService) ProcessCurtain(status string, mode string) error {
-	if mode == "" {
-		mode = "manual"
-	}
-
-	curtain := &models.CurtainStatus{
-		Status:    status,
-		Mode:      mode,
-		Timestamp: time.Now(),
-	}
-
-	// Smart CREATE vs UPDATE
-	existing, err := s.repo.GetLatest()
-	if err != nil {
-		// No existing data, create new record
-		if err := s.repo.SaveStatus(curtain); err != nil {
-			log.Printf("Error creating curtain status: %v", err)
-			return err
-		}
-		log.Printf("Curtain created: %s (Mode: %s)", status, mode)
-		return nil
-	}
+func NewCurtainService(r repository.CurtainRepository, events repository.DeviceStateRepository) CurtainService {
+	return &curtainService{repo: r, events: events}
+}
 
-	// Data exists, update it
-	if err := s.repo.Update(curtain); err != nil {
-		log.Printf("Error updating curtain status: %v", err)
-		return err
+// ProcessCurtain appends a state event and updates the current-state row
+func (s *curtainService) ProcessCurtain(change models.DeviceStateChange) (*models.DeviceStateEvent, error) {
+	if change.Mode == "" {
+		change.Mode = "manual"
 	}
 
-	log.Printf("Curtain updated: %s (Mode: %s) [prev: %s (%s)]", status, mode, existing.Status, existing.Mode)
-	return nil
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return appendDeviceState(s.events, "curtain", change)
 }
 
 func (s *curtainService) GetLatest() (*models.CurtainStatus, error) {
 	return s.repo.GetLatest()
 }
+
+func (s *curtainService) GetTimeline(filter models.DeviceStateFilter) ([]models.DeviceStateEvent, error) {
+	filter.Device = "curtain"
+	return s.events.GetTimeline(filter)
+}
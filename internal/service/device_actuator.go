@@ -0,0 +1,71 @@
+package service
+
+import (
+	"fmt"
+	"smarthome-backend/database/models"
+	"time"
+)
+
+// DeviceActuator sends tracked device commands. Implemented by mqtt.DeviceController,
+// so automations/schedules/scenes use the same publish path as /api/control.
+type DeviceActuator interface {
+	Send(req models.DeviceCommandRequest) (*models.DeviceCommand, error)
+	Wait(correlationID string, wait time.Duration) (*models.DeviceCommand, error)
+}
+
+// Buzzer sounds the buzzer. Implemented by mqtt.MQTTHandler (PublishBuzzerControl),
+// which also records the command in buzzer_log.
+type Buzzer interface {
+	PublishBuzzerControl(cmd models.BuzzerCommand) (queued bool, err error)
+}
+
+// FingerprintSensor drives the door unit's fingerprint reader. Implemented by mqtt.MQTTHandler.
+type FingerprintSensor interface {
+	PublishFingerprintEnroll(enrollmentID string, templateIndex int) error
+	PublishFingerprintDelete(templateIndex int) error
+}
+
+var deviceActions = map[string]map[string]bool{
+	"door":    {"lock": true, "unlock": true},
+	"lamp":    {"on": true, "off": true},
+	"curtain": {"open": true, "close": true},
+}
+
+// validateDeviceAction checks a stored action; unattended triggers may not unlock the door
+func validateDeviceAction(device, action string, allowUnlock bool) error {
+	actions, ok := deviceActions[device]
+	if !ok {
+		return fmt.Errorf("unsupported device %q", device)
+	}
+	if !actions[action] {
+		return fmt.Errorf("unsupported action %q for %s", action, device)
+	}
+	if device == "door" && action == "unlock" && !allowUnlock {
+		return fmt.Errorf("door unlock is not allowed here")
+	}
+	return nil
+}
+
+// commandRequest builds the controller request for an automated action.
+// Automated changes use mode "auto" unless the action says otherwise.
+func commandRequest(device, action, mode, source string, sourceID, userID *uint) models.DeviceCommandRequest {
+	req := models.DeviceCommandRequest{
+		Device:   device,
+		Action:   action,
+		Source:   source,
+		SourceID: sourceID,
+		UserID:   userID,
+	}
+	if device == "door" {
+		req.Method = "auto"
+		if userID != nil {
+			req.Method = "remote"
+		}
+	} else {
+		req.Mode = mode
+		if req.Mode == "" {
+			req.Mode = "auto"
+		}
+	}
+	return req
+}
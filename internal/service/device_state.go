@@ -0,0 +1,38 @@
+package service
+
+import (
+	"log"
+	"smarthome-backend/database/models"
+	"smarthome-backend/internal/repository"
+	"time"
+)
+
+// appendDeviceState records a lamp/curtain transition; returns nil when the
+// reported state equals the current one (repeated status messages)
+func appendDeviceState(repo repository.DeviceStateRepository, device string, change models.DeviceStateChange) (*models.DeviceStateEvent, error) {
+	if change.Source == "" {
+		change.Source = models.DeviceStateSourceDevice
+	}
+	event := &models.DeviceStateEvent{
+		Device:        device,
+		Status:        change.Status,
+		Mode:          change.Mode,
+		Source:        change.Source,
+		SourceID:      change.SourceID,
+		UserID:        change.UserID,
+		CorrelationID: change.CorrelationID,
+		Timestamp:     time.Now(),
+	}
+
+	appended, err := repo.Append(event)
+	if err != nil {
+		log.Printf("Error saving %s state: %v", device, err)
+		return nil, err
+	}
+	if !appended {
+		return nil, nil
+	}
+	log.Printf("%s: %s (%s) [prev: %s (%s), source: %s]",
+		device, event.Status, event.Mode, event.PreviousStatus, event.PreviousMode, event.Source)
+	return event, nil
+}
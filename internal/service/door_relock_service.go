@@ -0,0 +1,149 @@
+package service
+
+import (
+	"log"
+	"smarthome-backend/database/models"
+	"sync"
+	"time"
+)
+
+const doorRelockMethod = "auto_relock"
+
+type DoorRelockService interface {
+	// OnUnlocked starts the relock timer, OnLocked cancels it
+	OnUnlocked(method string)
+	OnLocked()
+
+	GetSettings() models.AutoRelockSettings
+	UpdateSettings(settings models.AutoRelockSettings, updatedBy *uint) (models.AutoRelockSettings, error)
+	// RelockAt is when the pending relock fires (nil when no timer runs)
+	RelockAt() *time.Time
+}
+
+type doorRelockService struct {
+	settingSvc SettingService
+	actuator   DeviceActuator
+	gas        GasPlaybookService
+
+	mu       sync.Mutex
+	settings models.AutoRelockSettings
+	timer    *time.Timer
+	relockAt time.Time
+}
+
+// NewDoorRelockService - relocking is suspended while a gas incident is active
+// (the playbook may have unlocked the door for evacuation)
+func NewDoorRelockService(settingSvc SettingService, actuator DeviceActuator, gas GasPlaybookService) DoorRelockService {
+	s := &doorRelockService{
+		settingSvc: settingSvc,
+		actuator:   actuator,
+		gas:        gas,
+		settings:   models.AutoRelockSettings{Enabled: true, DelaySeconds: 30},
+	}
+
+	var stored models.AutoRelockSettings
+	if err := settingSvc.GetJSON(SettingDoorAutoRelock, &stored); err == nil {
+		s.settings = stored
+	}
+	return s
+}
+
+func (s *doorRelockService) GetSettings() models.AutoRelockSettings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.settings
+}
+
+// UpdateSettings - Applies to the next unlock; disabling also cancels a pending relock
+func (s *doorRelockService) UpdateSettings(settings models.AutoRelockSettings, updatedBy *uint) (models.AutoRelockSettings, error) {
+	if err := s.settingSvc.SetJSON(SettingDoorAutoRelock, settings, updatedBy); err != nil {
+		return settings, err
+	}
+
+	s.mu.Lock()
+	s.settings = settings
+	if !settings.Enabled {
+		s.stopLocked()
+	}
+	s.mu.Unlock()
+	return settings, nil
+}
+
+func (s *doorRelockService) RelockAt() *time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.timer == nil {
+		return nil
+	}
+	at := s.relockAt
+	return &at
+}
+
+func (s *doorRelockService) OnUnlocked(method string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Repeated "unlocked" reports must not keep pushing the relock back
+	if !s.settings.Enabled || s.timer != nil {
+		return
+	}
+	if incident := s.gas.GetActiveIncident(); incident != nil {
+		log.Printf("[DOOR] Unlocked via %s during gas incident #%d, auto-relock suspended", method, incident.ID)
+		return
+	}
+
+	delay := time.Duration(s.settings.DelaySeconds) * time.Second
+	s.relockAt = time.Now().Add(delay)
+
+	var timer *time.Timer
+	timer = time.AfterFunc(delay, func() { s.relock(timer) })
+	s.timer = timer
+
+	log.Printf("[DOOR] Unlocked via %s, auto-relock in %ds", method, s.settings.DelaySeconds)
+}
+
+func (s *doorRelockService) OnLocked() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.timer != nil {
+		s.stopLocked()
+		log.Printf("[DOOR] Locked, auto-relock cancelled")
+	}
+}
+
+func (s *doorRelockService) relock(timer *time.Timer) {
+	s.mu.Lock()
+	if s.timer != timer {
+		// Cancelled or restarted meanwhile
+		s.mu.Unlock()
+		return
+	}
+	s.timer = nil
+	s.mu.Unlock()
+
+	// An emergency may have started since the unlock
+	if incident := s.gas.GetActiveIncident(); incident != nil {
+		log.Printf("[DOOR] Auto-relock skipped, gas incident #%d is active", incident.ID)
+		return
+	}
+
+	log.Printf("[DOOR] Auto-relock: sending lock command")
+	_, err := s.actuator.Send(models.DeviceCommandRequest{
+		Device: "door",
+		Action: "lock",
+		Method: doorRelockMethod,
+		Source: doorRelockMethod,
+	})
+	if err != nil {
+		log.Printf("[DOOR] Auto-relock failed: %v", err)
+	}
+}
+
+// stopLocked must be called with s.mu held
+func (s *doorRelockService) stopLocked() {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+}
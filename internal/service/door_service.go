@@ -15,10 +15,11 @@ type DoorService interface {
 type doorService struct {
 	repo          repository.DoorRepository
 	accessLogRepo repository.AccessLogRepository
+	events        EventPublisher
 }
 
-func NewDoorService(r repository.DoorRepository, accessLogRepo repository.AccessLogRepository) DoorService {
-	return &doorService{repo: r, accessLogRepo: accessLogRepo}
+func NewDoorService(r repository.DoorRepository, accessLogRepo repository.AccessLogRepository, events EventPublisher) DoorService {
+	return &doorService{repo: r, accessLogRepo: accessLogRepo, events: events}
 }
 
 func (s *doorService) ProcessDoor(status, method string, userID *uint) error {
@@ -41,9 +42,14 @@ func (s *doorService) ProcessDoor(status, method string, userID *uint) error {
 		return nil
 	}
 
-	// Jika data ada dan DoorID > 0, maka UPDATE
+	// Jika data ada dan DoorID > 0: transisi lock/unlock jadi baris baru (history),
+	// status yang sama cukup UPDATE
 	if existing != nil && existing.DoorID > 0 {
-		err = s.repo.Update(door)
+		if existing.Status != status {
+			err = s.repo.Create(door)
+		} else {
+			err = s.repo.Update(door)
+		}
 		if err != nil {
 			log.Printf("Door error: %v", err)
 			return err
@@ -75,12 +81,14 @@ func (s *doorService) GetHistory(limit int) ([]models.DoorStatus, error) {
 func (s *doorService) saveAccessLog(status, method string, userID *uint) {
 
 	validMethods := map[string]bool{
-		"face":   true,
-		"pin":    true,
-		"remote": true,
+		"face":        true,
+		"pin":         true,
+		"remote":      true,
+		"fingerprint": true,
 	}
 
-	if method == "face" {
+	// Face, PIN & fingerprint attempts are logged by FaceHandler / PinService / FingerprintService themselves
+	if method == "face" || method == "pin" || method == "fingerprint" {
 		return
 	}
 
@@ -105,6 +113,7 @@ func (s *doorService) saveAccessLog(status, method string, userID *uint) {
 	if err != nil {
 		log.Printf("⚠️ Failed to save access log: %v", err)
 	} else {
+		s.events.Publish(models.EventAccessLog, accessLog)
 		if userID != nil {
 			log.Printf("📝 Access log saved: %s (%s) by user_id: %d", method, accessStatus, *userID)
 		} else {
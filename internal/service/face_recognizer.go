@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Face recognition backends (FACE_BACKEND)
+const (
+	FaceBackendPython = "python"
+	FaceBackendMock   = "mock"
+)
+
+// FaceRecognizer is everything the backend needs from a face recognition engine.
+// PythonFaceClient talks to the HTTP service; MockFaceRecognizer keeps faces in memory.
+type FaceRecognizer interface {
+	ValidateFace(ctx context.Context, imageBase64 string) (*ValidateFaceResponse, error)
+	EnrollFace(ctx context.Context, userID int, name, imageBase64 string) (*EnrollFaceResponse, error)
+	RecognizeFace(ctx context.Context, imageBase64 string) (*RecognizeFaceResponse, error)
+	GetEnrolledFaces(ctx context.Context) (*GetEnrolledFacesResponse, error)
+	DeleteFace(ctx context.Context, userID uint) error
+	ReloadFaces(ctx context.Context) error
+	Health() FaceServiceHealth
+}
+
+var (
+	_ FaceRecognizer = (*PythonFaceClient)(nil)
+	_ FaceRecognizer = (*MockFaceRecognizer)(nil)
+)
+
+// NewFaceRecognizer picks the backend by name; empty means the Python service
+func NewFaceRecognizer(backend string, cfg PythonFaceClientConfig) (FaceRecognizer, error) {
+	switch strings.ToLower(strings.TrimSpace(backend)) {
+	case "", FaceBackendPython:
+		log.Printf("[FACE] Using Python face service at %s", cfg.BaseURL)
+		return NewPythonFaceClient(cfg), nil
+	case FaceBackendMock:
+		log.Printf("[FACE] Using in-memory mock face recognizer (development only)")
+		return NewMockFaceRecognizer(), nil
+	}
+	return nil, fmt.Errorf("unknown face backend %q (use %q or %q)", backend, FaceBackendPython, FaceBackendMock)
+}
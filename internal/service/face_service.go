@@ -4,6 +4,7 @@ import (
 	"log"
 	"smarthome-backend/database/models"
 	"smarthome-backend/internal/repository"
+	"smarthome-backend/internal/websocket"
 	"time"
 )
 
@@ -13,14 +14,21 @@ type FaceService interface {
 	GetRecentLogs(limit int) ([]models.FaceRecognitionLog, error)
 	GetUnresolvedAlerts() ([]models.FaceAlert, error)
 	ResolveAlert(id int) error
+
+	GetLogs(filter models.FaceLogFilter) ([]models.FaceRecognitionLog, error)
+	GetAlerts(filter models.FaceAlertFilter) ([]models.FaceAlert, error)
+	GetAlert(id int) (*models.FaceAlert, error)
+	ResolveAlerts(ids []int, resolvedBy *uint) (int64, error)
 }
 
 type faceService struct {
-	repo repository.FaceRepository
+	repo     repository.FaceRepository
+	notifSvc NotificationService
+	hub      *websocket.Hub
 }
 
-func NewFaceService(repo repository.FaceRepository) FaceService {
-	return &faceService{repo: repo}
+func NewFaceService(repo repository.FaceRepository, notifSvc NotificationService, hub *websocket.Hub) FaceService {
+	return &faceService{repo: repo, notifSvc: notifSvc, hub: hub}
 }
 
 func (s *faceService) ProcessRecognition(req *models.FaceRecognitionRequest) error {
@@ -32,7 +40,7 @@ func (s *faceService) ProcessRecognition(req *models.FaceRecognitionRequest) err
 		Recognized: req.Recognized,
 		Message:    req.Message,
 		Source:     req.Source,
-		Timestamp:  time.Unix(req.Timestamp, 0),
+		Timestamp:  unixOrNow(req.Timestamp),
 	}
 
 	if err := s.repo.SaveRecognitionLog(data); err != nil {
@@ -48,6 +56,19 @@ func (s *faceService) ProcessRecognition(req *models.FaceRecognitionRequest) err
 		log.Printf("[Face] ⚠️ Unknown face detected")
 	}
 
+	// 2. Live feed for the dashboard
+	if s.hub != nil {
+		s.hub.Broadcast(websocket.EventFaceRecognition, models.FaceRecognitionEvent{
+			Event:      "face_recognition",
+			UserID:     req.UserID,
+			Name:       req.Name,
+			Confidence: req.Confidence,
+			Recognized: req.Recognized,
+			Message:    req.Message,
+			Timestamp:  data.Timestamp.Unix(),
+		})
+	}
+
 	return nil
 }
 
@@ -56,7 +77,8 @@ func (s *faceService) ProcessAlert(req *models.FaceAlertRequest) error {
 	data := &models.FaceAlert{
 		AlertType:   req.AlertType,
 		ImageBase64: req.ImageBase64,
-		Timestamp:   time.Unix(req.Timestamp, 0),
+		Source:      req.Source,
+		Timestamp:   unixOrNow(req.Timestamp),
 	}
 
 	if err := s.repo.SaveAlert(data); err != nil {
@@ -66,6 +88,20 @@ func (s *faceService) ProcessAlert(req *models.FaceAlertRequest) error {
 
 	log.Printf("[Face] 🚨 Alert: %s", req.AlertType)
 
+	// 2. Notify (same throttle key as unknown faces seen by the ESP32-CAM)
+	data.HasImage = req.ImageBase64 != nil && *req.ImageBase64 != ""
+	if s.hub != nil {
+		s.hub.Broadcast(websocket.EventFaceAlert, map[string]interface{}{
+			"id":         data.ID,
+			"alert_type": data.AlertType,
+			"source":     data.Source,
+			"has_image":  data.HasImage,
+			"timestamp":  data.Timestamp,
+		})
+	}
+	go s.notifSvc.NotifyThrottled("unknown_face", time.Minute, models.NotifIntruder,
+		"Unknown Face", "Face service alert: "+req.AlertType)
+
 	return nil
 }
 
@@ -80,3 +116,34 @@ func (s *faceService) GetUnresolvedAlerts() ([]models.FaceAlert, error) {
 func (s *faceService) ResolveAlert(id int) error {
 	return s.repo.ResolveAlert(id)
 }
+
+func (s *faceService) GetLogs(filter models.FaceLogFilter) ([]models.FaceRecognitionLog, error) {
+	return s.repo.GetLogs(filter)
+}
+
+func (s *faceService) GetAlerts(filter models.FaceAlertFilter) ([]models.FaceAlert, error) {
+	return s.repo.GetAlerts(filter)
+}
+
+func (s *faceService) GetAlert(id int) (*models.FaceAlert, error) {
+	return s.repo.GetAlert(id)
+}
+
+func (s *faceService) ResolveAlerts(ids []int, resolvedBy *uint) (int64, error) {
+	resolved, err := s.repo.ResolveAlerts(ids, resolvedBy)
+	if err != nil {
+		return 0, err
+	}
+	if resolved > 0 {
+		log.Printf("[Face] %d alert(s) resolved", resolved)
+	}
+	return resolved, nil
+}
+
+// unixOrNow - callbacks may omit the timestamp
+func unixOrNow(ts int64) time.Time {
+	if ts <= 0 {
+		return time.Now()
+	}
+	return time.Unix(ts, 0)
+}
@@ -0,0 +1,423 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"smarthome-backend/database/models"
+	"smarthome-backend/internal/repository"
+	"smarthome-backend/internal/websocket"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrFingerprintEnrollBusy   = errors.New("another fingerprint enrollment is in progress")
+	ErrFingerprintStorageFull  = errors.New("fingerprint sensor has no free template slots")
+	ErrFingerprintUserInactive = errors.New("only active users can enroll a fingerprint")
+	ErrFingerprintNoSensor     = errors.New("fingerprint sensor is not connected")
+	ErrEnrollmentNotFound      = errors.New("enrollment not found")
+)
+
+// FingerprintPolicy - sensor capacity (template slots 1..Capacity), how long
+// the user has to press their finger during enrollment and the lowest sensor
+// confidence score accepted as a match
+type FingerprintPolicy struct {
+	Capacity      int
+	EnrollTimeout time.Duration
+	MinConfidence int
+}
+
+type FingerprintService interface {
+	GetAll() ([]models.FingerprintData, error)
+	GetByID(id uint) (*models.FingerprintData, error)
+	GetByUserID(userID uint) ([]models.FingerprintData, error)
+
+	// Enrollment runs on the sensor; the result arrives over MQTT
+	StartEnrollment(userID uint, requestedBy *uint) (*models.FingerprintEnrollment, error)
+	GetEnrollment(id string) (*models.FingerprintEnrollment, error)
+	OnEnrollResult(result models.FingerprintEnrollResult) (*models.FingerprintEnrollment, error)
+
+	// Delete drops the mapping and tells the sensor to wipe the slot
+	Delete(id uint) error
+
+	// VerifyMatch maps a sensor match to a user and writes the access log
+	VerifyMatch(match models.FingerprintMatch) (*models.FingerprintVerifyResult, error)
+
+	// SetSensor wires the reader once the MQTT handler exists
+	SetSensor(sensor FingerprintSensor)
+}
+
+type fingerprintService struct {
+	repo          repository.FingerprintRepository
+	userRepo      repository.UserRepository
+	accessLogRepo repository.AccessLogRepository
+	notifSvc      NotificationService
+	events        EventPublisher
+	hub           *websocket.Hub
+	auth          DeviceAuthenticator
+	limiter       AttemptLimiter
+	policy        FingerprintPolicy
+
+	mu          sync.Mutex
+	sensor      FingerprintSensor
+	enrollments map[string]*models.FingerprintEnrollment
+	active      string // id of the pending enrollment, the sensor handles one at a time
+}
+
+func NewFingerprintService(
+	repo repository.FingerprintRepository,
+	userRepo repository.UserRepository,
+	accessLogRepo repository.AccessLogRepository,
+	notifSvc NotificationService,
+	events EventPublisher,
+	hub *websocket.Hub,
+	auth DeviceAuthenticator,
+	limiter AttemptLimiter,
+	policy FingerprintPolicy,
+) FingerprintService {
+	if policy.Capacity <= 0 {
+		policy.Capacity = 127
+	}
+	if policy.EnrollTimeout <= 0 {
+		policy.EnrollTimeout = 60 * time.Second
+	}
+	if policy.MinConfidence <= 0 {
+		policy.MinConfidence = 50
+	}
+	return &fingerprintService{
+		repo:          repo,
+		userRepo:      userRepo,
+		accessLogRepo: accessLogRepo,
+		notifSvc:      notifSvc,
+		events:        events,
+		hub:           hub,
+		auth:          auth,
+		limiter:       limiter,
+		policy:        policy,
+		enrollments:   make(map[string]*models.FingerprintEnrollment),
+	}
+}
+
+func (s *fingerprintService) SetSensor(sensor FingerprintSensor) {
+	s.mu.Lock()
+	s.sensor = sensor
+	s.mu.Unlock()
+}
+
+func (s *fingerprintService) GetAll() ([]models.FingerprintData, error) {
+	return s.repo.GetAll()
+}
+
+func (s *fingerprintService) GetByID(id uint) (*models.FingerprintData, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *fingerprintService) GetByUserID(userID uint) ([]models.FingerprintData, error) {
+	return s.repo.GetByUserID(userID)
+}
+
+// ==================== ENROLLMENT ====================
+
+func (s *fingerprintService) StartEnrollment(userID uint, requestedBy *uint) (*models.FingerprintEnrollment, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status != "active" {
+		return nil, ErrFingerprintUserInactive
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.sensor == nil {
+		return nil, ErrFingerprintNoSensor
+	}
+	if current, ok := s.enrollments[s.active]; ok && current.Status == models.FingerprintEnrollPending {
+		return nil, ErrFingerprintEnrollBusy
+	}
+
+	index, err := s.freeTemplateIndex()
+	if err != nil {
+		return nil, err
+	}
+	id, err := newEventID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	enrollment := &models.FingerprintEnrollment{
+		ID:            id,
+		UserID:        userID,
+		TemplateIndex: index,
+		Status:        models.FingerprintEnrollPending,
+		Message:       "Place the finger on the door sensor",
+		RequestedBy:   requestedBy,
+		StartedAt:     now,
+		ExpiresAt:     now.Add(s.policy.EnrollTimeout),
+	}
+
+	if err := s.sensor.PublishFingerprintEnroll(id, index); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFingerprintNoSensor, err)
+	}
+
+	s.pruneEnrollments(now)
+	s.enrollments[id] = enrollment
+	s.active = id
+	time.AfterFunc(s.policy.EnrollTimeout, func() { s.expireEnrollment(id) })
+
+	log.Printf("[FINGERPRINT] Enrollment %s started for user %d (slot %d)", id, userID, index)
+	s.broadcast(websocket.EventFingerprintEnroll, s.copyEnrollment(enrollment))
+	return s.copyEnrollment(enrollment), nil
+}
+
+func (s *fingerprintService) GetEnrollment(id string) (*models.FingerprintEnrollment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	enrollment, ok := s.enrollments[id]
+	if !ok {
+		return nil, ErrEnrollmentNotFound
+	}
+	return s.copyEnrollment(enrollment), nil
+}
+
+// OnEnrollResult stores the slot → user mapping once the sensor saved the template
+func (s *fingerprintService) OnEnrollResult(result models.FingerprintEnrollResult) (*models.FingerprintEnrollment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	enrollment, ok := s.enrollments[result.EnrollmentID]
+	if !ok || enrollment.Status != models.FingerprintEnrollPending {
+		// Late result after a timeout: the slot is not mapped, so clear it on the sensor
+		if result.Success && s.sensor != nil {
+			if _, err := s.repo.GetByTemplateIndex(result.TemplateIndex); errors.Is(err, gorm.ErrRecordNotFound) {
+				s.sensor.PublishFingerprintDelete(result.TemplateIndex)
+			}
+		}
+		return nil, ErrEnrollmentNotFound
+	}
+	if result.Success && result.TemplateIndex != enrollment.TemplateIndex {
+		return nil, fmt.Errorf("sensor stored slot %d, expected %d", result.TemplateIndex, enrollment.TemplateIndex)
+	}
+
+	now := time.Now()
+	enrollment.FinishedAt = &now
+	enrollment.Message = result.Message
+
+	if result.Success {
+		fp := &models.FingerprintData{UserID: enrollment.UserID, TemplateIndex: enrollment.TemplateIndex}
+		if err := s.repo.Create(fp); err != nil {
+			enrollment.Status = models.FingerprintEnrollFailed
+			enrollment.Message = "Failed to save fingerprint"
+			s.broadcast(websocket.EventFingerprintEnroll, s.copyEnrollment(enrollment))
+			return s.copyEnrollment(enrollment), err
+		}
+		enrollment.Status = models.FingerprintEnrollSuccess
+		enrollment.Fingerprint = fp
+		if enrollment.Message == "" {
+			enrollment.Message = "Fingerprint enrolled"
+		}
+		log.Printf("[FINGERPRINT] User %d enrolled in slot %d", enrollment.UserID, enrollment.TemplateIndex)
+	} else {
+		enrollment.Status = models.FingerprintEnrollFailed
+		if enrollment.Message == "" {
+			enrollment.Message = "Enrollment failed on the sensor"
+		}
+		log.Printf("[FINGERPRINT] Enrollment %s failed: %s", enrollment.ID, enrollment.Message)
+	}
+
+	s.broadcast(websocket.EventFingerprintEnroll, s.copyEnrollment(enrollment))
+	return s.copyEnrollment(enrollment), nil
+}
+
+func (s *fingerprintService) expireEnrollment(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	enrollment, ok := s.enrollments[id]
+	if !ok || enrollment.Status != models.FingerprintEnrollPending {
+		return
+	}
+	now := time.Now()
+	enrollment.Status = models.FingerprintEnrollTimedOut
+	enrollment.Message = "No finger detected before the timeout"
+	enrollment.FinishedAt = &now
+
+	log.Printf("[FINGERPRINT] Enrollment %s timed out", id)
+	s.broadcast(websocket.EventFingerprintEnroll, s.copyEnrollment(enrollment))
+}
+
+// freeTemplateIndex - lowest unused slot; caller holds s.mu
+func (s *fingerprintService) freeTemplateIndex() (int, error) {
+	used, err := s.repo.GetUsedIndices()
+	if err != nil {
+		return 0, err
+	}
+	taken := make(map[int]bool, len(used))
+	for _, index := range used {
+		taken[index] = true
+	}
+	for index := 1; index <= s.policy.Capacity; index++ {
+		if !taken[index] {
+			return index, nil
+		}
+	}
+	return 0, ErrFingerprintStorageFull
+}
+
+// pruneEnrollments forgets finished sessions after an hour; caller holds s.mu
+func (s *fingerprintService) pruneEnrollments(now time.Time) {
+	for id, enrollment := range s.enrollments {
+		if enrollment.FinishedAt != nil && now.Sub(*enrollment.FinishedAt) > time.Hour {
+			delete(s.enrollments, id)
+		}
+	}
+}
+
+func (s *fingerprintService) copyEnrollment(enrollment *models.FingerprintEnrollment) *models.FingerprintEnrollment {
+	copied := *enrollment
+	return &copied
+}
+
+// ==================== DELETE ====================
+
+func (s *fingerprintService) Delete(id uint) error {
+	fp, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+
+	// Without a mapping the slot can no longer unlock, even if the sensor misses this
+	s.mu.Lock()
+	sensor := s.sensor
+	s.mu.Unlock()
+	if sensor != nil {
+		if err := sensor.PublishFingerprintDelete(fp.TemplateIndex); err != nil {
+			log.Printf("[FINGERPRINT] Failed to clear slot %d on the sensor: %v", fp.TemplateIndex, err)
+		}
+	}
+
+	log.Printf("[FINGERPRINT] Fingerprint %d (user %d, slot %d) deleted", fp.FingerprintID, fp.UserID, fp.TemplateIndex)
+	return nil
+}
+
+// ==================== VERIFICATION ====================
+
+// VerifyMatch only trusts matches signed by a known door unit; failures,
+// low-confidence and unassigned-slot matches count toward the PIN lockout
+func (s *fingerprintService) VerifyMatch(match models.FingerprintMatch) (*models.FingerprintVerifyResult, error) {
+	err := s.auth.Verify(SignedDeviceMessage{
+		DeviceID:  match.DeviceID,
+		Timestamp: match.Timestamp,
+		Nonce:     match.Nonce,
+		Signature: match.Signature,
+		Fields: []string{
+			strconv.FormatBool(match.Matched),
+			strconv.Itoa(match.TemplateIndex),
+			strconv.Itoa(match.Confidence),
+		},
+	})
+	if err != nil {
+		// Not counted: anyone on the broker could otherwise lock the real door unit out
+		log.Printf("[FINGERPRINT] Rejected match from device %q: %v", match.DeviceID, err)
+		s.notifyFailed("unauthenticated", "Fingerprint match with an invalid device signature was rejected")
+		return &models.FingerprintVerifyResult{Valid: false, Message: "Device not authenticated"}, nil
+	}
+
+	source := "fingerprint:" + match.DeviceID
+	if locked, err := s.limiter.CheckLocked(source); err != nil {
+		return nil, err
+	} else if locked != nil {
+		s.logAttempt("failed", nil)
+		return lockedFingerprintResult(locked), nil
+	}
+
+	if !match.Matched {
+		return s.rejectMatch(source, nil, "Unrecognized fingerprint", "Fingerprint not recognized")
+	}
+	if match.Confidence < s.policy.MinConfidence {
+		log.Printf("[FINGERPRINT] Slot %d matched with confidence %d (< %d)", match.TemplateIndex, match.Confidence, s.policy.MinConfidence)
+		return s.rejectMatch(source, nil, "Low-confidence fingerprint match", "Fingerprint not recognized")
+	}
+
+	fp, err := s.repo.GetByTemplateIndex(match.TemplateIndex)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		// Template left on the sensor without an owner (deleted user or lost delete command)
+		log.Printf("[FINGERPRINT] Slot %d matched but is not assigned to a user", match.TemplateIndex)
+		return s.rejectMatch(source, nil, fmt.Sprintf("Fingerprint slot %d is not assigned to any user", match.TemplateIndex), "Fingerprint not registered")
+	}
+
+	userID := fp.UserID
+	if fp.User.Status != "active" {
+		return s.rejectMatch(source, &userID, fmt.Sprintf("Fingerprint of %s (account %s) was rejected", fp.User.Name, fp.User.Status), "Account is not active")
+	}
+
+	s.limiter.RecordSuccess(source)
+	s.logAttempt("success", &userID)
+	log.Printf("[FINGERPRINT] %s verified (slot %d, confidence %d)", fp.User.Name, match.TemplateIndex, match.Confidence)
+	return &models.FingerprintVerifyResult{
+		Valid:   true,
+		UserID:  &userID,
+		Name:    fp.User.Name,
+		Message: "Fingerprint verified, door unlocked",
+	}, nil
+}
+
+// rejectMatch logs and counts a failed fingerprint attempt
+func (s *fingerprintService) rejectMatch(source string, userID *uint, notice, message string) (*models.FingerprintVerifyResult, error) {
+	s.logAttempt("failed", userID)
+	s.notifyFailed(source, notice)
+
+	attempt, err := s.limiter.RecordFailure(source, "fingerprint")
+	if err != nil {
+		return nil, err
+	}
+	if attempt.Locked {
+		return lockedFingerprintResult(attempt), nil
+	}
+	return &models.FingerprintVerifyResult{Valid: false, UserID: userID, Message: message}, nil
+}
+
+func lockedFingerprintResult(locked *models.PinVerifyResult) *models.FingerprintVerifyResult {
+	return &models.FingerprintVerifyResult{
+		Valid:          false,
+		Locked:         true,
+		LockoutSeconds: locked.LockoutSeconds,
+		Message:        locked.Message,
+	}
+}
+
+func (s *fingerprintService) logAttempt(status string, userID *uint) {
+	accessLog := &models.AccessLog{
+		UserID: userID,
+		Method: "fingerprint",
+		Status: status,
+	}
+	if err := s.accessLogRepo.Create(accessLog); err != nil {
+		log.Printf("⚠️ Failed to save fingerprint access log: %v", err)
+		return
+	}
+	s.events.Publish(models.EventAccessLog, accessLog)
+}
+
+func (s *fingerprintService) notifyFailed(source, message string) {
+	go s.notifSvc.NotifyThrottled("fingerprint_failed:"+source, time.Minute, models.NotifAccess, "Failed Access", message)
+}
+
+func (s *fingerprintService) broadcast(eventType string, data interface{}) {
+	if s.hub != nil {
+		s.hub.Broadcast(eventType, data)
+	}
+}
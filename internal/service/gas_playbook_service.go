@@ -0,0 +1,267 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"smarthome-backend/database/models"
+	"smarthome-backend/internal/repository"
+	"sync"
+	"time"
+)
+
+type GasPlaybookService interface {
+	// OnReading is fed every raw gas reading from MQTT
+	OnReading(ppm int)
+
+	GetPlaybook() models.GasPlaybook
+	UpdatePlaybook(playbook models.GasPlaybook, updatedBy *uint) (models.GasPlaybook, error)
+	GetActiveIncident() *models.GasIncident
+	GetIncidents(limit int) ([]models.GasIncident, error)
+	GetIncident(id uint) (*models.GasIncident, error)
+
+	// SetBuzzer wires the buzzer once the MQTT handler exists
+	SetBuzzer(buzzer Buzzer)
+}
+
+type gasPlaybookService struct {
+	repo       repository.GasIncidentRepository
+	settingSvc SettingService
+	notifSvc   NotificationService
+	actuator   DeviceActuator
+
+	mu           sync.Mutex
+	buzzer       Buzzer
+	playbook     models.GasPlaybook
+	dangerStreak int
+	active       *models.GasIncident
+	clearSince   time.Time
+}
+
+func NewGasPlaybookService(
+	r repository.GasIncidentRepository,
+	settingSvc SettingService,
+	notifSvc NotificationService,
+	actuator DeviceActuator,
+) GasPlaybookService {
+	s := &gasPlaybookService{
+		repo:       r,
+		settingSvc: settingSvc,
+		notifSvc:   notifSvc,
+		actuator:   actuator,
+		playbook:   models.DefaultGasPlaybook(),
+	}
+
+	var stored models.GasPlaybook
+	if err := settingSvc.GetJSON(SettingGasPlaybook, &stored); err == nil {
+		s.playbook = stored
+	}
+
+	// An incident still active at shutdown keeps waiting for its all-clear
+	if active, err := r.GetActive(); err == nil {
+		s.active = active
+		log.Printf("[GAS] Resuming active incident #%d", active.ID)
+	}
+	return s
+}
+
+func (s *gasPlaybookService) SetBuzzer(buzzer Buzzer) {
+	s.mu.Lock()
+	s.buzzer = buzzer
+	s.mu.Unlock()
+}
+
+func (s *gasPlaybookService) GetPlaybook() models.GasPlaybook {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.playbook
+}
+
+func (s *gasPlaybookService) UpdatePlaybook(playbook models.GasPlaybook, updatedBy *uint) (models.GasPlaybook, error) {
+	if playbook.ClearPPM >= playbook.DangerPPM {
+		return playbook, errors.New("clear_ppm must be lower than danger_ppm")
+	}
+	if err := s.settingSvc.SetJSON(SettingGasPlaybook, playbook, updatedBy); err != nil {
+		return playbook, err
+	}
+
+	s.mu.Lock()
+	s.playbook = playbook
+	s.dangerStreak = 0
+	s.mu.Unlock()
+	return playbook, nil
+}
+
+func (s *gasPlaybookService) GetActiveIncident() *models.GasIncident {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active == nil {
+		return nil
+	}
+	incident := *s.active
+	return &incident
+}
+
+func (s *gasPlaybookService) GetIncidents(limit int) ([]models.GasIncident, error) {
+	return s.repo.GetAll(limit)
+}
+
+func (s *gasPlaybookService) GetIncident(id uint) (*models.GasIncident, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *gasPlaybookService) OnReading(ppm int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	pb := s.playbook
+
+	if s.active == nil {
+		if !pb.Enabled {
+			s.dangerStreak = 0
+			return
+		}
+
+		// Debounce: one noisy sample must not start an incident
+		if ppm > pb.DangerPPM {
+			s.dangerStreak++
+		} else {
+			s.dangerStreak = 0
+		}
+		if s.dangerStreak >= pb.DebounceSamples {
+			s.dangerStreak = 0
+			s.startLocked(ppm, now, pb)
+		}
+		return
+	}
+
+	incident := s.active
+	if ppm > incident.PeakPPM {
+		incident.PeakPPM = ppm
+		incident.PeakAt = now
+		s.saveLocked()
+	}
+
+	// All-clear only after readings stay normal for the whole period
+	if ppm > pb.ClearPPM {
+		s.clearSince = time.Time{}
+		return
+	}
+	if s.clearSince.IsZero() {
+		s.clearSince = now
+		return
+	}
+	if now.Sub(s.clearSince) >= time.Duration(pb.ClearSeconds)*time.Second {
+		s.resolveLocked(now, pb)
+	}
+}
+
+// startLocked must be called with s.mu held
+func (s *gasPlaybookService) startLocked(ppm int, now time.Time, pb models.GasPlaybook) {
+	incident := &models.GasIncident{
+		Status:    "active",
+		StartPPM:  ppm,
+		PeakPPM:   ppm,
+		StartedAt: now,
+		PeakAt:    now,
+	}
+	if err := s.repo.Create(incident); err != nil {
+		log.Printf("[GAS] Failed to save incident: %v", err)
+	}
+	s.active = incident
+	s.clearSince = time.Time{}
+
+	log.Printf("[GAS] EMERGENCY: incident #%d started at %d PPM", incident.ID, ppm)
+
+	// Actions publish over MQTT, keep them off the MQTT callback
+	go s.runPlaybook(incident.ID, ppm, pb)
+}
+
+func (s *gasPlaybookService) runPlaybook(incidentID uint, ppm int, pb models.GasPlaybook) {
+	var results models.RuleActionResults
+
+	s.mu.Lock()
+	buzzer := s.buzzer
+	s.mu.Unlock()
+
+	if pb.Buzzer && buzzer != nil {
+		buzzer.PublishBuzzerControl(models.BuzzerCommand{
+			Action: "on",
+			Source: models.BuzzerSourceGas,
+			Reason: fmt.Sprintf("Gas emergency: incident #%d at %d PPM", incidentID, ppm),
+		})
+		results = append(results, models.RuleActionResult{Device: "buzzer", Action: "on", Status: "sent"})
+	}
+	if pb.OpenCurtain {
+		results = append(results, s.send("curtain", "open", incidentID))
+	}
+	if pb.UnlockDoor {
+		results = append(results, s.send("door", "unlock", incidentID))
+	}
+	if pb.Notify {
+		s.notify("Gas Emergency", fmt.Sprintf("Dangerous gas level detected: %d PPM", ppm))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active != nil && s.active.ID == incidentID {
+		s.active.Actions = results
+		s.saveLocked()
+	}
+}
+
+func (s *gasPlaybookService) send(device, action string, incidentID uint) models.RuleActionResult {
+	result := models.RuleActionResult{Device: device, Action: action}
+
+	cmd, err := s.actuator.Send(commandRequest(device, action, "auto", "gas_incident", &incidentID, nil))
+	if cmd != nil {
+		result.CorrelationID = cmd.CorrelationID
+		result.Status = cmd.Status
+	}
+	if err != nil {
+		result.Status = "failed"
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// resolveLocked must be called with s.mu held
+func (s *gasPlaybookService) resolveLocked(now time.Time, pb models.GasPlaybook) {
+	incident := s.active
+	incident.Status = "resolved"
+	incident.EndedAt = &now
+	s.saveLocked()
+
+	s.active = nil
+	s.clearSince = time.Time{}
+
+	log.Printf("[GAS] All clear: incident #%d resolved (peak %d PPM)", incident.ID, incident.PeakPPM)
+
+	if pb.Buzzer && s.buzzer != nil {
+		go s.buzzer.PublishBuzzerControl(models.BuzzerCommand{
+			Action: "off",
+			Source: models.BuzzerSourceGas,
+			Reason: fmt.Sprintf("Gas all clear: incident #%d resolved", incident.ID),
+		})
+	}
+	if pb.Notify {
+		go s.notify("Gas All Clear", fmt.Sprintf("Gas levels back to normal (peak %d PPM)", incident.PeakPPM))
+	}
+}
+
+// saveLocked must be called with s.mu held
+func (s *gasPlaybookService) saveLocked() {
+	if s.active == nil || s.active.ID == 0 {
+		return
+	}
+	if err := s.repo.Update(s.active); err != nil {
+		log.Printf("[GAS] Failed to update incident #%d: %v", s.active.ID, err)
+	}
+}
+
+func (s *gasPlaybookService) notify(title, message string) {
+	if err := s.notifSvc.Create(models.NotificationRequest{Title: title, Message: message, Type: "gas"}); err != nil {
+		log.Printf("[GAS] Failed to create notification: %v", err)
+	}
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"smarthome-backend/database/models"
+	"smarthome-backend/internal/repository"
+	"strings"
+	"time"
+)
+
+type GuestCodeService interface {
+	Create(req models.GuestCodeRequest, createdBy uint) (*models.GuestCodeCreated, error)
+	GetAll() ([]models.GuestCode, error)
+	GetByID(id uint) (*models.GuestCode, error)
+	Revoke(id uint) error
+	Delete(id uint) error
+
+	// Match finds a guest code allowed right now and counts one use; nil when nothing matches
+	Match(code string, now time.Time) (*models.GuestCode, error)
+	IsCodeInUse(code string) (bool, error)
+}
+
+var weekdayNames = map[time.Weekday]string{
+	time.Sunday:    "sun",
+	time.Monday:    "mon",
+	time.Tuesday:   "tue",
+	time.Wednesday: "wed",
+	time.Thursday:  "thu",
+	time.Friday:    "fri",
+	time.Saturday:  "sat",
+}
+
+const generatedGuestCodeLength = 6
+
+type guestCodeService struct {
+	repo        repository.GuestCodeRepository
+	userPinRepo repository.UserPinRepository
+	pinRepo     repository.PinRepository
+}
+
+func NewGuestCodeService(
+	r repository.GuestCodeRepository,
+	userPinRepo repository.UserPinRepository,
+	pinRepo repository.PinRepository,
+) GuestCodeService {
+	return &guestCodeService{
+		repo:        r,
+		userPinRepo: userPinRepo,
+		pinRepo:     pinRepo,
+	}
+}
+
+func (s *guestCodeService) Create(req models.GuestCodeRequest, createdBy uint) (*models.GuestCodeCreated, error) {
+	validFrom := time.Now()
+	if req.ValidFrom != nil {
+		validFrom = *req.ValidFrom
+	}
+	if !req.ValidUntil.After(validFrom) {
+		return nil, errors.New("valid_until must be after valid_from")
+	}
+	if (req.TimeStart == "") != (req.TimeEnd == "") {
+		return nil, errors.New("time_start and time_end must be set together")
+	}
+
+	code := req.Code
+	if code == "" {
+		generated, err := s.generateCode()
+		if err != nil {
+			return nil, err
+		}
+		code = generated
+	} else if inUse, err := s.conflicts(code); err != nil {
+		return nil, err
+	} else if inUse {
+		return nil, ErrPinInUse
+	}
+
+	codeHash, err := hashPin(code)
+	if err != nil {
+		return nil, err
+	}
+
+	guest := &models.GuestCode{
+		Label:       req.Label,
+		CodeHash:    codeHash,
+		ValidFrom:   validFrom,
+		ValidUntil:  req.ValidUntil,
+		MaxUses:     req.MaxUses,
+		AllowedDays: strings.Join(req.AllowedDays, ","),
+		CreatedBy:   createdBy,
+	}
+	if req.TimeStart != "" {
+		guest.TimeStart = &req.TimeStart
+		guest.TimeEnd = &req.TimeEnd
+	}
+
+	if err := s.repo.Create(guest); err != nil {
+		return nil, err
+	}
+
+	return &models.GuestCodeCreated{GuestCode: *guest, Code: code}, nil
+}
+
+func (s *guestCodeService) GetAll() ([]models.GuestCode, error) {
+	return s.repo.GetAll()
+}
+
+func (s *guestCodeService) GetByID(id uint) (*models.GuestCode, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *guestCodeService) Revoke(id uint) error {
+	return s.repo.Revoke(id)
+}
+
+func (s *guestCodeService) Delete(id uint) error {
+	return s.repo.Delete(id)
+}
+
+func (s *guestCodeService) Match(code string, now time.Time) (*models.GuestCode, error) {
+	codes, err := s.repo.GetUsable(now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve guest codes: %w", err)
+	}
+
+	for i := range codes {
+		guest := &codes[i]
+		if !pinMatches(guest.CodeHash, code) {
+			continue
+		}
+		if !allowedAt(guest, now) {
+			return nil, nil
+		}
+
+		// Conditional UPDATE so two simultaneous entries can't exceed max_uses
+		ok, err := s.repo.ConsumeUse(guest.ID, now)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, nil
+		}
+		guest.UseCount++
+		return guest, nil
+	}
+	return nil, nil
+}
+
+// IsCodeInUse - Any non-expired guest code with this value (used to keep PINs unambiguous)
+func (s *guestCodeService) IsCodeInUse(code string) (bool, error) {
+	codes, err := s.repo.GetUsable(time.Now())
+	if err != nil {
+		return false, err
+	}
+	for _, guest := range codes {
+		if pinMatches(guest.CodeHash, code) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// conflicts checks the code against personal PINs, the universal PIN and other guest codes
+func (s *guestCodeService) conflicts(code string) (bool, error) {
+	pins, err := s.userPinRepo.GetAllActive()
+	if err != nil {
+		return false, err
+	}
+	for _, p := range pins {
+		if pinMatches(p.PinHash, code) {
+			return true, nil
+		}
+	}
+
+	if universal, err := s.pinRepo.GetUniversalPin(); err == nil && pinMatches(universal.PinHash, code) {
+		return true, nil
+	}
+
+	return s.IsCodeInUse(code)
+}
+
+func (s *guestCodeService) generateCode() (string, error) {
+	max := big.NewInt(1)
+	for i := 0; i < generatedGuestCodeLength; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+
+	for i := 0; i < 10; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code := fmt.Sprintf("%0*d", generatedGuestCodeLength, n.Int64())
+
+		inUse, err := s.conflicts(code)
+		if err != nil {
+			return "", err
+		}
+		if !inUse {
+			return code, nil
+		}
+	}
+	return "", errors.New("failed to generate a unique guest code")
+}
+
+// allowedAt applies the validity window plus optional weekday / time-of-day restrictions.
+// A time window with start > end spans midnight (e.g. 22:00-06:00).
+func allowedAt(guest *models.GuestCode, now time.Time) bool {
+	if now.Before(guest.ValidFrom) || !now.Before(guest.ValidUntil) {
+		return false
+	}
+
+	var days []string
+	if guest.AllowedDays != "" {
+		days = strings.Split(guest.AllowedDays, ",")
+	}
+
+	var start, end string
+	if guest.TimeStart != nil && guest.TimeEnd != nil {
+		start, end = *guest.TimeStart, *guest.TimeEnd
+	}
+	return inTimeWindow(start, end, days, now)
+}
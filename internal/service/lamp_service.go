@@ -1,54 +1,33 @@
 package service
 
 import (
-	"log"
 	"smarthome-backend/database/models"
 	"smarthome-backend/internal/repository"
-	"time"
+	"sync"
 )
 
 type LampService interface {
-	ProcessLamp(status, mode string) error
+	ProcessLamp(change models.DeviceStateChange) (*models.DeviceStateEvent, error)
 	GetLatest() (*models.LampStatus, error)
-	GetHistory(limit int) ([]models.LampStatus, error)
+	GetTimeline(filter models.DeviceStateFilter) ([]models.DeviceStateEvent, error)
 }
 
 type lampService struct {
-	repo repository.LampRepository
-}
+	repo   repository.LampRepository
+	events repository.DeviceStateRepository
 
-func NewLampService(r repository.LampRepository) LampService {
-	return &lampService{repo: r}
+	mu sync.Mutex // status reports are processed concurrently
 }
 
-func (s *lampService) ProcessLamp(status, mode string) error {
-	lamp := &models.LampStatus{
-		Status:    status,
-		Mode:      mode,
-		Timestamp: time.Now(),
-	}
-
-	// Cek apakah sudah ada data
-	existing, err := s.repo.GetLatest()
-	if err != nil || existing.LampID == 0 {
-		// Belum ada data, INSERT
-		err = s.repo.Create(lamp)
-		if err != nil {
-			log.Printf("Error creating lamp status: %v", err)
-			return err
-		}
-		log.Printf("Lamp status created: %s (%s)", status, mode)
-	} else {
-		// Sudah ada data, UPDATE
-		err = s.repo.Update(lamp)
-		if err != nil {
-			log.Printf("Error updating lamp status: %v", err)
-			return err
-		}
-		log.Printf("Lamp status updated: %s (%s)", status, mode)
-	}
+func NewLampService(r repository.LampRepository, events repository.DeviceStateRepository) LampService {
+	return &lampService{repo: r, events: events}
+}
 
-	return nil
+// ProcessLamp appends a state event and updates the current-state row
+func (s *lampService) ProcessLamp(change models.DeviceStateChange) (*models.DeviceStateEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return appendDeviceState(s.events, "lamp", change)
 }
 
 func (s *lampService) GetLatest() (*models.LampStatus, error) {
@@ -66,6 +45,7 @@ func (s *lampService) GetLatest() (*models.LampStatus, error) {
 	return lamp, nil
 }
 
-func (s *lampService) GetHistory(limit int) ([]models.LampStatus, error) {
-	return s.repo.GetHistory(limit)
+func (s *lampService) GetTimeline(filter models.DeviceStateFilter) ([]models.DeviceStateEvent, error) {
+	filter.Device = "lamp"
+	return s.events.GetTimeline(filter)
 }
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MockFaceRecognizer is a deterministic in-memory FaceRecognizer for local
+// development: an image is "recognized" when its decoded bytes are identical
+// to the image a user enrolled with. Nothing survives a restart.
+type MockFaceRecognizer struct {
+	mu    sync.RWMutex
+	faces map[int]mockFace // user_id → enrolled face
+}
+
+type mockFace struct {
+	Name string
+	Hash [sha256.Size]byte
+}
+
+func NewMockFaceRecognizer() *MockFaceRecognizer {
+	return &MockFaceRecognizer{faces: make(map[int]mockFace)}
+}
+
+// ValidateFace accepts any non-empty base64 image as exactly one face
+func (m *MockFaceRecognizer) ValidateFace(ctx context.Context, imageBase64 string) (*ValidateFaceResponse, error) {
+	if _, err := decodeMockImage(imageBase64); err != nil {
+		return &ValidateFaceResponse{Valid: false, FacesDetected: 0, Message: err.Error()}, nil
+	}
+	return &ValidateFaceResponse{Valid: true, FacesDetected: 1, Message: "Face detected"}, nil
+}
+
+// EnrollFace stores the image hash for the user, replacing any previous face
+func (m *MockFaceRecognizer) EnrollFace(ctx context.Context, userID int, name, imageBase64 string) (*EnrollFaceResponse, error) {
+	data, err := decodeMockImage(imageBase64)
+	if err != nil {
+		return nil, &FaceServiceError{Status: http.StatusBadRequest, Message: err.Error()}
+	}
+
+	m.mu.Lock()
+	m.faces[userID] = mockFace{Name: name, Hash: sha256.Sum256(data)}
+	m.mu.Unlock()
+
+	return &EnrollFaceResponse{
+		Success: true,
+		File:    mockFaceFile(userID, name),
+		Message: "Face enrolled successfully",
+	}, nil
+}
+
+// RecognizeFace matches the image hash against enrolled faces
+func (m *MockFaceRecognizer) RecognizeFace(ctx context.Context, imageBase64 string) (*RecognizeFaceResponse, error) {
+	data, err := decodeMockImage(imageBase64)
+	if err != nil {
+		return nil, &FaceServiceError{Status: http.StatusBadRequest, Message: err.Error()}
+	}
+	hash := sha256.Sum256(data)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// Lowest user_id wins when two users enrolled the same image
+	ids := m.sortedIDs()
+	for _, id := range ids {
+		if face := m.faces[id]; face.Hash == hash {
+			return &RecognizeFaceResponse{
+				Recognized: true,
+				UserID:     id,
+				Name:       face.Name,
+				Confidence: 1.0,
+				Message:    "Face recognized",
+			}, nil
+		}
+	}
+	return &RecognizeFaceResponse{Recognized: false, Message: "Face not recognized"}, nil
+}
+
+// GetEnrolledFaces lists enrolled faces ordered by user_id
+func (m *MockFaceRecognizer) GetEnrolledFaces(ctx context.Context) (*GetEnrolledFacesResponse, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	faces := make([]EnrolledFace, 0, len(m.faces))
+	for _, id := range m.sortedIDs() {
+		face := m.faces[id]
+		faces = append(faces, EnrolledFace{File: mockFaceFile(id, face.Name), UserID: id, Name: face.Name})
+	}
+	return &GetEnrolledFacesResponse{Faces: faces, Total: len(faces)}, nil
+}
+
+// DeleteFace forgets the user's face; unknown users are not an error
+func (m *MockFaceRecognizer) DeleteFace(ctx context.Context, userID uint) error {
+	m.mu.Lock()
+	delete(m.faces, int(userID))
+	m.mu.Unlock()
+	return nil
+}
+
+// ReloadFaces is a no-op, the faces already live in memory
+func (m *MockFaceRecognizer) ReloadFaces(ctx context.Context) error {
+	return nil
+}
+
+func (m *MockFaceRecognizer) Health() FaceServiceHealth {
+	return FaceServiceHealth{
+		Status:  "healthy",
+		Breaker: BreakerClosed,
+		BaseURL: "mock://in-memory",
+	}
+}
+
+// sortedIDs - caller must hold the lock
+func (m *MockFaceRecognizer) sortedIDs() []int {
+	ids := make([]int, 0, len(m.faces))
+	for id := range m.faces {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// decodeMockImage strips an optional data URI prefix and decodes the base64 payload
+func decodeMockImage(imageBase64 string) ([]byte, error) {
+	payload := strings.TrimSpace(imageBase64)
+	if strings.HasPrefix(payload, "data:") {
+		if idx := strings.Index(payload, ","); idx >= 0 {
+			payload = payload[idx+1:]
+		}
+	}
+	if payload == "" {
+		return nil, fmt.Errorf("no image provided")
+	}
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 image")
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("no face detected")
+	}
+	return data, nil
+}
+
+func mockFaceFile(userID int, name string) string {
+	return fmt.Sprintf("%d_%s.jpg", userID, strings.ReplaceAll(strings.TrimSpace(name), " ", "_"))
+}
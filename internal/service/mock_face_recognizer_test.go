@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestMockFaceRecognizer(t *testing.T) {
+	alice := base64.StdEncoding.EncodeToString([]byte("alice-face"))
+	bob := "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString([]byte("bob-face"))
+	stranger := base64.StdEncoding.EncodeToString([]byte("stranger-face"))
+
+	ctx := context.Background()
+	m := NewMockFaceRecognizer()
+
+	// Steps run in order against the same recognizer
+	steps := []struct {
+		name   string
+		op     string // enroll | recognize | delete
+		userID int
+		label  string
+		image  string
+
+		wantRecognized bool
+		wantUserID     int
+		wantEnrolled   int
+	}{
+		{name: "unknown before enroll", op: "recognize", image: alice},
+		{name: "enroll alice", op: "enroll", userID: 1, label: "Alice", image: alice, wantEnrolled: 1},
+		{name: "enroll bob with data URI", op: "enroll", userID: 2, label: "Bob", image: bob, wantEnrolled: 2},
+		{name: "recognize alice", op: "recognize", image: alice, wantRecognized: true, wantUserID: 1},
+		{name: "recognize bob", op: "recognize", image: bob, wantRecognized: true, wantUserID: 2},
+		{name: "stranger not recognized", op: "recognize", image: stranger},
+		{name: "re-enroll replaces face", op: "enroll", userID: 1, label: "Alice", image: stranger, wantEnrolled: 2},
+		{name: "old face no longer matches", op: "recognize", image: alice},
+		{name: "new face matches", op: "recognize", image: stranger, wantRecognized: true, wantUserID: 1},
+		{name: "delete alice", op: "delete", userID: 1, wantEnrolled: 1},
+		{name: "deleted face not recognized", op: "recognize", image: stranger},
+		{name: "delete unknown user", op: "delete", userID: 99, wantEnrolled: 1},
+	}
+
+	for _, step := range steps {
+		switch step.op {
+		case "enroll":
+			resp, err := m.EnrollFace(ctx, step.userID, step.label, step.image)
+			if err != nil || !resp.Success {
+				t.Fatalf("%s: EnrollFace = %+v, %v", step.name, resp, err)
+			}
+		case "delete":
+			if err := m.DeleteFace(ctx, uint(step.userID)); err != nil {
+				t.Fatalf("%s: DeleteFace: %v", step.name, err)
+			}
+		case "recognize":
+			resp, err := m.RecognizeFace(ctx, step.image)
+			if err != nil {
+				t.Fatalf("%s: RecognizeFace: %v", step.name, err)
+			}
+			if resp.Recognized != step.wantRecognized || resp.UserID != step.wantUserID {
+				t.Fatalf("%s: got recognized=%v user=%d, want %v user=%d",
+					step.name, resp.Recognized, resp.UserID, step.wantRecognized, step.wantUserID)
+			}
+			continue
+		}
+
+		enrolled, err := m.GetEnrolledFaces(ctx)
+		if err != nil {
+			t.Fatalf("%s: GetEnrolledFaces: %v", step.name, err)
+		}
+		if enrolled.Total != step.wantEnrolled {
+			t.Fatalf("%s: %d enrolled faces, want %d", step.name, enrolled.Total, step.wantEnrolled)
+		}
+	}
+}
+
+func TestMockFaceRecognizerRejectsInvalidImages(t *testing.T) {
+	ctx := context.Background()
+	m := NewMockFaceRecognizer()
+
+	for _, image := range []string{"", "   ", "not base64!", "data:image/jpeg;base64,"} {
+		if _, err := m.EnrollFace(ctx, 1, "Alice", image); !isBadRequest(err) {
+			t.Errorf("EnrollFace(%q) error = %v, want 400", image, err)
+		}
+		if _, err := m.RecognizeFace(ctx, image); !isBadRequest(err) {
+			t.Errorf("RecognizeFace(%q) error = %v, want 400", image, err)
+		}
+		if resp, err := m.ValidateFace(ctx, image); err != nil || resp.Valid {
+			t.Errorf("ValidateFace(%q) = %+v, %v, want invalid", image, resp, err)
+		}
+	}
+}
+
+func isBadRequest(err error) bool {
+	var faceErr *FaceServiceError
+	return errors.As(err, &faceErr) && faceErr.Status == http.StatusBadRequest
+}
@@ -0,0 +1,320 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/mail"
+	"net/url"
+	"smarthome-backend/database/models"
+	"smarthome-backend/internal/repository"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrChannelNotFound      = errors.New("notification channel not found")
+	ErrChannelUnavailable   = errors.New("this channel kind is not configured on the server")
+	ErrInvalidChannelTarget = errors.New("invalid channel target")
+)
+
+// NotificationDeliveryPolicy controls retries and per-channel rate limiting
+type NotificationDeliveryPolicy struct {
+	MaxRetries         int           // extra attempts after the first one
+	RetryBackoff       time.Duration // doubled after every failed attempt
+	RateLimitPerMinute int           // per channel, 0 = unlimited
+	Timeout            time.Duration // per attempt
+}
+
+type NotificationChannelService interface {
+	Create(userID uint, req models.NotificationChannelRequest) (*models.NotificationChannel, error)
+	Update(userID, id uint, req models.NotificationChannelRequest) (*models.NotificationChannel, error)
+	Delete(userID, id uint) error
+	GetByUser(userID uint) ([]models.NotificationChannel, error)
+	AvailableKinds() []string
+
+	// SendTest delivers a test message synchronously and returns the log entry
+	SendTest(userID, id uint) (*models.NotificationDelivery, error)
+	GetDeliveries(userID uint, channelID *uint, status string, limit int) ([]models.NotificationDelivery, error)
+
+	// Dispatch fans a stored notification out to every subscribed channel (async)
+	Dispatch(notif *models.Notification)
+}
+
+type notificationChannelService struct {
+	repo      repository.NotificationChannelRepository
+	notifiers map[string]Notifier
+	policy    NotificationDeliveryPolicy
+
+	mu     sync.Mutex
+	recent map[uint][]time.Time // channel ID -> send times within the last minute
+}
+
+// NewNotificationChannelService - only kinds with a notifier can be used
+// (e.g. email is unavailable when SMTP is not configured)
+func NewNotificationChannelService(
+	r repository.NotificationChannelRepository,
+	policy NotificationDeliveryPolicy,
+	notifiers ...Notifier,
+) NotificationChannelService {
+	s := &notificationChannelService{
+		repo:      r,
+		notifiers: make(map[string]Notifier),
+		policy:    policy,
+		recent:    make(map[uint][]time.Time),
+	}
+	for _, n := range notifiers {
+		s.notifiers[n.Kind()] = n
+	}
+	return s
+}
+
+func (s *notificationChannelService) AvailableKinds() []string {
+	kinds := make([]string, 0, len(s.notifiers))
+	for _, kind := range []string{models.ChannelWebhook, models.ChannelEmail, models.ChannelChatBot} {
+		if _, ok := s.notifiers[kind]; ok {
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds
+}
+
+func (s *notificationChannelService) Create(userID uint, req models.NotificationChannelRequest) (*models.NotificationChannel, error) {
+	if err := s.validate(req); err != nil {
+		return nil, err
+	}
+
+	channel := &models.NotificationChannel{
+		UserID:  userID,
+		Kind:    req.Kind,
+		Name:    req.Name,
+		Target:  strings.TrimSpace(req.Target),
+		Types:   models.StringList(req.Types),
+		Enabled: req.Enabled == nil || *req.Enabled,
+	}
+	if err := s.repo.Create(channel); err != nil {
+		return nil, err
+	}
+	return channel, nil
+}
+
+func (s *notificationChannelService) Update(userID, id uint, req models.NotificationChannelRequest) (*models.NotificationChannel, error) {
+	channel, err := s.getOwned(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validate(req); err != nil {
+		return nil, err
+	}
+
+	channel.Kind = req.Kind
+	channel.Name = req.Name
+	channel.Target = strings.TrimSpace(req.Target)
+	channel.Types = models.StringList(req.Types)
+	if req.Enabled != nil {
+		channel.Enabled = *req.Enabled
+	}
+	if err := s.repo.Update(channel); err != nil {
+		return nil, err
+	}
+	return channel, nil
+}
+
+func (s *notificationChannelService) Delete(userID, id uint) error {
+	deleted, err := s.repo.Delete(userID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrChannelNotFound
+	}
+
+	s.mu.Lock()
+	delete(s.recent, id)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *notificationChannelService) GetByUser(userID uint) ([]models.NotificationChannel, error) {
+	return s.repo.GetByUser(userID)
+}
+
+func (s *notificationChannelService) GetDeliveries(userID uint, channelID *uint, status string, limit int) ([]models.NotificationDelivery, error) {
+	return s.repo.GetDeliveries(userID, channelID, status, limit)
+}
+
+func (s *notificationChannelService) SendTest(userID, id uint) (*models.NotificationDelivery, error) {
+	channel, err := s.getOwned(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := OutboundMessage{
+		Type:      models.NotifSystem,
+		Title:     "Test Notification",
+		Message:   "Channel \"" + channel.Name + "\" is set up correctly.",
+		Timestamp: time.Now(),
+	}
+	return s.deliver(channel, nil, msg), nil
+}
+
+func (s *notificationChannelService) Dispatch(notif *models.Notification) {
+	channels, err := s.repo.GetSubscribed(notif.Type)
+	if err != nil {
+		log.Printf("[NOTIFY] Failed to load channels for %s: %v", notif.Type, err)
+		return
+	}
+
+	notifID := notif.NotifID
+	msg := OutboundMessage{
+		NotifID:   notif.NotifID,
+		Type:      notif.Type,
+		Title:     notif.Title,
+		Message:   notif.Message,
+		Timestamp: notif.Timestamp,
+	}
+	if msg.Timestamp.IsZero() {
+		msg.Timestamp = time.Now()
+	}
+
+	for i := range channels {
+		go s.deliver(&channels[i], &notifID, msg)
+	}
+}
+
+// deliver sends with retries and records the outcome in the delivery log
+func (s *notificationChannelService) deliver(channel *models.NotificationChannel, notifID *uint, msg OutboundMessage) *models.NotificationDelivery {
+	delivery := &models.NotificationDelivery{
+		NotifID:   notifID,
+		ChannelID: channel.ID,
+		UserID:    channel.UserID,
+		Kind:      channel.Kind,
+	}
+
+	notifier, ok := s.notifiers[channel.Kind]
+	switch {
+	case !ok:
+		delivery.Status = models.DeliveryFailed
+		delivery.Error = ErrChannelUnavailable.Error()
+	case !s.allow(channel.ID):
+		delivery.Status = models.DeliveryRateLimited
+		delivery.Error = "rate limit exceeded"
+	default:
+		s.sendWithRetry(notifier, channel, msg, delivery)
+	}
+
+	if delivery.Status != models.DeliverySent {
+		log.Printf("[NOTIFY] %s channel #%d: %s (%s)", channel.Kind, channel.ID, delivery.Status, delivery.Error)
+	}
+	if err := s.repo.CreateDelivery(delivery); err != nil {
+		log.Printf("[NOTIFY] Failed to log delivery: %v", err)
+	}
+	return delivery
+}
+
+func (s *notificationChannelService) sendWithRetry(notifier Notifier, channel *models.NotificationChannel, msg OutboundMessage, delivery *models.NotificationDelivery) {
+	backoff := s.policy.RetryBackoff
+	var err error
+
+	for attempt := 0; attempt <= s.policy.MaxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), s.policy.Timeout)
+		err = notifier.Send(ctx, channel.Target, msg)
+		cancel()
+		delivery.Attempts++
+
+		if err == nil || isPermanent(err) {
+			break
+		}
+	}
+
+	if err != nil {
+		delivery.Status = models.DeliveryFailed
+		delivery.Error = truncate(err.Error(), 500)
+		return
+	}
+	now := time.Now()
+	delivery.Status = models.DeliverySent
+	delivery.DeliveredAt = &now
+}
+
+// allow applies the per-channel sliding window (one minute)
+func (s *notificationChannelService) allow(channelID uint) bool {
+	if s.policy.RateLimitPerMinute <= 0 {
+		return true
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.recent[channelID][:0]
+	for _, t := range s.recent[channelID] {
+		if now.Sub(t) < time.Minute {
+			kept = append(kept, t)
+		}
+	}
+	if len(kept) >= s.policy.RateLimitPerMinute {
+		s.recent[channelID] = kept
+		return false
+	}
+	s.recent[channelID] = append(kept, now)
+	return true
+}
+
+func (s *notificationChannelService) getOwned(userID, id uint) (*models.NotificationChannel, error) {
+	channel, err := s.repo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrChannelNotFound
+		}
+		return nil, err
+	}
+	if channel.UserID != userID {
+		return nil, ErrChannelNotFound
+	}
+	return channel, nil
+}
+
+func (s *notificationChannelService) validate(req models.NotificationChannelRequest) error {
+	if _, ok := s.notifiers[req.Kind]; !ok {
+		return ErrChannelUnavailable
+	}
+
+	target := strings.TrimSpace(req.Target)
+	switch req.Kind {
+	case models.ChannelWebhook:
+		u, err := url.Parse(target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+			return ErrInvalidChannelTarget
+		}
+		// No loopback / LAN / link-local targets: the test endpoint echoes the response
+		if err := checkPublicHost(u.Hostname()); err != nil {
+			return ErrInvalidChannelTarget
+		}
+	case models.ChannelEmail:
+		addr, err := mail.ParseAddress(target)
+		if err != nil || addr.Address != target {
+			return ErrInvalidChannelTarget
+		}
+	case models.ChannelChatBot:
+		if target == "" || strings.ContainsAny(target, " \t\r\n") {
+			return ErrInvalidChannelTarget
+		}
+	}
+	return nil
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max]
+}
@@ -4,20 +4,46 @@ import (
 	"log"
 	"smarthome-backend/database/models"
 	"smarthome-backend/internal/repository"
+	"smarthome-backend/internal/websocket"
+	"sync"
+	"time"
 )
 
 type NotificationService interface {
 	Create(req models.NotificationRequest) error
 	GetAll(limit int) ([]models.Notification, error)
 	GetByType(notifType string, limit int) ([]models.Notification, error)
+
+	// Notify creates a notification from a domain event (errors are only logged).
+	// NotifyThrottled drops repeats of the same key within window.
+	Notify(notifType, title, message string)
+	NotifyThrottled(key string, window time.Duration, notifType, title, message string)
+
+	// Per-user read state
+	List(userID uint, filter models.NotificationFilter) (*models.NotificationPage, error)
+	CountUnread(userID uint, types []string) (int64, error)
+	MarkRead(userID uint, ids []uint) error
+	MarkAllRead(userID uint, types []string) (int64, error)
 }
 
 type notificationService struct {
-	repo repository.NotificationRepository
+	repo     repository.NotificationRepository
+	hub      *websocket.Hub
+	channels NotificationChannelService
+
+	mu       sync.Mutex
+	lastSent map[string]time.Time
 }
 
-func NewNotificationService(r repository.NotificationRepository) NotificationService {
-	return &notificationService{repo: r}
+// NewNotificationService - channels (optional) pushes every notification to
+// the users' outbound channels (webhook, email, chat bot)
+func NewNotificationService(r repository.NotificationRepository, hub *websocket.Hub, channels NotificationChannelService) NotificationService {
+	return &notificationService{
+		repo:     r,
+		hub:      hub,
+		channels: channels,
+		lastSent: make(map[string]time.Time),
+	}
 }
 
 func (s *notificationService) Create(req models.NotificationRequest) error {
@@ -34,6 +60,13 @@ func (s *notificationService) Create(req models.NotificationRequest) error {
 	}
 
 	log.Printf("Notification created: %s (%s)", req.Title, req.Type)
+
+	if s.hub != nil {
+		s.hub.Broadcast(websocket.EventNotification, notif)
+	}
+	if s.channels != nil {
+		go s.channels.Dispatch(notif)
+	}
 	return nil
 }
 
@@ -44,3 +77,59 @@ func (s *notificationService) GetAll(limit int) ([]models.Notification, error) {
 func (s *notificationService) GetByType(notifType string, limit int) ([]models.Notification, error) {
 	return s.repo.GetByType(notifType, limit)
 }
+
+func (s *notificationService) Notify(notifType, title, message string) {
+	// Create already logs failures
+	_ = s.Create(models.NotificationRequest{Title: title, Message: message, Type: notifType})
+}
+
+func (s *notificationService) NotifyThrottled(key string, window time.Duration, notifType, title, message string) {
+	now := time.Now()
+
+	s.mu.Lock()
+	if last, ok := s.lastSent[key]; ok && now.Sub(last) < window {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSent[key] = now
+	s.mu.Unlock()
+
+	s.Notify(notifType, title, message)
+}
+
+func (s *notificationService) List(userID uint, filter models.NotificationFilter) (*models.NotificationPage, error) {
+	notifs, total, err := s.repo.List(userID, filter)
+	if err != nil {
+		return nil, err
+	}
+	unread, err := s.repo.CountUnread(userID, filter.Types)
+	if err != nil {
+		return nil, err
+	}
+
+	if notifs == nil {
+		notifs = make([]models.Notification, 0)
+	}
+	totalPages := int((total + int64(filter.PageSize) - 1) / int64(filter.PageSize))
+
+	return &models.NotificationPage{
+		Data:        notifs,
+		Total:       total,
+		Page:        filter.Page,
+		PageSize:    filter.PageSize,
+		TotalPages:  totalPages,
+		UnreadCount: unread,
+	}, nil
+}
+
+func (s *notificationService) CountUnread(userID uint, types []string) (int64, error) {
+	return s.repo.CountUnread(userID, types)
+}
+
+func (s *notificationService) MarkRead(userID uint, ids []uint) error {
+	return s.repo.MarkRead(userID, ids)
+}
+
+func (s *notificationService) MarkAllRead(userID uint, types []string) (int64, error) {
+	return s.repo.MarkAllRead(userID, types)
+}
@@ -0,0 +1,267 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"net/url"
+	"smarthome-backend/database/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OutboundMessage is what a Notifier delivers (NotifID is 0 for test messages)
+type OutboundMessage struct {
+	NotifID   uint      `json:"notif_id,omitempty"`
+	Type      string    `json:"type"`
+	Title     string    `json:"title"`
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Notifier delivers a message to one target of its kind
+// (webhook URL, email address or chat ID).
+type Notifier interface {
+	Kind() string
+	Send(ctx context.Context, target string, msg OutboundMessage) error
+}
+
+// permanentError marks failures that retrying will not fix (4xx, rejected recipient, ...)
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+func isPermanent(err error) bool {
+	var perm *permanentError
+	return errors.As(err, &perm)
+}
+
+// httpStatusError classifies a non-2xx response; 408/429/5xx are worth retrying,
+// redirects (never followed) and other 4xx are not
+func httpStatusError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+	err := fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	if resp.StatusCode < 500 &&
+		resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return &permanentError{err: err}
+	}
+	return err
+}
+
+// withoutURL drops the request URL that *url.Error embeds; delivery errors are
+// stored and shown to users, and URLs can carry credentials (bot token, webhook secret)
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("%s request failed: %w", urlErr.Op, urlErr.Err)
+	}
+	return err
+}
+
+// ==================== WEBHOOK ====================
+
+type webhookNotifier struct {
+	client *http.Client
+}
+
+// NewWebhookNotifier posts the message as JSON to the target URL. Targets are
+// user-supplied, so pass NewPublicHTTPClient(); redirects are never followed.
+func NewWebhookNotifier(client *http.Client) Notifier {
+	c := *client
+	c.CheckRedirect = noRedirects
+	return &webhookNotifier{client: &c}
+}
+
+func (n *webhookNotifier) Kind() string { return models.ChannelWebhook }
+
+func (n *webhookNotifier) Send(ctx context.Context, target string, msg OutboundMessage) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return &permanentError{err: err}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return &permanentError{err: withoutURL(err)}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "smarthome-backend")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		if errors.Is(err, ErrNonPublicAddress) {
+			return &permanentError{err: withoutURL(err)}
+		}
+		return withoutURL(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return httpStatusError(resp)
+	}
+	return nil
+}
+
+// ==================== CHAT BOT ====================
+
+type chatBotNotifier struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// NewChatBotNotifier sends through a Telegram-style bot API:
+// POST {baseURL}/bot{token}/sendMessage {"chat_id": target, "text": ...}
+func NewChatBotNotifier(baseURL, token string, client *http.Client) Notifier {
+	return &chatBotNotifier{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		client:  client,
+	}
+}
+
+func (n *chatBotNotifier) Kind() string { return models.ChannelChatBot }
+
+func (n *chatBotNotifier) Send(ctx context.Context, target string, msg OutboundMessage) error {
+	body, _ := json.Marshal(map[string]string{
+		"chat_id": target,
+		"text":    fmt.Sprintf("%s\n%s", msg.Title, msg.Message),
+	})
+
+	// The token is part of the URL, so it must never end up in an error message
+	endpoint := fmt.Sprintf("%s/bot%s/sendMessage", n.baseURL, n.token)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return &permanentError{err: withoutURL(err)}
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return withoutURL(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return httpStatusError(resp)
+	}
+
+	// Bot APIs answer 200 with {"ok": false} for some rejections
+	var result struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err == nil && !result.OK {
+		return &permanentError{err: fmt.Errorf("bot API rejected message: %s", result.Description)}
+	}
+	return nil
+}
+
+// ==================== SMTP ====================
+
+// SMTPConfig for the email notifier (Username empty = no AUTH)
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+type smtpNotifier struct {
+	cfg SMTPConfig
+}
+
+// NewSMTPNotifier sends plain-text mail, upgrading with STARTTLS when offered
+func NewSMTPNotifier(cfg SMTPConfig) Notifier {
+	return &smtpNotifier{cfg: cfg}
+}
+
+func (n *smtpNotifier) Kind() string { return models.ChannelEmail }
+
+func (n *smtpNotifier) Send(ctx context.Context, target string, msg OutboundMessage) error {
+	addr := net.JoinHostPort(n.cfg.Host, strconv.Itoa(n.cfg.Port))
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, n.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: n.cfg.Host}); err != nil {
+			return err
+		}
+	}
+	if n.cfg.Username != "" {
+		auth := smtp.PlainAuth("", n.cfg.Username, n.cfg.Password, n.cfg.Host)
+		if err := client.Auth(auth); err != nil {
+			return smtpError(err)
+		}
+	}
+
+	if err := client.Mail(n.cfg.From); err != nil {
+		return smtpError(err)
+	}
+	if err := client.Rcpt(target); err != nil {
+		return smtpError(err)
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return smtpError(err)
+	}
+	if _, err := w.Write(n.buildMessage(target, msg)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return smtpError(err)
+	}
+	return client.Quit()
+}
+
+func (n *smtpNotifier) buildMessage(to string, msg OutboundMessage) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + n.cfg.From + "\r\n")
+	b.WriteString("To: " + to + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", msg.Title) + "\r\n")
+	b.WriteString("Date: " + msg.Timestamp.Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Message, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
+
+// smtpError treats 5xx replies (unknown mailbox, auth rejected) as permanent
+func smtpError(err error) error {
+	var tpErr *textproto.Error
+	if errors.As(err, &tpErr) && tpErr.Code >= 500 {
+		return &permanentError{err: err}
+	}
+	return err
+}
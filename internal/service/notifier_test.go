@@ -0,0 +1,399 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"smarthome-backend/database/models"
+)
+
+var testMessage = OutboundMessage{
+	Type:      models.NotifSystem,
+	Title:     "Test Notification",
+	Message:   "Channel is set up correctly.",
+	Timestamp: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+}
+
+// ==================== WEBHOOK ====================
+
+func TestWebhookNotifierSend(t *testing.T) {
+	var got OutboundMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	n := NewWebhookNotifier(srv.Client())
+	if err := n.Send(context.Background(), srv.URL, testMessage); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if got.Title != testMessage.Title || got.Message != testMessage.Message {
+		t.Errorf("received %+v", got)
+	}
+}
+
+func TestWebhookNotifierErrorClassification(t *testing.T) {
+	tests := []struct {
+		status    int
+		permanent bool
+	}{
+		{http.StatusInternalServerError, false},
+		{http.StatusServiceUnavailable, false},
+		{http.StatusTooManyRequests, false},
+		{http.StatusRequestTimeout, false},
+		{http.StatusBadRequest, true},
+		{http.StatusNotFound, true},
+		{http.StatusFound, true},
+	}
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.status), func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.status == http.StatusFound {
+					w.Header().Set("Location", "http://169.254.169.254/latest/meta-data/")
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			err := NewWebhookNotifier(srv.Client()).Send(context.Background(), srv.URL, testMessage)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if isPermanent(err) != tt.permanent {
+				t.Errorf("isPermanent = %v, want %v (%v)", isPermanent(err), tt.permanent, err)
+			}
+		})
+	}
+}
+
+func TestWebhookNotifierPublicClientRejectsLoopback(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+	}))
+	defer srv.Close()
+
+	err := NewWebhookNotifier(NewPublicHTTPClient()).Send(context.Background(), srv.URL, testMessage)
+	if err == nil || !isPermanent(err) {
+		t.Fatalf("expected a permanent error, got %v", err)
+	}
+	if atomic.LoadInt32(&hits) != 0 {
+		t.Error("request reached a loopback server")
+	}
+}
+
+// ==================== CHAT BOT ====================
+
+func TestChatBotNotifierSend(t *testing.T) {
+	const token = "123:secret-token"
+	var body map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bot"+token+"/sendMessage" {
+			t.Errorf("path = %q", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	n := NewChatBotNotifier(srv.URL+"/", token, srv.Client())
+	if err := n.Send(context.Background(), "42", testMessage); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if body["chat_id"] != "42" || !strings.Contains(body["text"], testMessage.Title) {
+		t.Errorf("received %v", body)
+	}
+}
+
+func TestChatBotNotifierRejected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":false,"description":"chat not found"}`))
+	}))
+	defer srv.Close()
+
+	err := NewChatBotNotifier(srv.URL, "token", srv.Client()).Send(context.Background(), "42", testMessage)
+	if err == nil || !isPermanent(err) {
+		t.Fatalf("expected a permanent error, got %v", err)
+	}
+}
+
+func TestChatBotNotifierErrorHidesToken(t *testing.T) {
+	const token = "123:secret-token"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := srv.URL
+	srv.Close() // connection refused
+
+	err := NewChatBotNotifier(url, token, &http.Client{}).Send(context.Background(), "42", testMessage)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if isPermanent(err) {
+		t.Errorf("transport errors should be retried: %v", err)
+	}
+	if strings.Contains(err.Error(), token) {
+		t.Errorf("error leaks the bot token: %v", err)
+	}
+}
+
+// ==================== SMTP ====================
+
+// smtpStub is a minimal SMTP server; rcptReply overrides the RCPT TO answer
+type smtpStub struct {
+	addr      string
+	rcptReply string
+
+	mu   sync.Mutex
+	data []string
+}
+
+func newSMTPStub(t *testing.T, rcptReply string) *smtpStub {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	stub := &smtpStub{addr: ln.Addr().String(), rcptReply: rcptReply}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go stub.serve(conn)
+		}
+	}()
+	return stub
+}
+
+func (s *smtpStub) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 stub ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 stub")
+		case strings.HasPrefix(cmd, "MAIL FROM"):
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO"):
+			if s.rcptReply != "" {
+				reply(s.rcptReply)
+			} else {
+				reply("250 OK")
+			}
+		case cmd == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var b strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				b.WriteString(l)
+			}
+			s.mu.Lock()
+			s.data = append(s.data, b.String())
+			s.mu.Unlock()
+			reply("250 OK queued")
+		case cmd == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func (s *smtpStub) notifier(t *testing.T) Notifier {
+	host, portStr, _ := net.SplitHostPort(s.addr)
+	port, _ := strconv.Atoi(portStr)
+	return NewSMTPNotifier(SMTPConfig{Host: host, Port: port, From: "smarthome@localhost"})
+}
+
+func TestSMTPNotifierSend(t *testing.T) {
+	stub := newSMTPStub(t, "")
+
+	if err := stub.notifier(t).Send(context.Background(), "owner@example.com", testMessage); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	stub.mu.Lock()
+	defer stub.mu.Unlock()
+	if len(stub.data) != 1 {
+		t.Fatalf("received %d messages", len(stub.data))
+	}
+	msg := stub.data[0]
+	for _, want := range []string{"To: owner@example.com", "Subject: ", testMessage.Message} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message misses %q:\n%s", want, msg)
+		}
+	}
+}
+
+func TestSMTPNotifierErrorClassification(t *testing.T) {
+	tests := []struct {
+		reply     string
+		permanent bool
+	}{
+		{"550 No such user", true},
+		{"451 Try again later", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reply[:3], func(t *testing.T) {
+			stub := newSMTPStub(t, tt.reply)
+			err := stub.notifier(t).Send(context.Background(), "owner@example.com", testMessage)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if isPermanent(err) != tt.permanent {
+				t.Errorf("isPermanent = %v, want %v (%v)", isPermanent(err), tt.permanent, err)
+			}
+		})
+	}
+}
+
+// ==================== DELIVERY (retry / rate limit) ====================
+
+type stubChannelRepo struct {
+	channel    models.NotificationChannel
+	mu         sync.Mutex
+	deliveries []models.NotificationDelivery
+}
+
+func (r *stubChannelRepo) Create(channel *models.NotificationChannel) error { return nil }
+func (r *stubChannelRepo) Update(channel *models.NotificationChannel) error { return nil }
+func (r *stubChannelRepo) Delete(userID, id uint) (bool, error)             { return true, nil }
+func (r *stubChannelRepo) GetByID(id uint) (*models.NotificationChannel, error) {
+	channel := r.channel
+	return &channel, nil
+}
+func (r *stubChannelRepo) GetByUser(userID uint) ([]models.NotificationChannel, error) {
+	return []models.NotificationChannel{r.channel}, nil
+}
+func (r *stubChannelRepo) GetSubscribed(notifType string) ([]models.NotificationChannel, error) {
+	return []models.NotificationChannel{r.channel}, nil
+}
+func (r *stubChannelRepo) CreateDelivery(delivery *models.NotificationDelivery) error {
+	r.mu.Lock()
+	r.deliveries = append(r.deliveries, *delivery)
+	r.mu.Unlock()
+	return nil
+}
+func (r *stubChannelRepo) GetDeliveries(userID uint, channelID *uint, status string, limit int) ([]models.NotificationDelivery, error) {
+	return r.deliveries, nil
+}
+
+// newDeliveryTest serves status codes in order (the last one repeats)
+func newDeliveryTest(t *testing.T, policy NotificationDeliveryPolicy, statuses ...int) (NotificationChannelService, *int32) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := int(atomic.AddInt32(&hits, 1)) - 1
+		if i >= len(statuses) {
+			i = len(statuses) - 1
+		}
+		w.WriteHeader(statuses[i])
+	}))
+	t.Cleanup(srv.Close)
+
+	repo := &stubChannelRepo{channel: models.NotificationChannel{
+		ID:      1,
+		UserID:  7,
+		Kind:    models.ChannelWebhook,
+		Name:    "test",
+		Target:  srv.URL,
+		Enabled: true,
+	}}
+	if policy.Timeout == 0 {
+		policy.Timeout = time.Second
+	}
+	return NewNotificationChannelService(repo, policy, NewWebhookNotifier(srv.Client())), &hits
+}
+
+func TestDeliveryRetriesTransientErrors(t *testing.T) {
+	svc, hits := newDeliveryTest(t, NotificationDeliveryPolicy{MaxRetries: 3, RetryBackoff: time.Millisecond},
+		http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK)
+
+	delivery, err := svc.SendTest(7, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delivery.Status != models.DeliverySent || delivery.Attempts != 3 || atomic.LoadInt32(hits) != 3 {
+		t.Errorf("status=%s attempts=%d hits=%d", delivery.Status, delivery.Attempts, atomic.LoadInt32(hits))
+	}
+}
+
+func TestDeliveryGivesUpAfterMaxRetries(t *testing.T) {
+	svc, hits := newDeliveryTest(t, NotificationDeliveryPolicy{MaxRetries: 2, RetryBackoff: time.Millisecond},
+		http.StatusInternalServerError)
+
+	delivery, _ := svc.SendTest(7, 1)
+	if delivery.Status != models.DeliveryFailed || delivery.Attempts != 3 || atomic.LoadInt32(hits) != 3 {
+		t.Errorf("status=%s attempts=%d hits=%d", delivery.Status, delivery.Attempts, atomic.LoadInt32(hits))
+	}
+}
+
+func TestDeliveryDoesNotRetryPermanentErrors(t *testing.T) {
+	svc, hits := newDeliveryTest(t, NotificationDeliveryPolicy{MaxRetries: 3, RetryBackoff: time.Millisecond},
+		http.StatusBadRequest)
+
+	delivery, _ := svc.SendTest(7, 1)
+	if delivery.Status != models.DeliveryFailed || delivery.Attempts != 1 || atomic.LoadInt32(hits) != 1 {
+		t.Errorf("status=%s attempts=%d hits=%d", delivery.Status, delivery.Attempts, atomic.LoadInt32(hits))
+	}
+	if !strings.Contains(delivery.Error, "HTTP 400") {
+		t.Errorf("error = %q", delivery.Error)
+	}
+}
+
+func TestDeliveryRateLimit(t *testing.T) {
+	svc, hits := newDeliveryTest(t, NotificationDeliveryPolicy{RateLimitPerMinute: 2}, http.StatusOK)
+
+	var statuses []string
+	for i := 0; i < 3; i++ {
+		delivery, _ := svc.SendTest(7, 1)
+		statuses = append(statuses, delivery.Status)
+	}
+	want := []string{models.DeliverySent, models.DeliverySent, models.DeliveryRateLimited}
+	for i := range want {
+		if statuses[i] != want[i] {
+			t.Fatalf("statuses = %v, want %v", statuses, want)
+		}
+	}
+	if atomic.LoadInt32(hits) != 2 {
+		t.Errorf("hits = %d, want 2", atomic.LoadInt32(hits))
+	}
+}
+
+func TestDeliveryOtherUsersChannel(t *testing.T) {
+	svc, _ := newDeliveryTest(t, NotificationDeliveryPolicy{}, http.StatusOK)
+
+	if _, err := svc.SendTest(8, 1); err != ErrChannelNotFound {
+		t.Errorf("err = %v, want ErrChannelNotFound", err)
+	}
+}
@@ -1,21 +1,135 @@
 package service
 
 import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
 	"smarthome-backend/database/models"
 	"smarthome-backend/internal/repository"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 type PinService interface {
+	AttemptLimiter
+
 	GetUniversalPin() (*models.PinCode, error)
 	SetUniversalPin(pin string, setBy uint) error
+	VerifyPin(source, pin string) (*models.PinVerifyResult, error)
+	MigrateLegacyPins() error
+
+	// Universal PIN fallback toggle
+	IsUniversalPinEnabled() bool
+	SetUniversalPinEnabled(enabled bool, setBy uint) error
+
+	// Personal PINs
+	GetUserPin(userID uint) (*models.UserPin, error)
+	SetUserPin(userID, changedBy uint, pin string) error
+	GenerateUserPin(userID uint) (string, error)
+	DeleteUserPin(userID uint) error
+}
+
+var ErrPinInUse = errors.New("PIN already in use, choose a different one")
+
+// ErrPinRejected hides why a personal PIN was refused so PIN changes can't be
+// used to probe other users' PINs, guest codes or the universal PIN
+var ErrPinRejected = errors.New("PIN not accepted, choose a different one or generate one")
+
+// PinChangeLockedError is returned while a caller is locked out of PIN changes
+type PinChangeLockedError struct {
+	LockoutSeconds int
+}
+
+func (e *PinChangeLockedError) Error() string {
+	return fmt.Sprintf("too many rejected PIN changes, try again in %ds", e.LockoutSeconds)
 }
 
+const generatedUserPinLength = 6
+
+// AttemptLimiter is the brute-force counter behind PIN entry. Other door
+// credentials (fingerprint) report into the same per-source counters, so a
+// source locked out for PINs is locked out for everything. Lockouts are per
+// source: a passer-by guessing PINs never locks out fingerprints.
+type AttemptLimiter interface {
+	// CheckLocked returns the lockout result while source is locked, nil otherwise
+	CheckLocked(source string) (*models.PinVerifyResult, error)
+	// RecordFailure counts a failed attempt of the given credential ("fingerprint", ...)
+	RecordFailure(source, credential string) (*models.PinVerifyResult, error)
+	RecordSuccess(source string)
+}
+
+// PinLockoutPolicy controls brute-force protection for PIN entry
+type PinLockoutPolicy struct {
+	MaxAttempts int           // failures allowed before a lockout
+	BaseLockout time.Duration // first lockout duration, doubled on every repeat
+	MaxLockout  time.Duration // upper bound for progressive lockouts
+
+	// Wrong PINs from all sources combined before PIN entry pauses everywhere;
+	// only throttles PIN guessing, other credentials keep working
+	GlobalMaxAttempts int
+}
+
+// pinGlobalSource is the shared counter behind GlobalMaxAttempts
+const pinGlobalSource = "pin:global"
+
+// Lockout level is forgotten after a quiet day without failures
+const pinLockoutLevelResetAfter = 24 * time.Hour
+
 type pinService struct {
-	repo repository.PinRepository
+	repo          repository.PinRepository
+	userPinRepo   repository.UserPinRepository
+	attemptRepo   repository.PinAttemptRepository
+	accessLogRepo repository.AccessLogRepository
+	settingSvc    SettingService
+	guestSvc      GuestCodeService
+	notifSvc      NotificationService
+	events        EventPublisher
+	policy        PinLockoutPolicy
+
+	// Serializes attempts so concurrent guesses can't bypass the counter
+	mu sync.Mutex
 }
 
-func NewPinService(r repository.PinRepository) PinService {
-	return &pinService{repo: r}
+func NewPinService(
+	r repository.PinRepository,
+	userPinRepo repository.UserPinRepository,
+	attemptRepo repository.PinAttemptRepository,
+	accessLogRepo repository.AccessLogRepository,
+	settingSvc SettingService,
+	guestSvc GuestCodeService,
+	notifSvc NotificationService,
+	events EventPublisher,
+	policy PinLockoutPolicy,
+) PinService {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 5
+	}
+	if policy.BaseLockout <= 0 {
+		policy.BaseLockout = 30 * time.Second
+	}
+	if policy.MaxLockout < policy.BaseLockout {
+		policy.MaxLockout = policy.BaseLockout
+	}
+	if policy.GlobalMaxAttempts < policy.MaxAttempts {
+		policy.GlobalMaxAttempts = 4 * policy.MaxAttempts
+	}
+
+	return &pinService{
+		repo:          r,
+		userPinRepo:   userPinRepo,
+		attemptRepo:   attemptRepo,
+		accessLogRepo: accessLogRepo,
+		settingSvc:    settingSvc,
+		guestSvc:      guestSvc,
+		notifSvc:      notifSvc,
+		events:        events,
+		policy:        policy,
+	}
 }
 
 func (s *pinService) GetUniversalPin() (*models.PinCode, error) {
@@ -23,12 +137,457 @@ func (s *pinService) GetUniversalPin() (*models.PinCode, error) {
 }
 
 func (s *pinService) SetUniversalPin(pin string, setBy uint) error {
+	// Must not shadow a personal PIN, otherwise keypad attribution is ambiguous
+	if owner, err := s.findUserByPin(pin); err != nil {
+		return err
+	} else if owner != nil {
+		return ErrPinInUse
+	}
+	if inUse, err := s.guestSvc.IsCodeInUse(pin); err != nil {
+		return err
+	} else if inUse {
+		return ErrPinInUse
+	}
+
+	pinHash, err := hashPin(pin)
+	if err != nil {
+		return err
+	}
+
 	// Cek apakah sudah ada data
 	existing, err := s.repo.GetUniversalPin()
 	if err != nil || existing.ID == 0 {
 		// Belum ada data, CREATE
-		return s.repo.Create(pin, setBy)
+		return s.repo.Create(pinHash, setBy)
 	}
 	// Sudah ada data, UPDATE
-	return s.repo.Update(pin, setBy)
+	return s.repo.Update(pinHash, setBy)
+}
+
+func (s *pinService) IsUniversalPinEnabled() bool {
+	return s.settingSvc.GetBool(SettingUniversalPinEnabled, true)
+}
+
+func (s *pinService) SetUniversalPinEnabled(enabled bool, setBy uint) error {
+	return s.settingSvc.SetBool(SettingUniversalPinEnabled, enabled, &setBy)
+}
+
+func (s *pinService) GetUserPin(userID uint) (*models.UserPin, error) {
+	return s.userPinRepo.GetByUserID(userID)
+}
+
+// SetUserPin stores a personal PIN; it must be unique across users, guest codes and the universal PIN.
+// Rejections are not explained and count against changedBy's lockout, otherwise
+// repeated changes would enumerate the PINs already in use.
+func (s *pinService) SetUserPin(userID, changedBy uint, pin string) error {
+	// Kept apart from the keypad counters: a rejected change must not lock PIN entry
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	attempt, err := s.loadAttempt(fmt.Sprintf("pin_change:user:%d", changedBy), now)
+	if err != nil {
+		return err
+	}
+	if attempt.LockedUntil != nil && now.Before(*attempt.LockedUntil) {
+		return &PinChangeLockedError{LockoutSeconds: s.lockedResult(attempt, now).LockoutSeconds}
+	}
+
+	inUse, err := s.pinInUse(userID, pin)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		if result := s.failAttempt(attempt, now, "PIN change"); result.Locked {
+			return &PinChangeLockedError{LockoutSeconds: result.LockoutSeconds}
+		}
+		return ErrPinRejected
+	}
+
+	pinHash, err := hashPin(pin)
+	if err != nil {
+		return err
+	}
+	return s.userPinRepo.Upsert(userID, pinHash)
+}
+
+// GenerateUserPin picks a random unused PIN for the user and returns it once in plaintext
+func (s *pinService) GenerateUserPin(userID uint) (string, error) {
+	max := big.NewInt(1)
+	for i := 0; i < generatedUserPinLength; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+
+	for i := 0; i < 10; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		pin := fmt.Sprintf("%0*d", generatedUserPinLength, n.Int64())
+
+		inUse, err := s.pinInUse(userID, pin)
+		if err != nil {
+			return "", err
+		}
+		if inUse {
+			continue
+		}
+
+		pinHash, err := hashPin(pin)
+		if err != nil {
+			return "", err
+		}
+		if err := s.userPinRepo.Upsert(userID, pinHash); err != nil {
+			return "", err
+		}
+		return pin, nil
+	}
+	return "", errors.New("failed to generate a unique PIN")
+}
+
+// pinInUse reports whether pin is taken by another user, a guest code or the universal PIN
+func (s *pinService) pinInUse(userID uint, pin string) (bool, error) {
+	owner, err := s.findUserByPin(pin)
+	if err != nil {
+		return false, err
+	}
+	if owner != nil && *owner != userID {
+		return true, nil
+	}
+
+	if universal, err := s.repo.GetUniversalPin(); err == nil && pinMatches(universal.PinHash, pin) {
+		return true, nil
+	}
+	return s.guestSvc.IsCodeInUse(pin)
+}
+
+func (s *pinService) DeleteUserPin(userID uint) error {
+	return s.userPinRepo.Delete(userID)
+}
+
+// findUserByPin returns the active user owning the PIN, or nil
+func (s *pinService) findUserByPin(pin string) (*uint, error) {
+	pins, err := s.userPinRepo.GetAllActive()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve user PINs: %w", err)
+	}
+	for _, p := range pins {
+		if pinMatches(p.PinHash, pin) {
+			userID := p.UserID
+			return &userID, nil
+		}
+	}
+	return nil, nil
+}
+
+// MigrateLegacyPins hashes plaintext PINs left by older versions. Safe to run on every start.
+func (s *pinService) MigrateLegacyPins() error {
+	if err := s.repo.EnsureHashColumn(); err != nil {
+		return fmt.Errorf("failed to prepare pin_codes table: %w", err)
+	}
+
+	legacy, err := s.repo.GetLegacyPins()
+	if err != nil {
+		return err
+	}
+
+	for _, p := range legacy {
+		pinHash, err := hashPin(*p.LegacyPin)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.ReplaceLegacyPin(p.ID, pinHash); err != nil {
+			return fmt.Errorf("failed to migrate PIN id=%d: %w", p.ID, err)
+		}
+	}
+
+	if len(legacy) > 0 {
+		log.Printf("[PIN] Migrated %d plaintext PIN(s) to bcrypt", len(legacy))
+	}
+	return nil
+}
+
+func hashPin(pin string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+// pinMatches compares in constant time; an empty hash never matches
+func pinMatches(pinHash, pin string) bool {
+	if pinHash == "" {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(pinHash), []byte(pin)) == nil
+}
+
+// VerifyPin checks a PIN entered from the given source (e.g. "http:10.0.0.5", "keypad").
+// Every attempt is written to access_logs; repeated failures lock the source out
+// for progressively longer periods.
+func (s *pinService) VerifyPin(source, pin string) (*models.PinVerifyResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	attempt, global, err := s.loadPinAttempts(source, now)
+	if err != nil {
+		return nil, err
+	}
+	if locked := s.lockedAttempt(now, attempt, global); locked != nil {
+		s.logAttempt("failed", nil, "")
+		return s.lockedResult(locked, now), nil
+	}
+
+	matched, userID, guest, err := s.matchPin(pin, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if matched {
+		s.resetAttempt(attempt, now)
+		result := &models.PinVerifyResult{
+			Valid:             true,
+			UserID:            userID,
+			RemainingAttempts: s.policy.MaxAttempts,
+			Message:           "PIN verified, door unlocked",
+		}
+		if guest != nil {
+			result.GuestLabel = guest.Label
+			result.Message = "Guest code accepted, door unlocked"
+			log.Printf("[PIN] Guest code %q used (%d/%d)", guest.Label, guest.UseCount, guest.MaxUses)
+		}
+		s.logAttempt("success", userID, result.GuestLabel)
+
+		return result, nil
+	}
+
+	result := s.failPinAttempts(attempt, global, now)
+	if !result.Locked {
+		result.Message = "Invalid PIN"
+		go s.notifSvc.NotifyThrottled("pin_failed:"+source, time.Minute, models.NotifAccess, "Failed Access",
+			fmt.Sprintf("Wrong PIN entered on %s (%d attempts left)", source, result.RemainingAttempts))
+	}
+	s.logAttempt("failed", nil, "")
+
+	return result, nil
+}
+
+func (s *pinService) CheckLocked(source string) (*models.PinVerifyResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	attempt, err := s.loadAttempt(source, now)
+	if err != nil {
+		return nil, err
+	}
+	if locked := s.lockedAttempt(now, attempt); locked != nil {
+		return s.lockedResult(locked, now), nil
+	}
+	return nil, nil
+}
+
+func (s *pinService) RecordFailure(source, credential string) (*models.PinVerifyResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	attempt, err := s.loadAttempt(source, now)
+	if err != nil {
+		return nil, err
+	}
+	if locked := s.lockedAttempt(now, attempt); locked != nil {
+		return s.lockedResult(locked, now), nil
+	}
+	return s.failAttempt(attempt, now, credential), nil
+}
+
+func (s *pinService) RecordSuccess(source string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	attempt, err := s.loadAttempt(source, now)
+	if err != nil {
+		log.Printf("[PIN] Failed to load attempts for %s: %v", source, err)
+		return
+	}
+	s.resetAttempt(attempt, now)
+}
+
+// loadAttempt returns the counter for source (new when unseen); caller holds s.mu
+func (s *pinService) loadAttempt(source string, now time.Time) (*models.PinAttempt, error) {
+	attempt, err := s.attemptRepo.GetBySource(source)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		return &models.PinAttempt{Source: source}, nil
+	}
+
+	// Forget old lockouts after a quiet period
+	if attempt.LockoutLevel > 0 && now.Sub(attempt.LastAttemptAt) > pinLockoutLevelResetAfter {
+		attempt.LockoutLevel = 0
+	}
+	return attempt, nil
+}
+
+// loadPinAttempts returns the source counter and the global PIN counter; caller holds s.mu
+func (s *pinService) loadPinAttempts(source string, now time.Time) (*models.PinAttempt, *models.PinAttempt, error) {
+	attempt, err := s.loadAttempt(source, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	global, err := s.loadAttempt(pinGlobalSource, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	return attempt, global, nil
+}
+
+// lockedAttempt returns the first counter that is currently locked, or nil
+func (s *pinService) lockedAttempt(now time.Time, attempts ...*models.PinAttempt) *models.PinAttempt {
+	for _, attempt := range attempts {
+		if attempt.LockedUntil != nil && now.Before(*attempt.LockedUntil) {
+			return attempt
+		}
+	}
+	return nil
+}
+
+// failPinAttempts counts a wrong PIN against both the source and the global budget; caller holds s.mu
+func (s *pinService) failPinAttempts(attempt, global *models.PinAttempt, now time.Time) *models.PinVerifyResult {
+	result := s.failAttempt(attempt, now, "PIN")
+	globalResult := s.failAttempt(global, now, "PIN")
+	if result.Locked {
+		return result
+	}
+	if globalResult.Locked || globalResult.RemainingAttempts < result.RemainingAttempts {
+		return globalResult
+	}
+	return result
+}
+
+// resetAttempt clears the counter after a successful entry; caller holds s.mu
+func (s *pinService) resetAttempt(attempt *models.PinAttempt, now time.Time) {
+	attempt.FailedCount = 0
+	attempt.LockoutLevel = 0
+	attempt.LockedUntil = nil
+	attempt.LastAttemptAt = now
+	if err := s.attemptRepo.Save(attempt); err != nil {
+		log.Printf("[PIN] Failed to reset attempts for %s: %v", attempt.Source, err)
+	}
+}
+
+// failAttempt counts a failure and locks the source once MaxAttempts is reached; caller holds s.mu
+func (s *pinService) failAttempt(attempt *models.PinAttempt, now time.Time, credential string) *models.PinVerifyResult {
+	attempt.FailedCount++
+	attempt.LastAttemptAt = now
+	attempt.LockedUntil = nil
+
+	maxAttempts := s.policy.MaxAttempts
+	if attempt.Source == pinGlobalSource {
+		maxAttempts = s.policy.GlobalMaxAttempts
+	}
+
+	result := &models.PinVerifyResult{
+		Valid:             false,
+		RemainingAttempts: maxAttempts - attempt.FailedCount,
+		Message:           "Invalid " + credential,
+	}
+
+	if attempt.FailedCount >= maxAttempts {
+		lockout := s.lockoutDuration(attempt.LockoutLevel)
+		until := now.Add(lockout)
+		attempt.LockedUntil = &until
+		attempt.LockoutLevel++
+		attempt.FailedCount = 0
+		result = s.lockedResult(attempt, now)
+		log.Printf("[PIN] Source %s locked out for %s after %d failed attempts", attempt.Source, lockout, maxAttempts)
+		go s.notifSvc.Notify(models.NotifAccess, "Keypad Locked",
+			fmt.Sprintf("%d failed %s attempts on %s, locked for %s", maxAttempts, credential, attempt.Source, lockout))
+	}
+
+	if err := s.attemptRepo.Save(attempt); err != nil {
+		log.Printf("[PIN] Failed to save attempts for %s: %v", attempt.Source, err)
+	}
+	return result
+}
+
+// matchPin resolves personal PINs first (attributed to a user), then guest
+// codes, then the universal PIN when the fallback is enabled
+func (s *pinService) matchPin(pin string, now time.Time) (bool, *uint, *models.GuestCode, error) {
+	userID, err := s.findUserByPin(pin)
+	if err != nil {
+		return false, nil, nil, err
+	}
+	if userID != nil {
+		return true, userID, nil, nil
+	}
+
+	guest, err := s.guestSvc.Match(pin, now)
+	if err != nil {
+		return false, nil, nil, err
+	}
+	if guest != nil {
+		return true, nil, guest, nil
+	}
+
+	if !s.IsUniversalPinEnabled() {
+		return false, nil, nil, nil
+	}
+
+	pinData, err := s.repo.GetUniversalPin()
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil, nil, nil
+		}
+		return false, nil, nil, fmt.Errorf("failed to retrieve PIN: %w", err)
+	}
+
+	return pinMatches(pinData.PinHash, pin), nil, nil, nil
+}
+
+// lockoutDuration doubles the base lockout for every previous lockout level
+func (s *pinService) lockoutDuration(level int) time.Duration {
+	d := s.policy.BaseLockout
+	for i := 0; i < level; i++ {
+		d *= 2
+		if d >= s.policy.MaxLockout {
+			return s.policy.MaxLockout
+		}
+	}
+	return d
+}
+
+func (s *pinService) lockedResult(attempt *models.PinAttempt, now time.Time) *models.PinVerifyResult {
+	seconds := int(attempt.LockedUntil.Sub(now).Seconds() + 0.5)
+	return &models.PinVerifyResult{
+		Valid:             false,
+		Locked:            true,
+		RemainingAttempts: 0,
+		LockedUntil:       attempt.LockedUntil,
+		LockoutSeconds:    seconds,
+		Message:           fmt.Sprintf("Too many failed attempts. Try again in %d seconds", seconds),
+	}
+}
+
+// logAttempt writes the PIN attempt to access_logs (door service skips "pin")
+func (s *pinService) logAttempt(status string, userID *uint, guestLabel string) {
+	accessLog := &models.AccessLog{
+		UserID: userID,
+		Method: "pin",
+		Status: status,
+	}
+	if guestLabel != "" {
+		accessLog.GuestLabel = &guestLabel
+	}
+	if err := s.accessLogRepo.Create(accessLog); err != nil {
+		log.Printf("⚠️ Failed to save PIN access log: %v", err)
+		return
+	}
+	s.events.Publish(models.EventAccessLog, accessLog)
 }
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+var ErrNonPublicAddress = errors.New("destination is not a public internet address")
+
+const publicDialTimeout = 10 * time.Second
+
+// 100.64.0.0/10 (carrier-grade NAT) is not covered by net.IP.IsPrivate
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// publicAddress - global unicast outside the private, shared, loopback and link-local ranges
+func publicAddress(ip net.IP) bool {
+	if ip == nil || !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	if ip4 := ip.To4(); ip4 != nil && (sharedAddressSpace.Contains(ip4) || ip4[0] == 0) {
+		return false
+	}
+	return true
+}
+
+// checkPublicHost rejects a host that is, or resolves to, a non-public address
+func checkPublicHost(host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		if !publicAddress(ip) {
+			return ErrNonPublicAddress
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if !publicAddress(addr.IP) {
+			return ErrNonPublicAddress
+		}
+	}
+	return nil
+}
+
+// NewPublicHTTPClient is for user-supplied URLs: the resolved address is checked
+// again at dial time (DNS can change after validation) and redirects are not followed
+func NewPublicHTTPClient() *http.Client {
+	dialer := &net.Dialer{Timeout: publicDialTimeout, Control: publicDialControl}
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: publicDialTimeout,
+		},
+		CheckRedirect: noRedirects,
+	}
+}
+
+func publicDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if !publicAddress(net.ParseIP(host)) {
+		return ErrNonPublicAddress
+	}
+	return nil
+}
+
+// noRedirects hands the 3xx response back to the caller instead of following it
+func noRedirects(req *http.Request, via []*http.Request) error {
+	return http.ErrUseLastResponse
+}
@@ -2,26 +2,101 @@ package service
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
+	"math/rand"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 )
 
-// PythonFaceClient handles communication with Python Face Recognition Service
+// ErrFaceServiceUnavailable is returned while the circuit breaker is open
+var ErrFaceServiceUnavailable = errors.New("face service unavailable (circuit open)")
+
+// FaceServiceError is a 4xx answer from the Python service (bad image, no face, ...).
+// It is not retried and does not count against the circuit breaker.
+type FaceServiceError struct {
+	Status  int
+	Message string
+}
+
+func (e *FaceServiceError) Error() string {
+	return fmt.Sprintf("face service rejected request (status %d): %s", e.Status, e.Message)
+}
+
+// Circuit breaker states
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+// PythonFaceClientConfig - zero values fall back to sensible defaults
+type PythonFaceClientConfig struct {
+	BaseURL          string
+	Timeout          time.Duration // per attempt
+	MaxRetries       int           // extra attempts for network errors / 5xx
+	RetryBaseDelay   time.Duration // backoff base, full jitter
+	BreakerThreshold int           // consecutive failures that open the circuit
+	BreakerCooldown  time.Duration // open → half-open after this long
+}
+
+// FaceServiceHealth is the client's view of the Python service
+type FaceServiceHealth struct {
+	Status              string     `json:"status"` // healthy, degraded, unavailable
+	Breaker             string     `json:"breaker"`
+	BaseURL             string     `json:"base_url"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastSuccessAt       *time.Time `json:"last_success_at,omitempty"`
+	LastFailureAt       *time.Time `json:"last_failure_at,omitempty"`
+	OpenUntil           *time.Time `json:"open_until,omitempty"`
+}
+
+// PythonFaceClient handles communication with Python Face Recognition Service.
+// Every call goes through the same retry policy and circuit breaker.
 type PythonFaceClient struct {
-	baseURL string
-	client  *http.Client
+	cfg    PythonFaceClientConfig
+	client *http.Client
+
+	mu            sync.Mutex
+	state         string
+	failures      int
+	openUntil     time.Time
+	probing       bool // half-open: one request is testing the service
+	lastError     string
+	lastSuccessAt *time.Time
+	lastFailureAt *time.Time
 }
 
 // NewPythonFaceClient creates a new PythonFaceClient instance
-func NewPythonFaceClient(baseURL string) *PythonFaceClient {
+func NewPythonFaceClient(cfg PythonFaceClientConfig) *PythonFaceClient {
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.RetryBaseDelay <= 0 {
+		cfg.RetryBaseDelay = 200 * time.Millisecond
+	}
+	if cfg.BreakerThreshold <= 0 {
+		cfg.BreakerThreshold = 5
+	}
+	if cfg.BreakerCooldown <= 0 {
+		cfg.BreakerCooldown = 30 * time.Second
+	}
+
 	return &PythonFaceClient{
-		baseURL: baseURL,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		cfg:    cfg,
+		client: &http.Client{},
+		state:  BreakerClosed,
 	}
 }
 
@@ -38,40 +113,12 @@ type ValidateFaceResponse struct {
 }
 
 // ValidateFace validates if the image contains exactly one face
-func (c *PythonFaceClient) ValidateFace(imageBase64 string) (*ValidateFaceResponse, error) {
-	reqBody := ValidateFaceRequest{
-		Image: imageBase64,
-	}
-
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	resp, err := c.client.Post(
-		fmt.Sprintf("%s/validate-face", c.baseURL),
-		"application/json",
-		bytes.NewBuffer(jsonData),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to call Python service: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Python service error (status %d): %s", resp.StatusCode, string(body))
-	}
-
+func (c *PythonFaceClient) ValidateFace(ctx context.Context, imageBase64 string) (*ValidateFaceResponse, error) {
 	var result ValidateFaceResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	err := c.do(ctx, http.MethodPost, "/validate-face", ValidateFaceRequest{Image: imageBase64}, &result)
+	if err != nil {
+		return nil, err
 	}
-
 	return &result, nil
 }
 
@@ -87,45 +134,31 @@ type EnrollFaceResponse struct {
 	Success bool   `json:"success"`
 	File    string `json:"file"`
 	Message string `json:"message"`
+	Error   string `json:"error,omitempty"`
+}
+
+// failure turns an unsuccessful enrollment into an error
+func (r *EnrollFaceResponse) failure() error {
+	switch {
+	case r.Error != "":
+		return errors.New(r.Error)
+	case r.Message != "":
+		return errors.New(r.Message)
+	}
+	return errors.New("face enrollment failed")
 }
 
 // EnrollFace enrolls a new face for the given user
-func (c *PythonFaceClient) EnrollFace(userID int, name, imageBase64 string) (*EnrollFaceResponse, error) {
-	reqBody := EnrollFaceRequest{
+func (c *PythonFaceClient) EnrollFace(ctx context.Context, userID int, name, imageBase64 string) (*EnrollFaceResponse, error) {
+	var result EnrollFaceResponse
+	err := c.do(ctx, http.MethodPost, "/enroll-base64", EnrollFaceRequest{
 		UserID: userID,
 		Name:   name,
 		Image:  imageBase64,
-	}
-
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	resp, err := c.client.Post(
-		fmt.Sprintf("%s/enroll-base64", c.baseURL),
-		"application/json",
-		bytes.NewBuffer(jsonData),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to call Python service: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+	}, &result)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Python service error (status %d): %s", resp.StatusCode, string(body))
-	}
-
-	var result EnrollFaceResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+		return nil, err
 	}
-
 	return &result, nil
 }
 
@@ -144,40 +177,12 @@ type RecognizeFaceResponse struct {
 }
 
 // RecognizeFace recognizes a face from the given image
-func (c *PythonFaceClient) RecognizeFace(imageBase64 string) (*RecognizeFaceResponse, error) {
-	reqBody := RecognizeFaceRequest{
-		Image: imageBase64,
-	}
-
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	resp, err := c.client.Post(
-		fmt.Sprintf("%s/recognize-base64", c.baseURL),
-		"application/json",
-		bytes.NewBuffer(jsonData),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to call Python service: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Python service error (status %d): %s", resp.StatusCode, string(body))
-	}
-
+func (c *PythonFaceClient) RecognizeFace(ctx context.Context, imageBase64 string) (*RecognizeFaceResponse, error) {
 	var result RecognizeFaceResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	err := c.do(ctx, http.MethodPost, "/recognize-base64", RecognizeFaceRequest{Image: imageBase64}, &result)
+	if err != nil {
+		return nil, err
 	}
-
 	return &result, nil
 }
 
@@ -195,61 +200,234 @@ type GetEnrolledFacesResponse struct {
 }
 
 // GetEnrolledFaces retrieves all enrolled faces from the Python service
-func (c *PythonFaceClient) GetEnrolledFaces() (*GetEnrolledFacesResponse, error) {
-	resp, err := c.client.Get(fmt.Sprintf("%s/faces", c.baseURL))
-	if err != nil {
-		return nil, fmt.Errorf("failed to call Python service: %w", err)
+func (c *PythonFaceClient) GetEnrolledFaces(ctx context.Context) (*GetEnrolledFacesResponse, error) {
+	var result GetEnrolledFacesResponse
+	if err := c.do(ctx, http.MethodGet, "/faces", nil, &result); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
+	return &result, nil
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+// DeleteFace removes a user's face encoding; a face that is already gone is not an error
+func (c *PythonFaceClient) DeleteFace(ctx context.Context, userID uint) error {
+	err := c.do(ctx, http.MethodDelete, fmt.Sprintf("/faces/%d", userID), nil, nil)
+	var svcErr *FaceServiceError
+	if errors.As(err, &svcErr) && svcErr.Status == http.StatusNotFound {
+		return nil
 	}
+	return err
+}
+
+// ReloadFaces triggers the Python service to reload face encodings from disk
+func (c *PythonFaceClient) ReloadFaces(ctx context.Context) error {
+	return c.do(ctx, http.MethodPost, "/reload", nil, nil)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Python service error (status %d): %s", resp.StatusCode, string(body))
+// HealthCheck checks if the Python service is running (bypasses the breaker,
+// but a success closes it)
+func (c *PythonFaceClient) HealthCheck(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	_, err := c.attempt(ctx, http.MethodGet, "/health", nil, nil)
+	c.record(err)
+	return err
+}
+
+// Health returns the breaker state and recent results
+func (c *PythonFaceClient) Health() FaceServiceHealth {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	health := FaceServiceHealth{
+		Status:              "healthy",
+		Breaker:             c.state,
+		BaseURL:             c.cfg.BaseURL,
+		ConsecutiveFailures: c.failures,
+		LastError:           c.lastError,
+		LastSuccessAt:       c.lastSuccessAt,
+		LastFailureAt:       c.lastFailureAt,
+	}
+	switch {
+	case c.state != BreakerClosed:
+		health.Status = "unavailable"
+		openUntil := c.openUntil
+		health.OpenUntil = &openUntil
+	case c.failures > 0:
+		health.Status = "degraded"
 	}
+	return health
+}
 
-	var result GetEnrolledFacesResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+// ==================== TRANSPORT ====================
+
+// do runs one logical call: breaker check, then attempts with jittered backoff
+func (c *PythonFaceClient) do(ctx context.Context, method, path string, body, out interface{}) error {
+	if err := c.allow(); err != nil {
+		return err
 	}
 
-	return &result, nil
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+	}
+
+	var err error
+	for attempt := 0; attempt <= c.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if waitErr := c.backoff(ctx, attempt); waitErr != nil {
+				err = waitErr
+				break
+			}
+		}
+
+		attemptCtx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+		var retryable bool
+		retryable, err = c.attempt(attemptCtx, method, path, payload, out)
+		cancel()
+
+		if err == nil || !retryable || ctx.Err() != nil {
+			break
+		}
+		log.Printf("[FACE] %s %s failed (attempt %d): %v", method, path, attempt+1, err)
+	}
+
+	c.record(err)
+	return err
 }
 
-// ReloadFaces triggers the Python service to reload face encodings from disk
-func (c *PythonFaceClient) ReloadFaces() error {
-	resp, err := c.client.Post(
-		fmt.Sprintf("%s/reload", c.baseURL),
-		"application/json",
-		nil,
-	)
+// attempt performs a single HTTP request; retryable reports whether trying again may help
+func (c *PythonFaceClient) attempt(ctx context.Context, method, path string, payload []byte, out interface{}) (bool, error) {
+	var reader io.Reader
+	if payload != nil {
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.cfg.BaseURL+path, reader)
 	if err != nil {
-		return fmt.Errorf("failed to call Python service: %w", err)
+		return false, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("failed to call Python service: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("Python service error (status %d): %s", resp.StatusCode, string(body))
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return true, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	switch {
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+		return true, fmt.Errorf("Python service error (status %d): %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	case resp.StatusCode >= 400:
+		return false, &FaceServiceError{Status: resp.StatusCode, Message: errorMessage(respBody)}
 	}
 
+	if out == nil || len(respBody) == 0 {
+		return false, nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return false, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return false, nil
+}
+
+// backoff sleeps base*2^(attempt-1) with full jitter, or until ctx is done
+func (c *PythonFaceClient) backoff(ctx context.Context, attempt int) error {
+	max := c.cfg.RetryBaseDelay << (attempt - 1)
+	delay := time.Duration(rand.Int63n(int64(max) + 1))
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ==================== CIRCUIT BREAKER ====================
+
+func (c *PythonFaceClient) allow() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch c.state {
+	case BreakerOpen:
+		if time.Now().Before(c.openUntil) {
+			return ErrFaceServiceUnavailable
+		}
+		c.state = BreakerHalfOpen
+		c.probing = true
+		log.Println("[FACE] Circuit half-open, probing face service")
+		return nil
+	case BreakerHalfOpen:
+		if c.probing {
+			return ErrFaceServiceUnavailable
+		}
+		c.probing = true
+	}
 	return nil
 }
 
-// HealthCheck checks if the Python service is running
-func (c *PythonFaceClient) HealthCheck() error {
-	resp, err := c.client.Get(fmt.Sprintf("%s/health", c.baseURL))
-	if err != nil {
-		return fmt.Errorf("Python service unavailable: %w", err)
+// record updates the breaker; 4xx answers prove the service is up and
+// callers giving up (context canceled) say nothing about it
+func (c *PythonFaceClient) record(err error) {
+	var svcErr *FaceServiceError
+	failed := err != nil && !errors.As(err, &svcErr) &&
+		!errors.Is(err, ErrFaceServiceUnavailable) && !errors.Is(err, context.Canceled)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	c.probing = false
+
+	if !failed {
+		if err == nil || svcErr != nil {
+			if c.state != BreakerClosed {
+				log.Println("[FACE] Circuit closed, face service recovered")
+			}
+			c.state = BreakerClosed
+			c.failures = 0
+			c.lastSuccessAt = &now
+		}
+		return
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("Python service unhealthy (status %d)", resp.StatusCode)
+	c.failures++
+	c.lastError = err.Error()
+	c.lastFailureAt = &now
+	if c.state == BreakerHalfOpen || c.failures >= c.cfg.BreakerThreshold {
+		if c.state != BreakerOpen {
+			log.Printf("[FACE] Circuit open for %s after %d failure(s): %v", c.cfg.BreakerCooldown, c.failures, err)
+		}
+		c.state = BreakerOpen
+		c.openUntil = now.Add(c.cfg.BreakerCooldown)
 	}
+}
 
-	return nil
+// errorMessage extracts {"error": ...} or {"message": ...} from a Python error body
+func errorMessage(body []byte) string {
+	var parsed struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &parsed); err == nil {
+		if parsed.Error != "" {
+			return parsed.Error
+		}
+		if parsed.Message != "" {
+			return parsed.Message
+		}
+	}
+	return strings.TrimSpace(string(body))
 }
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"smarthome-backend/database/models"
+	"smarthome-backend/internal/repository"
+	"sync"
+	"time"
+)
+
+type SceneService interface {
+	Create(req models.SceneRequest, createdBy *uint) (*models.Scene, error)
+	Update(id uint, req models.SceneRequest) (*models.Scene, error)
+	Delete(id uint) error
+	GetByID(id uint) (*models.Scene, error)
+	GetAll() ([]models.Scene, error)
+	GetActivations(sceneID *uint, limit int) ([]models.SceneActivation, error)
+
+	// Activate sends every action of the scene; wait > 0 also waits for device confirmations
+	Activate(id uint, userID *uint, wait time.Duration) (*models.SceneActivation, error)
+}
+
+type sceneService struct {
+	repo     repository.SceneRepository
+	actuator DeviceActuator
+
+	// One activation at a time so two scenes never interleave their commands
+	mu sync.Mutex
+}
+
+func NewSceneService(r repository.SceneRepository, actuator DeviceActuator) SceneService {
+	return &sceneService{repo: r, actuator: actuator}
+}
+
+func (s *sceneService) Create(req models.SceneRequest, createdBy *uint) (*models.Scene, error) {
+	scene := &models.Scene{CreatedBy: createdBy}
+	if err := applySceneRequest(scene, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(scene); err != nil {
+		return nil, err
+	}
+	return scene, nil
+}
+
+func (s *sceneService) Update(id uint, req models.SceneRequest) (*models.Scene, error) {
+	scene, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applySceneRequest(scene, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(scene); err != nil {
+		return nil, err
+	}
+	return scene, nil
+}
+
+func (s *sceneService) Delete(id uint) error {
+	return s.repo.Delete(id)
+}
+
+func (s *sceneService) GetByID(id uint) (*models.Scene, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *sceneService) GetAll() ([]models.Scene, error) {
+	return s.repo.GetAll()
+}
+
+func (s *sceneService) GetActivations(sceneID *uint, limit int) ([]models.SceneActivation, error) {
+	return s.repo.GetActivations(sceneID, limit)
+}
+
+// Activate - The whole scene is validated before anything is published, so a
+// broken scene sends nothing. Commands then go out back-to-back and each device
+// is reported separately (MQTT has no cross-device transaction to roll back).
+func (s *sceneService) Activate(id uint, userID *uint, wait time.Duration) (*models.SceneActivation, error) {
+	scene, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	for i, action := range scene.Actions {
+		if err := validateDeviceAction(action.Device, action.Action, true); err != nil {
+			return nil, fmt.Errorf("action %d: %w", i+1, err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	activatedAt := time.Now()
+	sceneID := scene.ID
+	results := make(models.RuleActionResults, len(scene.Actions))
+
+	for i, action := range scene.Actions {
+		results[i] = models.RuleActionResult{Device: action.Device, Action: action.Action}
+
+		// Scenes are started by a person, so lamp/curtain default to manual like /api/control
+		mode := action.Mode
+		if mode == "" {
+			mode = "manual"
+		}
+
+		cmd, err := s.actuator.Send(commandRequest(action.Device, action.Action, mode, "scene", &sceneID, userID))
+		if cmd != nil {
+			results[i].CorrelationID = cmd.CorrelationID
+			results[i].Status = cmd.Status
+		}
+		if err != nil {
+			results[i].Status = "failed"
+			results[i].Error = err.Error()
+		}
+	}
+
+	if wait > 0 {
+		s.waitForResults(results, wait)
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Status == "failed" || result.Status == "timed_out" {
+			failed++
+		}
+	}
+	status := "success"
+	if failed == len(results) {
+		status = "failed"
+	} else if failed > 0 {
+		status = "partial"
+	}
+
+	if err := s.repo.MarkActivated(scene.ID, userID, activatedAt); err != nil {
+		log.Printf("[SCENE] Failed to update scene %d: %v", scene.ID, err)
+	}
+
+	entry := &models.SceneActivation{
+		SceneID:     scene.ID,
+		SceneName:   scene.Name,
+		UserID:      userID,
+		Status:      status,
+		Results:     results,
+		ActivatedAt: activatedAt,
+	}
+	if err := s.repo.CreateActivation(entry); err != nil {
+		log.Printf("[SCENE] Failed to save activation log: %v", err)
+	}
+
+	log.Printf("[SCENE] %q activated (%s, %d/%d ok)", scene.Name, status, len(results)-failed, len(results))
+	return entry, nil
+}
+
+// waitForResults waits for all pending commands in parallel, sharing one deadline
+func (s *sceneService) waitForResults(results models.RuleActionResults, wait time.Duration) {
+	var wg sync.WaitGroup
+	for i := range results {
+		if results[i].Status != "pending" {
+			continue
+		}
+		wg.Add(1)
+		go func(r *models.RuleActionResult) {
+			defer wg.Done()
+			cmd, err := s.actuator.Wait(r.CorrelationID, wait)
+			if err != nil || cmd == nil {
+				return
+			}
+			r.Status = cmd.Status
+			r.Error = cmd.Error
+		}(&results[i])
+	}
+	wg.Wait()
+}
+
+func applySceneRequest(scene *models.Scene, req models.SceneRequest) error {
+	seen := make(map[string]bool)
+	for i, action := range req.Actions {
+		if err := validateDeviceAction(action.Device, action.Action, true); err != nil {
+			return fmt.Errorf("action %d: %w", i+1, err)
+		}
+		if seen[action.Device] {
+			return errors.New("each device can only appear once in a scene")
+		}
+		seen[action.Device] = true
+	}
+
+	scene.Name = req.Name
+	scene.Description = req.Description
+	scene.Actions = req.Actions
+	return nil
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"smarthome-backend/database/models"
+	"smarthome-backend/internal/repository"
+	"time"
+)
+
+type ScheduleService interface {
+	Create(req models.ScheduleRequest, createdBy *uint) (*models.Schedule, error)
+	Update(id uint, req models.ScheduleRequest) (*models.Schedule, error)
+	Delete(id uint) error
+	GetByID(id uint) (*models.Schedule, error)
+	GetAll() ([]models.Schedule, error)
+	SetPaused(id uint, paused bool) (*models.Schedule, error)
+	SetSkipNext(id uint, skip bool) (*models.Schedule, error)
+
+	// Start runs the scheduler loop in the background (checks every minute)
+	Start()
+}
+
+type scheduleService struct {
+	repo            repository.ScheduleRepository
+	actuator        DeviceActuator
+	defaultTimezone string
+}
+
+func NewScheduleService(r repository.ScheduleRepository, actuator DeviceActuator, defaultTimezone string) ScheduleService {
+	if defaultTimezone == "" {
+		defaultTimezone = "Local"
+	}
+	return &scheduleService{
+		repo:            r,
+		actuator:        actuator,
+		defaultTimezone: defaultTimezone,
+	}
+}
+
+func (s *scheduleService) Create(req models.ScheduleRequest, createdBy *uint) (*models.Schedule, error) {
+	schedule := &models.Schedule{CreatedBy: createdBy}
+	if err := s.applyRequest(schedule, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func (s *scheduleService) Update(id uint, req models.ScheduleRequest) (*models.Schedule, error) {
+	schedule, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyRequest(schedule, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func (s *scheduleService) Delete(id uint) error {
+	return s.repo.Delete(id)
+}
+
+func (s *scheduleService) GetByID(id uint) (*models.Schedule, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *scheduleService) GetAll() ([]models.Schedule, error) {
+	return s.repo.GetAll()
+}
+
+// SetPaused pauses/resumes; resuming recalculates the next run from now
+func (s *scheduleService) SetPaused(id uint, paused bool) (*models.Schedule, error) {
+	schedule, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule.Paused = paused
+	fields := map[string]interface{}{"paused": paused}
+	if !paused {
+		schedule.NextRunAt = s.nextRun(schedule, time.Now())
+		fields["next_run_at"] = schedule.NextRunAt
+	}
+
+	if err := s.repo.UpdateFields(id, fields); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// SetSkipNext skips (or un-skips) only the next occurrence
+func (s *scheduleService) SetSkipNext(id uint, skip bool) (*models.Schedule, error) {
+	schedule, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule.SkipNext = skip
+	if err := s.repo.UpdateFields(id, map[string]interface{}{"skip_next": skip}); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func (s *scheduleService) Start() {
+	go func() {
+		log.Println("[SCHEDULER] Started")
+		for {
+			// Wake at the start of every minute and evaluate that exact minute
+			tick := time.Now().Truncate(time.Minute).Add(time.Minute)
+			time.Sleep(time.Until(tick))
+			s.runDue(tick)
+		}
+	}()
+}
+
+func (s *scheduleService) runDue(tick time.Time) {
+	schedules, err := s.repo.GetActive()
+	if err != nil {
+		log.Printf("[SCHEDULER] Failed to load schedules: %v", err)
+		return
+	}
+
+	for i := range schedules {
+		schedule := &schedules[i]
+
+		spec, loc, err := s.parse(schedule.Cron, schedule.Timezone)
+		if err != nil {
+			log.Printf("[SCHEDULER] Schedule %d has invalid cron/timezone: %v", schedule.ID, err)
+			continue
+		}
+		if !spec.matches(tick.In(loc)) {
+			continue
+		}
+		if schedule.LastRunAt != nil && !schedule.LastRunAt.Before(tick) {
+			continue // already handled this minute
+		}
+
+		s.run(schedule, tick, spec.next(tick, loc))
+	}
+}
+
+func (s *scheduleService) run(schedule *models.Schedule, tick, next time.Time) {
+	fields := map[string]interface{}{
+		"last_run_at": tick,
+		"next_run_at": nullableTime(next),
+	}
+
+	if schedule.SkipNext {
+		log.Printf("[SCHEDULER] Schedule %q skipped once", schedule.Name)
+		fields["skip_next"] = false
+		fields["last_status"] = "skipped"
+		fields["last_command_id"] = ""
+	} else {
+		log.Printf("[SCHEDULER] Running %q: %s → %s", schedule.Name, schedule.Device, schedule.Action)
+
+		scheduleID := schedule.ID
+		cmd, err := s.actuator.Send(commandRequest(schedule.Device, schedule.Action, schedule.Mode, "schedule", &scheduleID, nil))
+		switch {
+		case err != nil:
+			log.Printf("[SCHEDULER] Schedule %q failed: %v", schedule.Name, err)
+			fields["last_status"] = "failed"
+		default:
+			fields["last_status"] = cmd.Status
+		}
+		if cmd != nil {
+			fields["last_command_id"] = cmd.CorrelationID
+		}
+	}
+
+	if err := s.repo.UpdateFields(schedule.ID, fields); err != nil {
+		log.Printf("[SCHEDULER] Failed to update schedule %d: %v", schedule.ID, err)
+	}
+}
+
+func (s *scheduleService) applyRequest(schedule *models.Schedule, req models.ScheduleRequest) error {
+	if req.Timezone == "" {
+		req.Timezone = s.defaultTimezone
+	}
+	if _, _, err := s.parse(req.Cron, req.Timezone); err != nil {
+		return err
+	}
+	if err := validateDeviceAction(req.Device, req.Action, false); err != nil {
+		return err
+	}
+
+	schedule.Name = req.Name
+	schedule.Cron = req.Cron
+	schedule.Timezone = req.Timezone
+	schedule.Device = req.Device
+	schedule.Action = req.Action
+	schedule.Mode = req.Mode
+	schedule.Paused = req.Paused
+	schedule.NextRunAt = s.nextRun(schedule, time.Now())
+	return nil
+}
+
+func (s *scheduleService) parse(expr, timezone string) (*cronSpec, *time.Location, error) {
+	spec, err := parseCron(expr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid cron: %w", err)
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid timezone %q", timezone)
+	}
+	return spec, loc, nil
+}
+
+func (s *scheduleService) nextRun(schedule *models.Schedule, from time.Time) *time.Time {
+	spec, loc, err := s.parse(schedule.Cron, schedule.Timezone)
+	if err != nil {
+		return nil
+	}
+	return nullableTime(spec.next(from, loc))
+}
+
+func nullableTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"encoding/json"
+	"smarthome-backend/internal/repository"
+	"strconv"
+)
+
+// Setting keys
+const (
+	SettingUniversalPinEnabled = "universal_pin_enabled"
+	SettingGasPlaybook         = "gas_playbook"
+	SettingDoorAutoRelock      = "door_auto_relock"
+	SettingSensorThresholds    = "sensor_thresholds"
+)
+
+type SettingService interface {
+	GetBool(key string, defaultValue bool) bool
+	SetBool(key string, value bool, updatedBy *uint) error
+	GetJSON(key string, dest interface{}) error
+	SetJSON(key string, value interface{}, updatedBy *uint) error
+}
+
+type settingService struct {
+	repo repository.SettingRepository
+}
+
+func NewSettingService(r repository.SettingRepository) SettingService {
+	return &settingService{repo: r}
+}
+
+// GetBool returns defaultValue when the key is missing or unparsable
+func (s *settingService) GetBool(key string, defaultValue bool) bool {
+	setting, err := s.repo.Get(key)
+	if err != nil {
+		return defaultValue
+	}
+	value, err := strconv.ParseBool(setting.Value)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func (s *settingService) SetBool(key string, value bool, updatedBy *uint) error {
+	return s.repo.Set(key, strconv.FormatBool(value), updatedBy)
+}
+
+// GetJSON decodes a JSON setting into dest (error when missing or invalid)
+func (s *settingService) GetJSON(key string, dest interface{}) error {
+	setting, err := s.repo.Get(key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(setting.Value), dest)
+}
+
+func (s *settingService) SetJSON(key string, value interface{}, updatedBy *uint) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return s.repo.Set(key, string(b), updatedBy)
+}
@@ -1,12 +1,9 @@
 package service
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
-	"net/http"
-	"os"
 	"smarthome-backend/database/models"
 	"smarthome-backend/internal/repository"
 
@@ -38,7 +35,8 @@ type UserService interface {
 }
 
 type userService struct {
-	repo repository.UserRepository
+	repo       repository.UserRepository
+	faceClient FaceRecognizer
 }
 
 // normalizeRole maps external labels to DB enum values.
@@ -65,8 +63,8 @@ func normalizeStatus(status string) (string, error) {
 	}
 }
 
-func NewUserService(r repository.UserRepository) UserService {
-	return &userService{repo: r}
+func NewUserService(r repository.UserRepository, faceClient FaceRecognizer) UserService {
+	return &userService{repo: r, faceClient: faceClient}
 }
 
 func (s *userService) Register(req models.UserRequest) (*models.User, error) {
@@ -345,71 +343,28 @@ func (s *userService) ReEnrollFace(id uint, imageBase64 string) (*models.User, e
 		return nil, errors.New("user not found")
 	}
 
-	// 1. Delete old face encoding if exists
+	// 1. Delete old face encoding if exists (enrollment overwrites it anyway)
 	if user.FaceEncodingPath != "" {
-		pythonServiceURL := os.Getenv("PYTHON_SERVICE_URL")
-		if pythonServiceURL == "" {
-			pythonServiceURL = "http://localhost:5000"
-		}
-		deleteURL := fmt.Sprintf("%s/faces/%d", pythonServiceURL, id)
-
-		req, _ := http.NewRequest("DELETE", deleteURL, nil)
-		client := &http.Client{}
-		resp, err := client.Do(req)
-		if err != nil {
-			fmt.Printf("Failed to delete old face encoding: %v\n", err)
-		} else {
-			defer resp.Body.Close()
-			if resp.StatusCode == http.StatusOK {
-				fmt.Printf("Old face encoding deleted for user_id: %d\n", id)
-			}
-		}
+		s.deleteFace(id)
 	}
 
 	// 2. Enroll new face via Python service
-	pythonServiceURL := os.Getenv("PYTHON_SERVICE_URL")
-	if pythonServiceURL == "" {
-		pythonServiceURL = "http://localhost:5000"
-	}
-	enrollURL := fmt.Sprintf("%s/enroll-base64", pythonServiceURL)
-	payload := map[string]interface{}{
-		"user_id": id,
-		"name":    user.Name,
-		"image":   imageBase64,
-	}
-
-	jsonData, _ := json.Marshal(payload)
-	resp, err := http.Post(enrollURL, "application/json", bytes.NewBuffer(jsonData))
+	resp, err := s.faceClient.EnrollFace(context.Background(), int(id), user.Name, imageBase64)
 	if err != nil {
 		return nil, errors.New("failed to enroll face: " + err.Error())
 	}
-	defer resp.Body.Close()
-
-	var result map[string]interface{}
-	json.NewDecoder(resp.Body).Decode(&result)
-
-	success, ok := result["success"].(bool)
-	if !ok || !success {
-		errorMsg := "face enrollment failed"
-		if errStr, ok := result["error"].(string); ok {
-			errorMsg = errStr
-		}
-		return nil, errors.New(errorMsg)
+	if !resp.Success {
+		return nil, resp.failure()
 	}
 
 	// 3. Update face_encoding_path in database
 	// Python service returns 'file' field, not 'face_encoding_path'
-	filename, ok := result["file"].(string)
-	if !ok {
-		// Try 'message' field as fallback
-		if msg, ok := result["message"].(string); ok {
-			return nil, errors.New(msg)
-		}
+	if resp.File == "" {
 		return nil, errors.New("invalid response from face service")
 	}
 
 	// Build full path: known_faces/filename.pkl
-	newPath := "known_faces/" + filename
+	newPath := "known_faces/" + resp.File
 
 	err = s.repo.UpdateFacePath(id, newPath)
 	if err != nil {
@@ -430,36 +385,23 @@ func (s *userService) Delete(id uint) error {
 
 	// If user has face encoding, delete it from Python service first
 	if user.FaceEncodingPath != "" {
-		pythonServiceURL := os.Getenv("PYTHON_SERVICE_URL")
-		if pythonServiceURL == "" {
-			pythonServiceURL = "http://localhost:5000"
-		}
-		deleteURL := fmt.Sprintf("%s/faces/%d", pythonServiceURL, id)
-
-		req, err := http.NewRequest("DELETE", deleteURL, nil)
-		if err != nil {
-			// Log error but continue with user deletion
-			fmt.Printf("Failed to create request to delete face encoding: %v\n", err)
-			return err
-		}
-		resp, err := http.DefaultClient.Do(req)
-		if err != nil {
-			// Log error but continue with user deletion
-			fmt.Printf("Failed to delete face encoding from Python service: %v\n", err)
-		} else {
-			defer resp.Body.Close()
-			if resp.StatusCode == http.StatusOK {
-				fmt.Printf("Face encoding deleted for user_id: %d\n", id)
-			} else {
-				fmt.Printf("Python service returned status %d when deleting face\n", resp.StatusCode)
-			}
-		}
+		s.deleteFace(id)
 	}
 
 	// Delete user from database
 	return s.repo.Delete(id)
 }
 
+// deleteFace removes a face encoding; failures are logged but never block
+// re-enrollment or user deletion
+func (s *userService) deleteFace(id uint) {
+	if err := s.faceClient.DeleteFace(context.Background(), id); err != nil {
+		fmt.Printf("Failed to delete face encoding for user_id %d: %v\n", id, err)
+		return
+	}
+	fmt.Printf("Face encoding deleted for user_id: %d\n", id)
+}
+
 func (s *userService) GetPending() ([]models.User, error) {
 	allUsers, err := s.repo.GetAll()
 	if err != nil {
@@ -0,0 +1,506 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"smarthome-backend/database/models"
+	"smarthome-backend/internal/repository"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	webhookMaxAttempts   = 8                // ~21 minutes of retries before dead-lettering
+	webhookBaseBackoff   = 10 * time.Second // doubled after every failed attempt
+	webhookMaxBackoff    = time.Hour
+	webhookTimeout       = 10 * time.Second
+	webhookPollInterval  = 5 * time.Second
+	webhookBatchSize     = 20
+	webhookSignatureHdr  = "X-Webhook-Signature"
+	webhookTimestampHdr  = "X-Webhook-Timestamp"
+	webhookEventHdr      = "X-Webhook-Event"
+	webhookEventIDHdr    = "X-Webhook-ID"
+	webhookDeliveryIDHdr = "X-Webhook-Delivery"
+)
+
+var (
+	ErrDeliveryPending   = errors.New("delivery is still pending")
+	ErrInvalidThresholds = errors.New("invalid sensor thresholds")
+)
+
+var thresholdSensors = map[string]bool{"gas": true, "temperature": true, "humidity": true, "light": true}
+
+// EventPublisher emits domain events to webhook subscribers (never blocks the caller)
+type EventPublisher interface {
+	Publish(eventType string, data interface{})
+}
+
+type WebhookService interface {
+	EventPublisher
+
+	// OnReading emits sensor.threshold when a reading changes band (low/normal/high)
+	OnReading(sensor string, value float64)
+	// Start runs the delivery worker (due deliveries and retries)
+	Start()
+
+	CreateEndpoint(req models.WebhookEndpointRequest, createdBy *uint) (*models.WebhookEndpoint, string, error)
+	UpdateEndpoint(id uint, req models.WebhookEndpointRequest) (*models.WebhookEndpoint, error)
+	DeleteEndpoint(id uint) error
+	GetEndpoint(id uint) (*models.WebhookEndpoint, error)
+	GetEndpoints() ([]models.WebhookEndpoint, error)
+	RotateSecret(id uint) (string, error)
+
+	GetDeliveries(filter models.WebhookDeliveryFilter) ([]models.WebhookDelivery, error)
+	GetDelivery(id uint) (*models.WebhookDelivery, error)
+	// Redeliver queues a copy of a finished (delivered or dead) delivery
+	Redeliver(id uint) (*models.WebhookDelivery, error)
+
+	GetThresholds() models.SensorThresholds
+	UpdateThresholds(thresholds models.SensorThresholds, updatedBy *uint) error
+}
+
+// webhookEnvelope is the signed request body
+type webhookEnvelope struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+type webhookService struct {
+	repo       repository.WebhookRepository
+	settingSvc SettingService
+	client     *http.Client
+	wake       chan struct{}
+
+	mu         sync.Mutex
+	thresholds models.SensorThresholds
+	bands      map[string]string // sensor -> last band
+}
+
+func NewWebhookService(r repository.WebhookRepository, settingSvc SettingService) WebhookService {
+	s := &webhookService{
+		repo:       r,
+		settingSvc: settingSvc,
+		client:     &http.Client{},
+		wake:       make(chan struct{}, 1),
+		thresholds: models.DefaultSensorThresholds(),
+		bands:      make(map[string]string),
+	}
+
+	var stored models.SensorThresholds
+	if err := settingSvc.GetJSON(SettingSensorThresholds, &stored); err == nil && stored != nil {
+		s.thresholds = stored
+	}
+	return s
+}
+
+// ==================== EVENTS ====================
+
+func (s *webhookService) Publish(eventType string, data interface{}) {
+	go s.enqueue(eventType, data)
+}
+
+func (s *webhookService) enqueue(eventType string, data interface{}) {
+	endpoints, err := s.repo.GetSubscribed(eventType)
+	if err != nil {
+		log.Printf("[WEBHOOK] Failed to load endpoints for %s: %v", eventType, err)
+		return
+	}
+	if len(endpoints) == 0 {
+		return
+	}
+
+	eventID, err := newEventID()
+	if err != nil {
+		log.Printf("[WEBHOOK] Failed to generate event ID: %v", err)
+		return
+	}
+	now := time.Now()
+	payload, err := json.Marshal(webhookEnvelope{ID: eventID, Type: eventType, CreatedAt: now, Data: data})
+	if err != nil {
+		log.Printf("[WEBHOOK] Failed to encode %s: %v", eventType, err)
+		return
+	}
+
+	deliveries := make([]models.WebhookDelivery, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		deliveries = append(deliveries, models.WebhookDelivery{
+			EndpointID:    endpoint.ID,
+			EventID:       eventID,
+			EventType:     eventType,
+			Payload:       payload,
+			Status:        models.WebhookPending,
+			NextAttemptAt: &now,
+		})
+	}
+	if err := s.repo.CreateDeliveries(deliveries); err != nil {
+		log.Printf("[WEBHOOK] Failed to queue %s: %v", eventType, err)
+		return
+	}
+	s.kick()
+}
+
+func (s *webhookService) OnReading(sensor string, value float64) {
+	s.mu.Lock()
+	threshold, ok := s.thresholds[sensor]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+
+	band := "normal"
+	if threshold.High != nil && value > *threshold.High {
+		band = "high"
+	} else if threshold.Low != nil && value < *threshold.Low {
+		band = "low"
+	}
+
+	previous, seen := s.bands[sensor]
+	if !seen {
+		previous = "normal"
+	}
+	s.bands[sensor] = band
+	s.mu.Unlock()
+
+	if band == previous {
+		return
+	}
+
+	s.Publish(models.EventSensorThreshold, map[string]interface{}{
+		"sensor":         sensor,
+		"value":          value,
+		"level":          band,
+		"previous_level": previous,
+		"low":            threshold.Low,
+		"high":           threshold.High,
+	})
+}
+
+// ==================== DELIVERY WORKER ====================
+
+func (s *webhookService) Start() {
+	go func() {
+		ticker := time.NewTicker(webhookPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-s.wake:
+			}
+			s.processDue()
+		}
+	}()
+	log.Println("[WEBHOOK] Delivery worker started")
+}
+
+func (s *webhookService) kick() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// processDue sends due deliveries batch by batch; a batch finishes before the
+// next query so a delivery is never attempted twice at once
+func (s *webhookService) processDue() {
+	for {
+		due, err := s.repo.GetDue(time.Now(), webhookBatchSize)
+		if err != nil {
+			log.Printf("[WEBHOOK] Failed to load due deliveries: %v", err)
+			return
+		}
+
+		var wg sync.WaitGroup
+		for i := range due {
+			wg.Add(1)
+			go func(d *models.WebhookDelivery) {
+				defer wg.Done()
+				s.attempt(d)
+			}(&due[i])
+		}
+		wg.Wait()
+
+		if len(due) < webhookBatchSize {
+			return
+		}
+	}
+}
+
+func (s *webhookService) attempt(d *models.WebhookDelivery) {
+	endpoint, err := s.repo.GetEndpoint(d.EndpointID)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		s.deadLetter(d, "endpoint deleted")
+		return
+	case err != nil:
+		log.Printf("[WEBHOOK] Failed to load endpoint #%d: %v", d.EndpointID, err)
+		return
+	case !endpoint.Enabled:
+		s.deadLetter(d, "endpoint disabled")
+		return
+	}
+
+	code, err := s.send(endpoint, d)
+	d.Attempts++
+	d.ResponseCode = code
+	now := time.Now()
+
+	switch {
+	case err == nil:
+		d.Status = models.WebhookDelivered
+		d.DeliveredAt = &now
+		d.NextAttemptAt = nil
+		d.LastError = ""
+	case d.Attempts >= webhookMaxAttempts:
+		s.deadLetter(d, err.Error())
+		return
+	default:
+		next := now.Add(webhookBackoff(d.Attempts))
+		d.NextAttemptAt = &next
+		d.LastError = truncate(err.Error(), 500)
+		log.Printf("[WEBHOOK] Delivery #%d to %s failed (attempt %d): %v", d.ID, endpoint.URL, d.Attempts, err)
+	}
+
+	if err := s.repo.SaveAttempt(d); err != nil {
+		log.Printf("[WEBHOOK] Failed to save delivery #%d: %v", d.ID, err)
+	}
+}
+
+func (s *webhookService) deadLetter(d *models.WebhookDelivery, reason string) {
+	d.Status = models.WebhookDead
+	d.NextAttemptAt = nil
+	d.LastError = truncate(reason, 500)
+	log.Printf("[WEBHOOK] Delivery #%d dead after %d attempts: %s", d.ID, d.Attempts, reason)
+
+	if err := s.repo.SaveAttempt(d); err != nil {
+		log.Printf("[WEBHOOK] Failed to save delivery #%d: %v", d.ID, err)
+	}
+}
+
+// send POSTs the stored payload, signed over "<timestamp>.<body>"
+func (s *webhookService) send(endpoint *models.WebhookEndpoint, d *models.WebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "smarthome-backend")
+	req.Header.Set(webhookEventHdr, d.EventType)
+	req.Header.Set(webhookEventIDHdr, d.EventID)
+	req.Header.Set(webhookDeliveryIDHdr, strconv.FormatUint(uint64(d.ID), 10))
+	req.Header.Set(webhookTimestampHdr, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(webhookSignatureHdr, signWebhook(endpoint.Secret, timestamp, d.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		return resp.StatusCode, fmt.Errorf("HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return resp.StatusCode, nil
+}
+
+// signWebhook - receivers recompute HMAC-SHA256(secret, "<timestamp>.<body>")
+func signWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func webhookBackoff(attempts int) time.Duration {
+	backoff := webhookBaseBackoff
+	for i := 1; i < attempts && backoff < webhookMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > webhookMaxBackoff {
+		backoff = webhookMaxBackoff
+	}
+	return backoff
+}
+
+// ==================== ENDPOINTS ====================
+
+func (s *webhookService) CreateEndpoint(req models.WebhookEndpointRequest, createdBy *uint) (*models.WebhookEndpoint, string, error) {
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, "", err
+	}
+
+	endpoint := &models.WebhookEndpoint{
+		URL:         req.URL,
+		Description: req.Description,
+		Secret:      secret,
+		Events:      models.StringList(req.Events),
+		Enabled:     req.Enabled == nil || *req.Enabled,
+		CreatedBy:   createdBy,
+	}
+	if err := s.repo.CreateEndpoint(endpoint); err != nil {
+		return nil, "", err
+	}
+	return endpoint, secret, nil
+}
+
+func (s *webhookService) UpdateEndpoint(id uint, req models.WebhookEndpointRequest) (*models.WebhookEndpoint, error) {
+	endpoint, err := s.repo.GetEndpoint(id)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint.URL = req.URL
+	endpoint.Description = req.Description
+	endpoint.Events = models.StringList(req.Events)
+	if req.Enabled != nil {
+		endpoint.Enabled = *req.Enabled
+	}
+	if err := s.repo.UpdateEndpoint(endpoint); err != nil {
+		return nil, err
+	}
+	return endpoint, nil
+}
+
+func (s *webhookService) DeleteEndpoint(id uint) error {
+	if _, err := s.repo.GetEndpoint(id); err != nil {
+		return err
+	}
+	return s.repo.DeleteEndpoint(id)
+}
+
+func (s *webhookService) GetEndpoint(id uint) (*models.WebhookEndpoint, error) {
+	return s.repo.GetEndpoint(id)
+}
+
+func (s *webhookService) GetEndpoints() ([]models.WebhookEndpoint, error) {
+	return s.repo.GetEndpoints()
+}
+
+// RotateSecret - the old secret stops working immediately (also for queued retries)
+func (s *webhookService) RotateSecret(id uint) (string, error) {
+	if _, err := s.repo.GetEndpoint(id); err != nil {
+		return "", err
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return "", err
+	}
+	if err := s.repo.UpdateSecret(id, secret); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+// ==================== DELIVERIES ====================
+
+func (s *webhookService) GetDeliveries(filter models.WebhookDeliveryFilter) ([]models.WebhookDelivery, error) {
+	return s.repo.GetDeliveries(filter)
+}
+
+func (s *webhookService) GetDelivery(id uint) (*models.WebhookDelivery, error) {
+	return s.repo.GetDelivery(id)
+}
+
+func (s *webhookService) Redeliver(id uint) (*models.WebhookDelivery, error) {
+	original, err := s.repo.GetDelivery(id)
+	if err != nil {
+		return nil, err
+	}
+	if original.Status == models.WebhookPending {
+		return nil, ErrDeliveryPending
+	}
+
+	now := time.Now()
+	delivery := &models.WebhookDelivery{
+		EndpointID:    original.EndpointID,
+		EventID:       original.EventID,
+		EventType:     original.EventType,
+		Payload:       original.Payload,
+		Status:        models.WebhookPending,
+		NextAttemptAt: &now,
+		RedeliveryOf:  &original.ID,
+	}
+	if err := s.repo.CreateDelivery(delivery); err != nil {
+		return nil, err
+	}
+
+	s.kick()
+	return delivery, nil
+}
+
+// ==================== THRESHOLDS ====================
+
+func (s *webhookService) GetThresholds() models.SensorThresholds {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	thresholds := make(models.SensorThresholds, len(s.thresholds))
+	for sensor, t := range s.thresholds {
+		thresholds[sensor] = t
+	}
+	return thresholds
+}
+
+func (s *webhookService) UpdateThresholds(thresholds models.SensorThresholds, updatedBy *uint) error {
+	for sensor, t := range thresholds {
+		if !thresholdSensors[sensor] {
+			return fmt.Errorf("%w: unknown sensor %q", ErrInvalidThresholds, sensor)
+		}
+		if t.Low == nil && t.High == nil {
+			return fmt.Errorf("%w: %s needs low and/or high", ErrInvalidThresholds, sensor)
+		}
+		if t.Low != nil && t.High != nil && *t.Low >= *t.High {
+			return fmt.Errorf("%w: %s low must be below high", ErrInvalidThresholds, sensor)
+		}
+	}
+
+	if err := s.settingSvc.SetJSON(SettingSensorThresholds, thresholds, updatedBy); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.thresholds = thresholds
+	s.bands = make(map[string]string) // re-evaluated against the new bounds
+	s.mu.Unlock()
+	return nil
+}
+
+// newWebhookSecret returns 32 random bytes as hex
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// newEventID returns a random UUIDv4 string
+func newEventID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
@@ -0,0 +1,104 @@
+package websocket
+
+import (
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	writeWait      = 10 * time.Second
+	pongWait       = 60 * time.Second
+	pingPeriod     = (pongWait * 9) / 10
+	maxMessageSize = 512
+)
+
+// Client is a single dashboard connection
+type Client struct {
+	hub        *Hub
+	conn       *websocket.Conn
+	send       chan []byte
+	types      map[string]bool // empty = all event types
+	remoteAddr string
+}
+
+func newClient(hub *Hub, conn *websocket.Conn, types []string) *Client {
+	filter := make(map[string]bool, len(types))
+	for _, t := range types {
+		if t != "" {
+			filter[t] = true
+		}
+	}
+
+	return &Client{
+		hub:        hub,
+		conn:       conn,
+		send:       make(chan []byte, clientSendBuffer),
+		types:      filter,
+		remoteAddr: conn.RemoteAddr().String(),
+	}
+}
+
+func (c *Client) wants(eventType string) bool {
+	return len(c.types) == 0 || c.types[eventType]
+}
+
+// enqueue never blocks; false means the buffer is full
+func (c *Client) enqueue(payload []byte) bool {
+	select {
+	case c.send <- payload:
+		return true
+	default:
+		return false
+	}
+}
+
+// readPump only exists to process pong/close frames; clients don't send commands
+func (c *Client) readPump() {
+	defer func() {
+		c.hub.unregister(c)
+		c.conn.Close()
+	}()
+
+	c.conn.SetReadLimit(maxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		c.conn.SetReadDeadline(time.Now().Add(pongWait))
+		return nil
+	})
+
+	for {
+		if _, _, err := c.conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+func (c *Client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case payload, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				// Hub closed the channel (slow consumer or shutdown)
+				c.conn.WriteMessage(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "resume with ?since="))
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
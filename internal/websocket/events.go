@@ -0,0 +1,37 @@
+package websocket
+
+import "time"
+
+// Event types pushed to dashboard clients
+const (
+	EventHello           = "hello"
+	EventResync          = "resync"
+	EventTemperature     = "temperature"
+	EventHumidity        = "humidity"
+	EventGas             = "gas"
+	EventLight           = "light"
+	EventLampStatus      = "lamp_status"
+	EventDoorStatus      = "door_status"
+	EventCurtainStatus   = "curtain_status"
+	EventPinVerification = "pin_verification"
+	EventDeviceCommand   = "device_command"
+	EventNotification    = "notification"
+	EventFaceRecognition = "face_recognition"
+	EventFaceAlert       = "face_alert"
+	EventCameraStatus    = "camera_status"
+	EventBuzzerStatus    = "buzzer_status"
+
+	// Fingerprint reader on the door unit
+	EventFingerprintEnroll = "fingerprint_enroll"
+	EventFingerprintMatch  = "fingerprint_match"
+)
+
+// Event is the envelope every WebSocket message is wrapped in.
+// Seq is monotonic per process so clients can resume with ?since=<seq>;
+// a since ahead of the current seq (backend restarted) gets a resync.
+type Event struct {
+	Seq       uint64      `json:"seq"`
+	Type      string      `json:"type"`
+	Data      interface{} `json:"data,omitempty"`
+	Timestamp time.Time   `json:"timestamp"`
+}
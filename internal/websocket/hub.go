@@ -0,0 +1,185 @@
+package websocket
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// Number of recent events kept in memory for ?since= resume
+	defaultHistorySize = 512
+	// Per-client outbound buffer; a client that falls this far behind is dropped.
+	// Fits a full replay plus the hello and a resync message.
+	clientSendBuffer = defaultHistorySize + 2
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// CORS is already open for the REST API (ngrok / mobile app)
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+type bufferedEvent struct {
+	seq       uint64
+	eventType string
+	payload   []byte
+}
+
+// Hub fans out events to every connected dashboard client
+type Hub struct {
+	mu          sync.Mutex
+	clients     map[*Client]struct{}
+	seq         uint64
+	history     []bufferedEvent
+	historySize int
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		clients:     make(map[*Client]struct{}),
+		history:     make([]bufferedEvent, 0, defaultHistorySize),
+		historySize: defaultHistorySize,
+	}
+}
+
+// Broadcast assigns the next sequence number to the event, stores it for
+// resume and pushes it to every client subscribed to its type.
+func (h *Hub) Broadcast(eventType string, data interface{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	payload, err := json.Marshal(Event{
+		Seq:       h.seq,
+		Type:      eventType,
+		Data:      data,
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		log.Printf("[WS] Marshal %s event failed: %v", eventType, err)
+		return
+	}
+
+	if len(h.history) >= h.historySize {
+		copy(h.history, h.history[1:])
+		h.history = h.history[:len(h.history)-1]
+	}
+	h.history = append(h.history, bufferedEvent{seq: h.seq, eventType: eventType, payload: payload})
+
+	for c := range h.clients {
+		if !c.wants(eventType) {
+			continue
+		}
+		if !c.enqueue(payload) {
+			// Backpressure: never block MQTT callbacks on a slow socket.
+			// The client can reconnect with ?since=<last seq> to catch up.
+			log.Printf("[WS] Client %s too slow, disconnecting", c.remoteAddr)
+			h.removeLocked(c)
+		}
+	}
+}
+
+// ClientCount returns the number of connected clients
+func (h *Hub) ClientCount() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients)
+}
+
+// ServeWS upgrades the HTTP request and attaches the connection to the hub.
+// When resume is true, events newer than since are replayed first.
+func (h *Hub) ServeWS(w http.ResponseWriter, r *http.Request, since uint64, resume bool, types []string) error {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return err
+	}
+
+	client := newClient(h, conn, types)
+	h.register(client, since, resume)
+
+	go client.writePump()
+	go client.readPump()
+
+	return nil
+}
+
+func (h *Hub) register(c *Client, since uint64, resume bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.clients[c] = struct{}{}
+	log.Printf("[WS] Client connected: %s (total: %d)", c.remoteAddr, len(h.clients))
+
+	c.enqueue(h.controlMessage(EventHello, map[string]interface{}{
+		"seq": h.seq,
+	}))
+
+	if !resume || since == h.seq {
+		return
+	}
+
+	// Sequence numbers restart with the process, so a position ahead of ours
+	// comes from before a backend restart
+	if since > h.seq {
+		h.resyncLocked(c, "sequence_reset")
+		return
+	}
+
+	// Requested position already fell out of the buffer: tell the client
+	// to reload a fresh snapshot from /api/dashboard/initial.
+	if len(h.history) == 0 || since+1 < h.history[0].seq {
+		h.resyncLocked(c, "history_expired")
+		return
+	}
+
+	for _, evt := range h.history {
+		if evt.seq <= since || !c.wants(evt.eventType) {
+			continue
+		}
+		// Keep the last slot for a resync so a partial replay is never silent
+		if len(c.send) >= cap(c.send)-1 || !c.enqueue(evt.payload) {
+			h.resyncLocked(c, "replay_overflow")
+			return
+		}
+	}
+}
+
+// resyncLocked tells the client to reload a fresh snapshot; caller holds h.mu
+func (h *Hub) resyncLocked(c *Client, reason string) {
+	c.enqueue(h.controlMessage(EventResync, map[string]interface{}{
+		"reason": reason,
+		"seq":    h.seq,
+	}))
+}
+
+func (h *Hub) unregister(c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.removeLocked(c)
+}
+
+func (h *Hub) removeLocked(c *Client) {
+	if _, ok := h.clients[c]; !ok {
+		return
+	}
+	delete(h.clients, c)
+	close(c.send)
+	log.Printf("[WS] Client disconnected: %s (total: %d)", c.remoteAddr, len(h.clients))
+}
+
+// controlMessage builds an unsequenced message (hello/resync) carrying the current seq
+func (h *Hub) controlMessage(eventType string, data interface{}) []byte {
+	payload, _ := json.Marshal(Event{
+		Seq:       h.seq,
+		Type:      eventType,
+		Data:      data,
+		Timestamp: time.Now(),
+	})
+	return payload
+}
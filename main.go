@@ -3,7 +3,9 @@ package main
 import (
 	"fmt"
 	"log"
+	"net/http"
 	"time"
+	_ "time/tzdata" // schedules need IANA zones even on minimal images
 
 	"smarthome-backend/config"
 	"smarthome-backend/internal/handler"
@@ -11,6 +13,7 @@ import (
 	"smarthome-backend/internal/repository"
 	"smarthome-backend/internal/router"
 	"smarthome-backend/internal/service"
+	"smarthome-backend/internal/websocket"
 
 	mqttLib "github.com/eclipse/paho.mqtt.golang"
 )
@@ -22,6 +25,12 @@ func main() {
 
 	// 1. Load Config & DB
 	cfg := config.LoadConfig()
+	if err := cfg.ValidateJWTSecret(); err != nil {
+		log.Fatal("[AUTH] ", err)
+	}
+	if err := cfg.ValidateDeviceAPIKey(); err != nil {
+		log.Fatal("[AUTH] ", err)
+	}
 	db := config.InitDB()
 
 	// 2. Init Repositories
@@ -32,27 +41,159 @@ func main() {
 	doorRepo := repository.NewDoorRepository(db)
 	lampRepo := repository.NewLampRepository(db)
 	curtainRepo := repository.NewCurtainRepository(db)
+	deviceStateRepo := repository.NewDeviceStateRepository(db)
 	userRepo := repository.NewUserRepository(db)
 	accessLogRepo := repository.NewAccessLogRepository(db)
 	pinRepo := repository.NewPinRepository(db)
+	pinAttemptRepo := repository.NewPinAttemptRepository(db)
+	userPinRepo := repository.NewUserPinRepository(db)
+	settingRepo := repository.NewSettingRepository(db)
+	guestCodeRepo := repository.NewGuestCodeRepository(db)
+	deviceCommandRepo := repository.NewDeviceCommandRepository(db)
+	automationRepo := repository.NewAutomationRepository(db)
+	scheduleRepo := repository.NewScheduleRepository(db)
+	sceneRepo := repository.NewSceneRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	notificationChannelRepo := repository.NewNotificationChannelRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	faceRepo := repository.NewFaceRepository(db)
+	cameraCaptureRepo := repository.NewCameraCaptureRepository(db)
+	cameraRepo := repository.NewCameraRepository(db)
+	fingerprintRepo := repository.NewFingerprintRepository(db)
+	buzzerRepo := repository.NewBuzzerRepository(db)
+	alarmRepo := repository.NewAlarmRepository(db)
+	gasIncidentRepo := repository.NewGasIncidentRepository(db)
+
+	// 4. Init WebSocket Hub (live dashboard events) & Services
+	wsHub := websocket.NewHub()
+	// Outbound channels: webhook always, email / chat bot only when configured
+	notifiers := []service.Notifier{service.NewWebhookNotifier(service.NewPublicHTTPClient())}
+	if cfg.SMTPHost != "" {
+		notifiers = append(notifiers, service.NewSMTPNotifier(service.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+		}))
+	}
+	if cfg.ChatBotToken != "" {
+		notifiers = append(notifiers, service.NewChatBotNotifier(cfg.ChatBotAPIURL, cfg.ChatBotToken, &http.Client{}))
+	}
+	notificationChannelSvc := service.NewNotificationChannelService(notificationChannelRepo, service.NotificationDeliveryPolicy{
+		MaxRetries:         cfg.NotifyMaxRetries,
+		RetryBackoff:       2 * time.Second,
+		RateLimitPerMinute: cfg.NotifyRateLimitPerMin,
+		Timeout:            time.Duration(cfg.NotifyTimeoutSeconds) * time.Second,
+	}, notifiers...)
+
+	// Notifications from domain events (also pushed over WebSocket & outbound channels)
+	notificationSvc := service.NewNotificationService(notificationRepo, wsHub, notificationChannelSvc)
+
+	settingSvc := service.NewSettingService(settingRepo)
+	// Signed outgoing webhooks for domain events (access logs, device states, ...)
+	webhookSvc := service.NewWebhookService(webhookRepo, settingSvc)
+	webhookSvc.Start()
 
-	// 4. Init Services
 	gasSvc := service.NewGasService(gasRepo)
 	tempSvc := service.NewTempService(tempRepo)
 	humidSvc := service.NewHumidService(humidRepo)
 	lightSvc := service.NewLightService(lightRepo)
-	doorSvc := service.NewDoorService(doorRepo, accessLogRepo)
-	lampSvc := service.NewLampService(lampRepo)
-	curtainSvc := service.NewCurtainService(curtainRepo)
-	userSvc := service.NewUserService(userRepo)
-	accessLogSvc := service.NewAccessLogService(accessLogRepo)
-	pinSvc := service.NewPinService(pinRepo)
+	doorSvc := service.NewDoorService(doorRepo, accessLogRepo, webhookSvc)
+	lampSvc := service.NewLampService(lampRepo, deviceStateRepo)
+	curtainSvc := service.NewCurtainService(curtainRepo, deviceStateRepo)
+	// Face recognition backend: Python service (retries + circuit breaker) or in-memory mock
+	faceClient, err := service.NewFaceRecognizer(cfg.FaceBackend, service.PythonFaceClientConfig{
+		BaseURL:          cfg.PythonServiceURL,
+		Timeout:          time.Duration(cfg.FaceTimeoutSeconds) * time.Second,
+		MaxRetries:       cfg.FaceMaxRetries,
+		BreakerThreshold: cfg.FaceBreakerThreshold,
+		BreakerCooldown:  time.Duration(cfg.FaceBreakerCooldownSeconds) * time.Second,
+	})
+	if err != nil {
+		log.Fatal("[FACE] ", err)
+	}
+	userSvc := service.NewUserService(userRepo, faceClient)
+	accessLogSvc := service.NewAccessLogService(accessLogRepo, webhookSvc)
+	guestCodeSvc := service.NewGuestCodeService(guestCodeRepo, userPinRepo, pinRepo)
+	pinSvc := service.NewPinService(pinRepo, userPinRepo, pinAttemptRepo, accessLogRepo, settingSvc, guestCodeSvc, notificationSvc, webhookSvc, service.PinLockoutPolicy{
+		MaxAttempts:       cfg.PinMaxAttempts,
+		BaseLockout:       time.Duration(cfg.PinLockoutSeconds) * time.Second,
+		MaxLockout:        time.Duration(cfg.PinLockoutMaxSeconds) * time.Second,
+		GlobalMaxAttempts: cfg.PinGlobalMaxAttempts,
+	})
 	sensorAnalyticsSvc := service.NewSensorAnalyticsService(db)
+	// Recognition logs & unknown-face alerts pushed by the Python service
+	faceSvc := service.NewFaceService(faceRepo, notificationSvc, wsHub)
+	// Fingerprint slots on the door sensor → users; unlocks are logged as method "fingerprint".
+	// Matches must be signed with the door unit's DEVICE_SIGNING_KEYS entry and share the PIN lockout.
+	deviceAuth := service.NewDeviceAuthenticator(cfg.DeviceSigningKeys)
+	if len(cfg.DeviceSigningKeys) == 0 {
+		log.Println("⚠️  [FINGERPRINT] DEVICE_SIGNING_KEYS is empty, fingerprint matches will be rejected")
+	}
+	fingerprintSvc := service.NewFingerprintService(fingerprintRepo, userRepo, accessLogRepo, notificationSvc, webhookSvc, wsHub, deviceAuth, pinSvc, service.FingerprintPolicy{
+		Capacity:      cfg.FingerprintCapacity,
+		EnrollTimeout: time.Duration(cfg.FingerprintEnrollTimeoutSeconds) * time.Second,
+		MinConfidence: cfg.FingerprintMinConfidence,
+	})
+	// Every buzzer command (manual, gas, alarm) and device-reported state change
+	buzzerSvc := service.NewBuzzerService(buzzerRepo)
+	// ESP32-CAM registry; snapshots & MJPEG are proxied so the app never needs the LAN IP
+	cameraSvc := service.NewCameraService(cameraRepo)
+	// ESP32-CAM frames stored under UPLOAD_DIR, purged after CAPTURE_RETENTION_DAYS
+	cameraCaptureSvc := service.NewCameraCaptureService(cameraCaptureRepo, service.CameraCapturePolicy{
+		Dir:       cfg.UploadDir,
+		MaxBytes:  cfg.CaptureMaxBytes,
+		Retention: time.Duration(cfg.CaptureRetentionDays) * 24 * time.Hour,
+	})
+
+	// One-shot: hash any plaintext universal PIN left from older versions
+	if err := pinSvc.MigrateLegacyPins(); err != nil {
+		log.Fatal("[PIN] Legacy PIN migration failed:", err)
+	}
+	if err := accessLogRepo.EnsureGuestLabelColumn(); err != nil {
+		log.Fatal("[DB] access_logs guest_label migration failed:", err)
+	}
+	if err := doorRepo.EnsureMethodEnum(); err != nil {
+		log.Fatal("[DB] door_status method migration failed:", err)
+	}
+	if err := notificationRepo.EnsureTypeEnum(); err != nil {
+		log.Fatal("[DB] notifications type migration failed:", err)
+	}
+	if err := faceRepo.EnsureTables(); err != nil {
+		log.Fatal("[DB] face tables migration failed:", err)
+	}
+	if err := accessLogRepo.EnsureCaptureColumn(); err != nil {
+		log.Fatal("[DB] access_logs capture_id migration failed:", err)
+	}
+	if err := cameraCaptureRepo.EnsureColumns(); err != nil {
+		log.Fatal("[DB] camera_captures migration failed:", err)
+	}
+	if err := cameraRepo.EnsureTable(); err != nil {
+		log.Fatal("[DB] cameras migration failed:", err)
+	}
+	if err := accessLogRepo.EnsureMethodEnum(); err != nil {
+		log.Fatal("[DB] access_logs method migration failed:", err)
+	}
+	if err := fingerprintRepo.EnsureTable(); err != nil {
+		log.Fatal("[DB] fingerprint_data migration failed:", err)
+	}
+	if err := buzzerRepo.EnsureColumns(); err != nil {
+		log.Fatal("[DB] buzzer_log migration failed:", err)
+	}
+	if err := curtainRepo.EnsureStatusColumn(); err != nil {
+		log.Fatal("[DB] curtain_status migration failed:", err)
+	}
+	if err := deviceStateRepo.EnsureTable(); err != nil {
+		log.Fatal("[DB] device_state_events migration failed:", err)
+	}
+	cameraCaptureSvc.StartRetention()
 
 	// =================================================================
-	// [KEMBALI KE LAMA] Hardcode URL & Secret (Supaya tidak Error Config)
+	// JWT signed with JWT_SECRET (validated at startup)
+	// URL face service sekarang dari PYTHON_SERVICE_URL lewat faceClient
 	// =================================================================
-	authSvc := service.NewAuthService("http://192.168.1.113:5001", "jwt-secret-key")
+	authSvc := service.NewAuthService(faceClient, cfg.JWTSecret)
 
 	// ================= SETUP MQTT (HiveMQ) =================
 	opts := mqttLib.NewClientOptions()
@@ -74,22 +215,64 @@ func main() {
 	opts.SetPingTimeout(10 * time.Second)
 	opts.SetWriteTimeout(10 * time.Second)
 
+	// Handler dibuat setelah client, tapi callback baru dipanggil saat Connect()
+	var mqttH *mqtt.MQTTHandler
+	var publisher *mqtt.Publisher
+
 	opts.OnConnectionLost = func(c mqttLib.Client, err error) {
 		log.Printf("[MQTT] Connection Lost: %v", err)
+		mqttH.OnConnectionLost(err)
 	}
 	opts.OnConnect = func(c mqttLib.Client) {
 		log.Println("[MQTT] Connected successfully to HiveMQ!")
+		// Clean session: subscriptions must be restored on every reconnect
+		mqttH.OnConnect(c)
 	}
 
 	mqttClient := mqttLib.NewClient(opts)
+	publisher = mqtt.NewPublisher(
+		mqttClient,
+		cfg.MQTTQueueSize,
+		time.Duration(cfg.MQTTQueueMaxAgeSeconds)*time.Second,
+	)
 
-	if token := mqttClient.Connect(); token.Wait() && token.Error() != nil {
-		log.Fatal("[MQTT] Connection Failed:", token.Error())
+	// 5. Security alarm (intruder notifications, buzzer wired after the MQTT handler)
+	alarmSvc := service.NewAlarmService(alarmRepo, notificationSvc)
+
+	// Control commands are tracked until the device confirms via */status
+	deviceController := mqtt.NewDeviceController(
+		publisher,
+		deviceCommandRepo,
+		wsHub,
+		time.Duration(cfg.CommandTimeoutSeconds)*time.Second,
+		cfg.CommandMaxRetries,
+	)
+	if err := deviceController.RecoverPending(); err != nil {
+		log.Printf("[CMD] Failed to expire stale commands: %v", err)
 	}
 
+	// Rule engine acts through the same controller as /api/control
+	automationSvc := service.NewAutomationService(automationRepo, deviceController)
+	if err := automationSvc.Reload(); err != nil {
+		log.Printf("[AUTOMATION] %v", err)
+	}
+
+	scheduleSvc := service.NewScheduleService(scheduleRepo, deviceController, cfg.Timezone)
+	scheduleSvc.Start()
+
+	sceneSvc := service.NewSceneService(sceneRepo, deviceController)
+
+	// Gas emergency playbook (buzzer, ventilation, evacuation)
+	gasPlaybookSvc := service.NewGasPlaybookService(gasIncidentRepo, settingSvc, notificationSvc, deviceController)
+
+	// Locks the door again after any unlock (delay set by admins), never during a gas incident
+	doorRelockSvc := service.NewDoorRelockService(settingSvc, deviceController, gasPlaybookSvc)
+
 	// 6. Init MQTT Handler
-	mqttH := mqtt.NewMQTTHandler(
+	mqttH = mqtt.NewMQTTHandler(
 		mqttClient,
+		publisher,
+		deviceController,
 		gasSvc,
 		tempSvc,
 		humidSvc,
@@ -98,52 +281,95 @@ func main() {
 		lampSvc,
 		curtainSvc,
 		pinSvc,
+		automationSvc,
+		alarmSvc,
+		gasPlaybookSvc,
+		doorRelockSvc,
+		notificationSvc,
+		webhookSvc,
+		cameraSvc,
+		fingerprintSvc,
+		buzzerSvc,
+		wsHub,
 	)
+	// Alarm & gas playbook sound the buzzer through the MQTT handler
+	alarmSvc.SetBuzzer(mqttH)
+	gasPlaybookSvc.SetBuzzer(mqttH)
+	fingerprintSvc.SetSensor(mqttH)
 
-	// 7. Setup Routes
-	mqttH.SetupRoutes(mqttClient)
+	// 7. Connect (subscriptions are set up in OnConnect)
+	if token := mqttClient.Connect(); token.Wait() && token.Error() != nil {
+		log.Fatal("[MQTT] Connection Failed:", token.Error())
+	}
 
 	// 8. Init Handlers (HTTP)
 	gasHandler := handler.NewGasHandler(gasSvc)
 	tempHandler := handler.NewTempHandler(tempSvc)
 	humidHandler := handler.NewHumidHandler(humidSvc)
 	lightHandler := handler.NewLightHandler(lightSvc)
-	doorHandler := handler.NewDoorHandler(doorSvc, pinSvc, mqttClient)
+	doorHandler := handler.NewDoorHandler(doorSvc, pinSvc, doorRelockSvc, deviceController)
 	lampHandler := handler.NewLampHandler(lampSvc)
 	curtainHandler := handler.NewCurtainHandler(curtainSvc)
-	userHandler := handler.NewUserHandler(userSvc)
+	userHandler := handler.NewUserHandler(userSvc, pinSvc)
 	accessLogHandler := handler.NewAccessLogHandler(accessLogSvc)
-	authHandler := handler.NewAuthHandler(userSvc, authSvc)
-	adminHandler := handler.NewAdminHandler(pinSvc, userSvc)
+	authHandler := handler.NewAuthHandler(userSvc, authSvc, notificationSvc)
+	adminHandler := handler.NewAdminHandler(pinSvc, userSvc, notificationSvc, webhookSvc)
+	guestCodeHandler := handler.NewGuestCodeHandler(guestCodeSvc)
 
-	deviceControlHandler := handler.NewDeviceControlHandler(
-		mqttClient,
-		lampSvc,
-		doorSvc,
-		curtainSvc,
-	)
+	deviceControlHandler := handler.NewDeviceControlHandler(publisher, deviceController, mqttH)
+	automationHandler := handler.NewAutomationHandler(automationSvc)
+	scheduleHandler := handler.NewScheduleHandler(scheduleSvc)
+	sceneHandler := handler.NewSceneHandler(sceneSvc, deviceController.DefaultWait())
 
-	faceHandler := handler.NewFaceHandler(accessLogSvc, mqttClient)
+	faceHandler := handler.NewFaceHandler(accessLogSvc, faceSvc, faceClient, cameraCaptureSvc, deviceController, alarmSvc, notificationSvc)
+	alarmHandler := handler.NewAlarmHandler(alarmSvc)
+	notificationHandler := handler.NewNotificationHandler(notificationSvc)
+	notificationChannelHandler := handler.NewNotificationChannelHandler(notificationChannelSvc)
+	webhookHandler := handler.NewWebhookHandler(webhookSvc)
+	gasIncidentHandler := handler.NewGasIncidentHandler(gasPlaybookSvc)
 	sensorAnalyticsHandler := handler.NewSensorAnalyticsHandler(sensorAnalyticsSvc)
 	dashboardHandler := handler.NewDashboardHandler(tempSvc, humidSvc, gasSvc, lightSvc, lampSvc, doorSvc, curtainSvc)
+	webSocketHandler := handler.NewWebSocketHandler(wsHub)
+	healthHandler := handler.NewHealthHandler(publisher, faceClient)
+	cameraHandler := handler.NewCameraHandler(cameraSvc, cameraCaptureSvc)
+	fingerprintHandler := handler.NewFingerprintHandler(fingerprintSvc)
+	buzzerHandler := handler.NewBuzzerHandler(buzzerSvc)
 
 	// 9. Router Configuration
 	routerCfg := router.AppConfig{
-		GasHandler:             gasHandler,
-		TempHandler:            tempHandler,
-		HumidHandler:           humidHandler,
-		LightHandler:           lightHandler,
-		SensorAnalyticsHandler: sensorAnalyticsHandler,
-		DoorHandler:            doorHandler,
-		LampHandler:            lampHandler,
-		CurtainHandler:         curtainHandler,
-		UserHandler:            userHandler,
-		AccessLogHandler:       accessLogHandler,
-		AuthHandler:            authHandler,
-		AdminHandler:           adminHandler,
-		DeviceControlHandler:   deviceControlHandler,
-		FaceHandler:            faceHandler,
-		DashboardHandler:       dashboardHandler,
+		GasHandler:                 gasHandler,
+		TempHandler:                tempHandler,
+		HumidHandler:               humidHandler,
+		LightHandler:               lightHandler,
+		SensorAnalyticsHandler:     sensorAnalyticsHandler,
+		DoorHandler:                doorHandler,
+		LampHandler:                lampHandler,
+		CurtainHandler:             curtainHandler,
+		BuzzerHandler:              buzzerHandler,
+		UserHandler:                userHandler,
+		AccessLogHandler:           accessLogHandler,
+		AuthHandler:                authHandler,
+		AdminHandler:               adminHandler,
+		GuestCodeHandler:           guestCodeHandler,
+		DeviceControlHandler:       deviceControlHandler,
+		AutomationHandler:          automationHandler,
+		ScheduleHandler:            scheduleHandler,
+		SceneHandler:               sceneHandler,
+		FaceHandler:                faceHandler,
+		CameraHandler:              cameraHandler,
+		FingerprintHandler:         fingerprintHandler,
+		AlarmHandler:               alarmHandler,
+		NotificationHandler:        notificationHandler,
+		NotificationChannelHandler: notificationChannelHandler,
+		WebhookHandler:             webhookHandler,
+		GasIncidentHandler:         gasIncidentHandler,
+		DashboardHandler:           dashboardHandler,
+		WebSocketHandler:           webSocketHandler,
+		HealthHandler:              healthHandler,
+		AuthService:                authSvc,
+		UserService:                userSvc,
+		DeviceAPIKey:               cfg.DeviceAPIKey,
+		TrustedProxies:             cfg.TrustedProxies,
 	}
 	r := router.InitRouter(routerCfg)
 